@@ -1,9 +1,23 @@
 package cli
+{{- if .Wrapper}}
+{{- if .Imports}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{- end}}
+{{range .Doc}}
+// {{.}}
+{{- end}}
+{{- else}}
 
 import (
 	"time"
 
 	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
 )
 
 var _ = time.Time{}
@@ -12,6 +26,7 @@ var _ = time.Time{}
 type Title__ = Type__
 
 // Title__Flag is a flag with type Type__
+{{- end}}
 type Title__Flag struct {
 	Name        string
 	Aliases     []string
@@ -23,14 +38,175 @@ type Title__Flag struct {
 	Hidden      bool
 	TakesFile   bool
 	SkipAltSrc  bool
-
+	Secret      bool
+{{if .Wrapper}}
+{{- range .ValueDoc}}
+	// {{.}}
+{{- end}}
+	Value       {{.Value}}
+	Destination {{.Destination}}
+{{- else}}
 	Value       Title__
 	Destination *Title__
+{{- end}}
+
+	// FromFileFlag names another flag whose value is the path of a file
+	// to read the value of this flag from
+	FromFileFlag string
+
+	// Resolvers are used in order to find a value for the flag when it is
+	// not set on the command line, defaults to the EnvVars then FilePath
+	Resolvers []Resolver
+
+	// EnvTransform is applied to values from the environment, files or
+	// other resolvers before they are parsed, such as to decode base64
+	EnvTransform func(raw string) (string, error)
+
+	// Requires names other flags which must also be set whenever this
+	// flag is set, such as a --tls-cert flag requiring --tls-key
+	Requires []string
+
+	// AllowSchemes reads a value given as file://path from the file,
+	// without a trailing newline, and a value given as env://NAME from the
+	// environment variable, other values are used as given
+	AllowSchemes bool
+
+	// PositionalAlias is the position of an argument, starting from 1,
+	// which is used as the value of the flag if the flag is not given on
+	// the command line, and is then removed from the arguments
+	PositionalAlias int
+
+	// Derive computes the value of the flag once the other flags are
+	// parsed if it is not set, such as a --data-dir of <config-dir>/data.
+	// Derived flags looked up from the Context are derived first, and a
+	// cycle between them is an error. A nil result keeps the default.
+	Derive func(ctx *Context) (interface{}, error)
+{{- if and (not .IsSlice) (ne .Name "bool") (ne .Name "time") (ne .Name "stringMap")}}
+
+	// HasValue marks a zero Value as deliberately chosen so that it is
+	// shown as the default in help output, where it is otherwise omitted
+	HasValue bool
+{{- end}}
+{{- if .IsSlice}}
+
+	// NArgs is the maximum number of arguments consumed by each
+	// occurrence of the flag, negative values consume until the next flag
+	NArgs int
+
+	// Append adds the values given on the command line or from the
+	// environment, files or other resolvers to the default value rather
+	// than replacing it
+	Append bool
+
+	// ResetToken is a value, such as "-", which clears the values of the
+	// flag given before it on the command line along with the default, so
+	// that --tag - --tag new is only new
+	ResetToken string
+
+	// EmptyEnvClears makes a value from the environment, files or other
+	// resolvers equal to EmptyEnvValue set the flag to an empty slice
+	// rather than the default
+	EmptyEnvClears bool
+	// EmptyEnvValue is the value which clears the flag when EmptyEnvClears
+	// is set, defaults to an empty string
+	EmptyEnvValue string
+
+	// NewlineSeparated splits values from the environment, files or other
+	// resolvers on newlines instead of commas, trimming whitespace around
+	// each line and ignoring blank lines, so that each line is an element
+	// which may contain commas
+	NewlineSeparated bool
+
+	// CSVEnv parses values from the environment, files or other resolvers
+	// as CSV fields, so that quoted elements such as "a, b" may contain
+	// commas, spaces or doubled quotes
+	CSVEnv bool
+{{- end}}
+{{- if or .IsSlice (eq .Name "stringMap")}}
+
+	// MinItems and MaxItems limit the number of values of the flag after
+	// it is resolved, a MaxItems of zero is unlimited
+	MinItems int
+	MaxItems int
+
+	// Unique rejects a {{if eq .Name "stringMap"}}key{{else}}value{{end}} which is given more than once
+	Unique bool
+
+	// Formatter formats each element of the default value for help
+	// output, such as to show times as dates, in place of the default
+	// formatting of the element type
+	Formatter func(interface{}) string
+{{- end}}
+{{- if or (eq .Name "time") (eq .Name "timeSlice")}}
+
+	// Layout is the time layout used to display default values and tried
+	// first when parsing values, defaults to time.RFC3339
+	Layout string
+{{- end}}
+{{- if eq .Name "time"}}
+
+	// Keywords are values such as "now" or "today" which are resolved to
+	// a time by calling the func when the value is parsed, before any
+	// other parsing, see DefaultTimeKeywords
+	Keywords map[string]func() time.Time
+{{- end}}
+{{- if or (eq .Name "string") (eq .Name "stringSlice")}}
+
+	// Choices lists the allowed values of the flag, which are also offered
+	// as values by shell completion
+	Choices []string
+{{- end}}
+{{- if eq .Name "string"}}
+
+	// ExpandEnv expands ${VAR} references in default and file values,
+	// including ${VAR:-default} for a default if VAR is unset or empty
+	// and ${VAR:?message} for an error if it is
+	ExpandEnv bool
+
+	// TemplateDefault executes Value as a text/template against the values
+	// of the other flags once they are parsed, such as "{{"{{"}}.region}}-bucket",
+	// if the flag is not set. Other template defaults referenced are
+	// executed first, and a cycle between them is an error.
+	TemplateDefault bool
+{{- end}}
+{{- if or (eq .Name "duration") (eq .Name "durationSlice")}}
+
+	// DurationFormat is DurationFormatGo by default to parse values with
+	// time.ParseDuration, or DurationFormatISO8601 to parse values such as
+	// "P1DT2H" and display the default value in that form
+	DurationFormat string
+{{- end}}
+{{- if eq .Name "duration"}}
+
+	// Humanize displays the default value in help output in a human
+	// readable form such as "1 hour 30 minutes", see HumanizeDuration
+	Humanize bool
+{{- end}}
+{{- if eq .Name "path"}}
+
+	// Expand replaces a leading ~ with the home directory of the user and
+	// ${VAR} references with environment variables in each value
+	Expand bool
+
+	// MustExist, MustBeDir and MustBeFile check that a non-empty path
+	// exists, or exists and is a directory or a regular file, once the
+	// flags are parsed and before the Action is run
+	MustExist  bool
+	MustBeDir  bool
+	MustBeFile bool
+{{- end}}
+{{- if eq .Name "bytes"}}
+
+	// Encoding is BytesEncodingRaw by default, or BytesEncodingBase64 or
+	// BytesEncodingHex to decode each value
+	Encoding string
+{{- end}}
 }
+{{- if not .Wrapper}}
 
 // Apply populates the flag given the flag set and environment
 func (f *Title__Flag) Apply(set *flag.FlagSet) error {
-	return Apply(f, "LongName__", set)
+	return Apply(f, generic.TypeName(f.Value), set)
 }
 
 // Title__ looks up the value of a local Title__Flag, returns
@@ -38,3 +214,4 @@ func (f *Title__Flag) Apply(set *flag.FlagSet) error {
 func (c *Context) Title__(name string) Type__ {
 	return c.Lookup(name, *new(Title__)).(Type__)
 }
+{{- end}}
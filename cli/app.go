@@ -2,10 +2,12 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"sort"
 	"time"
 
@@ -19,20 +21,36 @@ type App struct {
 	Name string
 	// Full name of command for help, defaults to Name
 	HelpName string
-	// Description of the program.
+	// A short description of the program, shown after the name in help
 	Usage string
 	// Text to override the USAGE section of help
 	UsageText string
-	// Description of the program argument format.
+	// A short description of the arguments of the program, shown in the
+	// USAGE section of help
 	ArgsUsage string
 	// Version of the program
 	Version string
-	// Description of the program
+	// A longer explanation of the program, shown in the DESCRIPTION
+	// section of help
 	Description string
+	// Examples of invocations of the program, shown in the EXAMPLES
+	// section of help
+	Examples []*Example
 	// List of commands to execute
 	Commands []*Command
 	// List of flags to parse
 	Flags []Flag
+	// SortFlags lists flags in help sorted by name instead of in the order
+	// they are declared
+	SortFlags bool
+	// SortCommands lists commands in help sorted by name instead of in the
+	// order they are declared, within each category
+	SortCommands bool
+	// PersistentFlags are flags which may also be given after any command
+	// or subcommand, and are read from the Context of each of them. They
+	// are listed separately in help, and a Required persistent flag is
+	// checked for the command which is run.
+	PersistentFlags []Flag
 	// Boolean to enable bash completion commands
 	EnableBashCompletion bool
 	// Boolean to hide built-in help command and help flag
@@ -42,6 +60,9 @@ type App struct {
 	HideHelpCommand bool
 	// Boolean to hide built-in version flag and the VERSION section of help
 	HideVersion bool
+	// VersionPrinter prints the version for the App, overriding the
+	// package level VersionPrinter if set
+	VersionPrinter func(*Context)
 	// categories contains the categorized commands and is populated on app startup
 	categories CommandCategories
 	// An action to execute when the shell completion flag is set
@@ -52,11 +73,21 @@ type App struct {
 	// An action to execute after any subcommands are run, but after the subcommand has finished
 	// It is run even if Action() panics
 	After AfterFunc
+	// Validator is called once the flags are parsed and have passed the
+	// built-in checks, such as for required flags, and before Before and
+	// any commands are run, to check rules which span several flags. An
+	// error is returned by Run without running the App.
+	Validator func(ctx *Context) error
 	// The action to execute when no subcommands are specified
 	Action ActionFunc
+	// DefaultCommand names the command to run when no command is given and
+	// Action is not set, the arguments are passed to the command
+	DefaultCommand string
 	// Execute this function if the proper command cannot be found
 	CommandNotFound CommandNotFoundFunc
-	// Execute this function if an usage error occurs
+	// Execute this function if an usage error occurs, such as an unknown
+	// flag. Commands without an OnUsageError also use this function.
+	// Returning nil ignores the error.
 	OnUsageError OnUsageErrorFunc
 	// Compilation date
 	Compiled time.Time
@@ -68,6 +99,40 @@ type App struct {
 	Writer io.Writer
 	// ErrWriter writes error output
 	ErrWriter io.Writer
+	// Warn is passed each warning of the App, such as a deprecation notice,
+	// to write it to a logger. Defaults to writing the warning to ErrWriter.
+	Warn func(msg string)
+	// WarnDeprecated warns through Warn each time a deprecated feature is
+	// used, such as an Action of the legacy func(*Context) signature
+	WarnDeprecated bool
+	// ValueSources are consulted in order for the value of each flag which
+	// is not set on the command line, from the environment or from a file,
+	// such as to read defaults from a remote configuration service
+	ValueSources []ValueSource
+	// Translator returns the user-facing messages of the App, such as the
+	// headers of help output and error messages, for localization. Defaults
+	// to DefaultTranslator.
+	Translator TranslatorFunc
+	// RecoverPanic recovers from a panic while running the App, such as in
+	// a Before, Action or After func, writing the panic value to ErrWriter
+	// and returning a PanicError instead. It is off by default so that the
+	// stack trace of a bug is not hidden.
+	RecoverPanic bool
+	// PromptMissing prompts for the value of each required flag which is
+	// not set when stdin is a terminal, instead of failing, reading the
+	// values of Secret flags without echo
+	PromptMissing bool
+	// OnComplete is called once Run returns, including after an error or a
+	// panic recovered with RecoverPanic, with the total duration of the
+	// run. The context is that of the command run, or nil if the run ended
+	// before its flags were validated, such as to show help.
+	OnComplete func(ctx *Context, err error, dur time.Duration)
+	// ErrorFormat is ErrorFormatText by default, or ErrorFormatJSON to write
+	// an error returned by Run to ErrWriter as {"error":"...","code":N}
+	// without showing help, for CLIs run by other programs. The process
+	// is not exited by Run on an ExitCoder error, use Main to exit with the
+	// code.
+	ErrorFormat string
 	// ExitErrHandler processes any error encountered while running an App before
 	// it is returned to the caller. If no function is provided, HandleExitCoder
 	// is used as the default behavior.
@@ -84,8 +149,67 @@ type App struct {
 	// single-character bool arguments into one
 	// i.e. foobar -o -v -> foobar -ov
 	UseShortOptionHandling bool
-
-	didSetup bool
+	// BoolFlagWords lets a bool flag take a following true, false, yes, no,
+	// on or off as its value, such as --force false. Such a word after a
+	// bool flag is then never an argument, so with `app --force on start`
+	// the argument is only start.
+	BoolFlagWords bool
+	// Boolean to enable expanding arguments of the form @file to the
+	// whitespace separated arguments contained in file
+	AllowArgFiles bool
+	// ArgsRewriter is called once by Run with the arguments, including the
+	// program name and those expanded from arg files, and returns the
+	// arguments to parse, such as to replace a removed flag with its new name
+	ArgsRewriter func(args []string) []string
+	// FlagsFile names a flag, such as "flags-file", which is added to the
+	// App to read the values of flags from a file with lines such as
+	// --name value, as if given on the command line before the arguments.
+	// Flags given on the command line take precedence over the file.
+	FlagsFile string
+	// GenerateConfig names a hidden bool flag, such as "generate-config",
+	// which is added to the App to write the resolved value of each flag of
+	// the App as a JSON config file to Writer instead of running it, such
+	// as to save a working invocation as a config file for altsrc. Only the
+	// flags of the App are written, not those of its commands. Secret flags
+	// are omitted unless GenerateConfigMaskSecrets is set.
+	GenerateConfig string
+	// GenerateConfigMaskSecrets writes Secret flags to the config written
+	// for GenerateConfig with a masked value, so that the config lists every
+	// flag to be filled in, instead of omitting them
+	GenerateConfigMaskSecrets bool
+	// DotEnvFiles are read in order by Run to set environment variables from
+	// lines such as KEY=value, with later files overriding earlier ones but
+	// never overriding variables already set in the environment. Files which
+	// do not exist are ignored, such as an optional ".env.local".
+	DotEnvFiles []string
+	// EnvPrefix enables reading flags without EnvVars or Resolvers from the
+	// environment variable named by EnvPrefix and EnvNameFunc of the flag name
+	EnvPrefix string
+	// EnvNameFunc converts a flag name to an environment variable name for
+	// EnvPrefix, defaults to upper snake case. Setting EnvNameFunc without
+	// EnvPrefix also enables reading flags from the environment.
+	EnvNameFunc func(flagName string) string
+	// EnvAliases maps an environment variable to the names of the flags it
+	// populates, such as a legacy variable read by several flags. The
+	// variables are tried after any other EnvVars of a flag. Each flag
+	// parses the value as its own type, so flags of different types which
+	// share a variable will fail to parse values invalid for their type.
+	EnvAliases map[string][]string
+	// TrackFlagHistory records each value assigned to a flag with its
+	// source, such as the default then env then the command line, for
+	// Context.History to help debug precedence
+	TrackFlagHistory bool
+
+	didSetup    bool
+	versionFlag Flag
+	middleware  []MiddlewareFunc
+	// parsedContext is the context of the most recently parsed command
+	parsedContext *Context
+	// command is the command with subcommands run as this App
+	command *Command
+	// exited is set once HandleExitCoder has called OsExiter for an error
+	// of a run of the App or of any of its commands
+	exited bool
 }
 
 type showHelpFunc = func(context *Context) error
@@ -160,7 +284,8 @@ func (a *App) Setup() {
 		a.BashComplete = DefaultAppComplete
 	}
 
-	if a.Action == nil {
+	// an App with commands and no Action requires a command, see runContext
+	if a.Action == nil && a.DefaultCommand == "" && len(a.Commands) == 0 {
 		a.Action = helpCommand.Action
 	}
 
@@ -196,8 +321,19 @@ func (a *App) Setup() {
 		}
 	}
 
+	if a.FlagsFile != "" {
+		a.appendFlag(a.newFlagsFileFlag())
+	}
+
+	if a.GenerateConfig != "" {
+		a.appendFlag(a.newGenerateConfigFlag())
+	}
+
 	if !a.HideVersion {
-		a.appendFlag(VersionFlag)
+		a.versionFlag = a.newVersionFlag()
+		if a.versionFlag != nil {
+			a.appendFlag(a.versionFlag)
+		}
 	}
 
 	a.categories = newCommandCategories()
@@ -211,8 +347,51 @@ func (a *App) Setup() {
 	}
 }
 
+// newVersionFlag returns the VersionFlag without any names already used by
+// the App flags, or nil if all of its names are in use
+func (a *App) newVersionFlag() Flag {
+	if VersionFlag == nil || hasFlag(a.Flags, VersionFlag) {
+		return VersionFlag
+	}
+	used := map[string]bool{}
+	for _, f := range a.Flags {
+		for _, name := range FlagNames(f) {
+			used[name] = true
+		}
+	}
+	var names []string
+	for _, name := range FlagNames(VersionFlag) {
+		if !used[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == len(FlagNames(VersionFlag)) {
+		return VersionFlag
+	}
+	boolFlag, ok := VersionFlag.(*BoolFlag)
+	if !ok || len(names) == 0 {
+		return nil
+	}
+	versionFlag := *boolFlag
+	versionFlag.Name = names[0]
+	versionFlag.Aliases = names[1:]
+	return &versionFlag
+}
+
 func (a *App) newFlagSet() (*flag.FlagSet, error) {
-	return flagSet(a.Name, a.Flags)
+	set, err := flagSet(a.Name, a.envFlags(a.allFlags()), a.TrackFlagHistory)
+	if err == nil {
+		set.BoolWords = a.BoolFlagWords
+	}
+	return set, err
+}
+
+// allFlags returns the Flags of the App followed by its PersistentFlags
+func (a *App) allFlags() []Flag {
+	if len(a.PersistentFlags) == 0 {
+		return a.Flags
+	}
+	return append(append([]Flag{}, a.Flags...), a.PersistentFlags...)
 }
 
 func (a *App) useShortOptionHandling() bool {
@@ -221,10 +400,37 @@ func (a *App) useShortOptionHandling() bool {
 
 // Run is the entry point to the cli app. Parses the arguments slice and routes
 // to the proper flag/args combination
+//
+// A flag parse error at any level is returned as a CommandError naming the
+// command whose flags failed to parse, once the After funcs of the commands
+// already entered have run. Its message is prefixed with the command, use
+// errors.Is or errors.As to compare the parse error itself.
 func (a *App) Run(arguments []string) (err error) {
 	return a.RunContext(context.Background(), arguments)
 }
 
+// Main runs the app with os.Args and exits the process on error, calling
+// OsExiter with the code of an ExitCoder, which may be wrapped, otherwise
+// printing the error to ErrWriter and exiting with 1. OsExiter is called once,
+// so Main does not exit again if Run has already exited for an ExitCoder. It
+// is meant to be the only line in main, use Run for testing.
+func (a *App) Main() {
+	a.exited = false
+	err := a.Run(os.Args)
+	if err == nil || a.exited {
+		return
+	}
+	code := 1
+	var exitErr ExitCoder
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	}
+	if _, ok := err.(ExitCoder); !ok && !a.jsonErrors() {
+		fmt.Fprintln(a.ErrWriter, err)
+	}
+	OsExiter(code)
+}
+
 // RunContext is like Run except it takes a Context that will be
 // passed to its commands and sub-commands. Through this, you can
 // propagate timeouts and cancellation requests
@@ -233,7 +439,47 @@ func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
 		return fmt.Errorf("arguments not provided")
 	}
 	a.Setup()
+	a.parsedContext = nil
 
+	// completed is false if the run is panicking
+	completed := false
+	if a.OnComplete != nil {
+		start := time.Now()
+		defer func() {
+			if completed {
+				a.OnComplete(a.parsedContext, err, time.Since(start))
+			}
+		}()
+	}
+
+	if a.jsonErrors() {
+		defer func() {
+			if completed && err != nil {
+				writeJSONError(a.ErrWriter, err)
+			}
+		}()
+	}
+
+	if a.RecoverPanic {
+		defer func() {
+			if r := recover(); r != nil {
+				perr := &PanicError{Value: r, Stack: debug.Stack()}
+				if !a.jsonErrors() {
+					fmt.Fprintln(a.ErrWriter, perr)
+				}
+				err = perr
+				completed = true
+			}
+		}()
+	}
+
+	err = a.runContext(ctx, arguments)
+	completed = true
+	return err
+}
+
+// runContext parses the arguments and runs the App as for RunContext
+func (a *App) runContext(ctx context.Context, arguments []string) (err error) {
 	// handle the completion flag separately from the flagset since
 	// completion could be attempted after a flag, but before its value was put
 	// on the command line. this causes the flagset to interpret the completion
@@ -242,17 +488,35 @@ func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
 	// always appends the completion flag at the end of the command
 	shellComplete, arguments := checkShellCompleteFlag(a, arguments)
 
+	if err := loadDotEnvFiles(a.DotEnvFiles); err != nil {
+		return err
+	}
+
+	if a.AllowArgFiles {
+		expanded, err := expandArgFiles(arguments[1:])
+		if err != nil {
+			return err
+		}
+		arguments = append(arguments[:1:1], expanded...)
+	}
+
+	if a.ArgsRewriter != nil {
+		arguments = a.ArgsRewriter(arguments)
+	}
+
 	set, err := a.newFlagSet()
 	if err != nil {
 		return err
 	}
 
 	err = parseIter(set, a, arguments[1:], shellComplete)
-	nerr := normalizeFlags(a.Flags, set)
+	nerr := normalizeFlags(a.allFlags(), set)
 	context := NewContext(a, set, &Context{Context: ctx})
 	if nerr != nil {
-		fmt.Fprintln(a.Writer, nerr)
-		ShowAppHelp(context)
+		if !a.jsonErrors() {
+			fmt.Fprintln(a.Writer, nerr)
+			ShowAppHelp(context)
+		}
 		return nerr
 	}
 	context.shellComplete = shellComplete
@@ -262,7 +526,7 @@ func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
 	}
 
 	if err != nil {
-		return a.helpOnError(ShowAppHelp, context, showHelpError{err})
+		return a.usageError(ShowAppHelp, context, err, false)
 	}
 
 	if !a.HideHelp && checkHelp(context) {
@@ -275,10 +539,28 @@ func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
 		return nil
 	}
 
-	cerr := checkRequiredFlags(a.Flags, context)
+	ferr, cerr := resolveAndCheckFlags(a.allFlags(), a.PersistentFlags, context)
 	if cerr != nil {
-		ShowAppHelp(context)
-		return cerr
+		if !a.jsonErrors() {
+			ShowAppHelp(context)
+		}
+		return joinErrors(ferr, cerr)
+	}
+	if ferr != nil {
+		a.handleExitCoder(context, ferr)
+		return ferr
+	}
+	setParsedContext(context)
+
+	if generateConfig(context) {
+		return writeConfig(context)
+	}
+
+	if a.Validator != nil {
+		if err := a.Validator(context); err != nil {
+			a.handleExitCoder(context, err)
+			return err
+		}
 	}
 
 	if a.After != nil {
@@ -296,7 +578,7 @@ func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
 
 	if a.Before != nil {
 		if err := a.Before(context); err != nil {
-			return a.helpOnError(ShowAppHelp, context, err)
+			return a.helpOnError(ShowAppHelp, context, err, false)
 		}
 	}
 
@@ -309,27 +591,50 @@ func (a *App) RunContext(ctx context.Context, arguments []string) (err error) {
 		}
 	}
 
-	if a.Action == nil {
-		a.Action = helpCommand.Action
+	c, err := a.defaultCommand(context)
+	if err != nil {
+		a.handleExitCoder(context, err)
+		return err
+	}
+	if c != nil {
+		return c.Run(context)
+	}
+
+	if a.Action == nil && !args.Present() && a.hasCommands() {
+		err := Exit(a.translate(MsgSubcommandRequired), 1)
+		if !a.jsonErrors() {
+			ShowAppHelp(context)
+		}
+		a.handleExitCoder(context, err)
+		return err
+	}
+
+	action := a.Action
+	if action == nil {
+		action = helpCommand.Action
+	}
+
+	if err := a.checkPersistentFlags(context); err != nil {
+		return err
 	}
 
 	// Run default Action
-	err = a.Action(context)
+	err = a.withMiddleware(action)(context)
 
 	a.handleExitCoder(context, err)
 	return err
 }
 
-func (a *App) helpOnError(showHelp showHelpFunc, context *Context, err error) error {
+func (a *App) helpOnError(showHelp showHelpFunc, context *Context, err error, isSubcommand bool) error {
 	if err == nil {
 		return nil
 	}
 	if e, ok := err.(showHelpError); ok {
 		err = e.error
 		if a.OnUsageError != nil {
-			err = a.OnUsageError(context, err, false)
-		} else {
-			fmt.Fprintf(a.Writer, "%s:\n   %s\n\n", "Incorrect Usage", err.Error())
+			err = a.OnUsageError(context, err, isSubcommand)
+		} else if !a.jsonErrors() {
+			fmt.Fprintf(a.Writer, "%s\n   %s\n\n", a.translate(MsgIncorrectUsage), err.Error())
 			showHelp(context)
 		}
 	}
@@ -337,6 +642,16 @@ func (a *App) helpOnError(showHelp showHelpFunc, context *Context, err error) er
 	return err
 }
 
+// usageError handles a flag parse error with helpOnError, returning a
+// CommandError naming the app unless handled by OnUsageError
+func (a *App) usageError(showHelp showHelpFunc, context *Context, err error, isSubcommand bool) error {
+	herr := a.helpOnError(showHelp, context, showHelpError{err}, isSubcommand)
+	if a.OnUsageError != nil {
+		return herr
+	}
+	return newCommandError(a.Name, err)
+}
+
 // RunAndExitOnError calls .Run() and exits non-zero if an error was returned
 //
 // Deprecated: instead you should return an error that fulfills cli.ExitCoder
@@ -370,10 +685,15 @@ func (a *App) RunAsSubcommand(ctx *Context) (err error) {
 	}
 
 	err = parseIter(set, a, ctx.Args().Tail(), ctx.shellComplete)
-	nerr := normalizeFlags(a.Flags, set)
+	nerr := normalizeFlags(a.allFlags(), set)
 	context := NewContext(a, set, ctx)
+	context.Command = a.command
+	inheritPersistentFlags(a.PersistentFlags, context)
 
 	if nerr != nil {
+		if a.jsonErrors() {
+			return nerr
+		}
 		fmt.Fprintln(a.Writer, nerr)
 		fmt.Fprintln(a.Writer)
 		if len(a.Commands) > 0 {
@@ -389,23 +709,39 @@ func (a *App) RunAsSubcommand(ctx *Context) (err error) {
 	}
 
 	if err != nil {
-		return a.helpOnError(ShowSubcommandHelp, context, showHelpError{err})
+		return a.usageError(ShowSubcommandHelp, context, err, true)
 	}
 
-	if len(a.Commands) > 0 {
-		if checkSubcommandHelp(context) {
-			return nil
-		}
-	} else {
-		if checkCommandHelp(ctx, context.Args().First()) {
-			return nil
+	if !a.HideHelp {
+		if len(a.Commands) > 0 {
+			if checkSubcommandHelp(context) {
+				return nil
+			}
+		} else {
+			if checkCommandHelp(ctx, context.Args().First()) {
+				return nil
+			}
 		}
 	}
 
-	cerr := checkRequiredFlags(a.Flags, context)
+	ferr, cerr := resolveAndCheckFlags(a.allFlags(), a.PersistentFlags, context)
 	if cerr != nil {
-		ShowSubcommandHelp(context)
-		return cerr
+		if !a.jsonErrors() {
+			ShowSubcommandHelp(context)
+		}
+		return joinErrors(ferr, cerr)
+	}
+	if ferr != nil {
+		a.handleExitCoder(context, ferr)
+		return ferr
+	}
+	setParsedContext(context)
+
+	if a.Validator != nil {
+		if err := a.Validator(context); err != nil {
+			a.handleExitCoder(context, err)
+			return err
+		}
 	}
 
 	if a.After != nil {
@@ -423,7 +759,7 @@ func (a *App) RunAsSubcommand(ctx *Context) (err error) {
 
 	if a.Before != nil {
 		if err := a.Before(context); err != nil {
-			return a.helpOnError(ShowSubcommandHelp, context, err)
+			return a.helpOnError(ShowSubcommandHelp, context, err, true)
 		}
 	}
 
@@ -436,26 +772,121 @@ func (a *App) RunAsSubcommand(ctx *Context) (err error) {
 		}
 	}
 
+	if a.Action == nil {
+		a.Action = helpCommand.Action
+	}
+
+	if err := a.checkPersistentFlags(context); err != nil {
+		return err
+	}
+
 	// Run default Action
-	err = a.Action(context)
+	err = a.withMiddleware(a.Action)(context)
 
 	a.handleExitCoder(context, err)
 	return err
 }
 
+// hasCommands returns true if the App has any commands other than help
+func (a *App) hasCommands() bool {
+	for _, c := range a.Commands {
+		if c != helpCommand {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultCommand returns the DefaultCommand if no Action is set, and sets
+// the arguments of context to run it
+func (a *App) defaultCommand(context *Context) (*Command, error) {
+	if a.Action != nil || a.DefaultCommand == "" {
+		return nil, nil
+	}
+	c := a.Command(a.DefaultCommand)
+	if c == nil {
+		return nil, errors.New(a.translate(MsgDefaultCommandNotFound, a.DefaultCommand))
+	}
+	// flags are already parsed, so only the arguments are replaced
+	args := append([]string{"--", c.Name}, context.Args().Slice()...)
+	if err := context.flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Use adds middleware to be composed around the Action that is run, with the
+// first middleware added being the outermost. For a command the middleware
+// also wraps its Before and After funcs, while those of the App and of
+// commands with subcommands are run outside of the middleware as they apply
+// to all of their subcommands.
+func (a *App) Use(middleware ...MiddlewareFunc) {
+	a.middleware = append(a.middleware, middleware...)
+}
+
+// withMiddleware returns action wrapped by the middleware of the App
+func (a *App) withMiddleware(action ActionFunc) ActionFunc {
+	for i := len(a.middleware) - 1; i >= 0; i-- {
+		action = a.middleware[i](action)
+	}
+	return action
+}
+
 // Command returns the named command on App. Returns nil if the command does not exist
 func (a *App) Command(name string) *Command {
-	for _, c := range a.Commands {
-		if c.HasName(name) {
-			return c
+	return findCommand(a.Commands, name)
+}
+
+// LookupCommand returns the command found by following the names in path
+// through the commands and subcommands of the App, matching names and aliases
+// as Run does. Returns false if path is empty or any name is not found.
+func (a *App) LookupCommand(path ...string) (*Command, bool) {
+	var command *Command
+	commands := a.Commands
+	for _, name := range path {
+		if command = findCommand(commands, name); command == nil {
+			return nil, false
 		}
+		commands = command.Subcommands
 	}
+	return command, command != nil
+}
 
+// AddCommands appends cmds to the Commands of the App, such as from
+// packages which each register their commands on a shared App when
+// initialized. Returns an error without adding any of cmds if one of their
+// names or aliases is already used by another command.
+func (a *App) AddCommands(cmds ...*Command) error {
+	if err := checkCommandNames(a.Commands, cmds); err != nil {
+		return err
+	}
+	a.Commands = append(a.Commands, cmds...)
+	if a.didSetup {
+		for _, c := range cmds {
+			if c.HelpName == "" {
+				c.HelpName = fmt.Sprintf("%s %s", a.HelpName, c.Name)
+			}
+			a.categories.AddCommand(c.Category, c)
+		}
+		sort.Sort(a.categories.(*commandCategories))
+	}
 	return nil
 }
 
+// Merge adds the commands of other to the App as AddCommands does, other
+// than the help command added by Setup
+func (a *App) Merge(other *App) error {
+	var cmds []*Command
+	for _, c := range other.Commands {
+		if c != helpCommand {
+			cmds = append(cmds, c)
+		}
+	}
+	return a.AddCommands(cmds...)
+}
+
 // VisibleCategories returns a slice of categories and commands that are
-// Hidden=false
+// Hidden=false, with the commands of each sorted if SortCommands is set
 func (a *App) VisibleCategories() []CommandCategory {
 	ret := []CommandCategory{}
 	for _, category := range a.categories.Categories() {
@@ -465,13 +896,17 @@ func (a *App) VisibleCategories() []CommandCategory {
 			}
 			return nil
 		}(); visible != nil {
+			if a.SortCommands {
+				visible = sortedCategory{visible}
+			}
 			ret = append(ret, visible)
 		}
 	}
 	return ret
 }
 
-// VisibleCommands returns a slice of the Commands with Hidden=false
+// VisibleCommands returns a slice of the Commands with Hidden=false,
+// sorted if SortCommands is set
 func (a *App) VisibleCommands() []*Command {
 	var ret []*Command
 	for _, command := range a.Commands {
@@ -479,12 +914,40 @@ func (a *App) VisibleCommands() []*Command {
 			ret = append(ret, command)
 		}
 	}
+	if a.SortCommands {
+		sort.Stable(CommandsByName(ret))
+	}
 	return ret
 }
 
-// VisibleFlags returns a slice of the Flags with Hidden=false
+// VisibleFlags returns a slice of the Flags with Hidden=false, sorted if
+// SortFlags is set
 func (a *App) VisibleFlags() []Flag {
-	return visibleFlags(a.Flags)
+	return a.sortFlags(visibleFlags(a.Flags))
+}
+
+// VisiblePersistentFlags returns a slice of the PersistentFlags with
+// Hidden=false, sorted if SortFlags is set
+func (a *App) VisiblePersistentFlags() []Flag {
+	return a.sortFlags(visibleFlags(a.PersistentFlags))
+}
+
+// sortFlags sorts flags by name if SortFlags is set
+func (a *App) sortFlags(flags []Flag) []Flag {
+	if a != nil && a.SortFlags {
+		sort.Stable(FlagsByName(flags))
+	}
+	return flags
+}
+
+// checkPersistentFlags checks the PersistentFlags as the Flags of the App
+// were, as a required persistent flag may be given after a command
+func (a *App) checkPersistentFlags(context *Context) error {
+	err := checkFlags(a.PersistentFlags, context)
+	if err != nil && !a.jsonErrors() {
+		ShowSubcommandHelp(context)
+	}
+	return err
 }
 
 func (a *App) appendFlag(fl Flag) {
@@ -502,9 +965,45 @@ func (a *App) appendCommand(c *Command) {
 func (a *App) handleExitCoder(context *Context, err error) {
 	if a.ExitErrHandler != nil {
 		a.ExitErrHandler(context, err)
-	} else {
+	} else if !a.jsonErrors() {
 		HandleExitCoder(err)
+		if exitsFor(err) {
+			a.exited = true
+			if root := rootApp(context); root != nil {
+				root.exited = true
+			}
+		}
+	}
+}
+
+// rootApp returns the App of the outermost context, which is the App run
+// rather than one started for a command with subcommands
+func rootApp(context *Context) *App {
+	var root *App
+	for _, c := range context.Lineage() {
+		if c.App != nil {
+			root = c.App
+		}
+	}
+	return root
+}
+
+// warn passes msg to Warn, or writes it to ErrWriter if Warn is not set
+func (a *App) warn(msg string) {
+	if a.Warn != nil {
+		a.Warn(msg)
+		return
 	}
+	w := a.ErrWriter
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintln(w, a.translate(MsgWarning), msg)
+}
+
+// jsonErrors returns true if errors are written as JSON by Run
+func (a *App) jsonErrors() bool {
+	return a.ErrorFormat == ErrorFormatJSON
 }
 
 // Author represents someone who has contributed to a cli project.
@@ -523,6 +1022,14 @@ func (a *Author) String() string {
 	return fmt.Sprintf("%v%v", a.Name, e)
 }
 
+// Example is an example invocation shown in the EXAMPLES section of help
+type Example struct {
+	// Command is the command line of the example, such as "app serve -p 80"
+	Command string
+	// Usage is a short description of what the example does
+	Usage string
+}
+
 // HandleAction attempts to figure out which Action signature was used.  If
 // it's an ActionFunc or a func with the legacy signature for Action, the func
 // is run! Panics on invalid function signature.
@@ -533,6 +1040,9 @@ func HandleAction(action interface{}, context *Context) (err error) {
 	case func(*Context) error:
 		return a(context)
 	case func(*Context): // deprecated function signature
+		if context != nil && context.App != nil && context.App.WarnDeprecated {
+			context.App.warn(context.App.translate(MsgDeprecatedAction))
+		}
 		a(context)
 		return nil
 	}
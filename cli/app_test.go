@@ -2,14 +2,17 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rancher/spur/flag"
 )
@@ -315,6 +318,47 @@ func ExampleApp_Run_bashComplete_withMultipleLongFlag() {
 	// --string-flag-2
 }
 
+func ExampleApp_Run_bashComplete_withChoices() {
+	os.Args = []string{"greet", "--color", "--generate-bash-completion"}
+
+	app := NewApp()
+	app.Name = "greet"
+	app.EnableBashCompletion = true
+	app.Flags = []Flag{
+		&StringFlag{
+			Name:    "color",
+			Choices: []string{"red", "green", "blue"},
+		},
+		&StringFlag{
+			Name: "color-mode",
+		},
+	}
+
+	app.Run(os.Args)
+	// Output:
+	// red
+	// green
+	// blue
+}
+
+func ExampleApp_Run_bashComplete_takesFile() {
+	os.Args = []string{"greet", "--config", "--generate-bash-completion"}
+
+	app := NewApp()
+	app.Name = "greet"
+	app.EnableBashCompletion = true
+	app.Flags = []Flag{
+		&StringFlag{
+			Name:      "config",
+			TakesFile: true,
+		},
+	}
+
+	app.Run(os.Args)
+	// Output:
+	// :files:
+}
+
 func ExampleApp_Run_bashComplete() {
 	// set args for examples sake
 	// set args for examples sake
@@ -409,6 +453,111 @@ func TestApp_Run(t *testing.T) {
 	expect(t, s, "foobar")
 }
 
+func TestApp_RunWithArgFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli_argfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outer := dir + "/outer.txt"
+	inner := dir + "/inner.txt"
+	ioutil.WriteFile(outer, []byte("--name outer\n@"+inner+"\nfirst"), 0644)
+	ioutil.WriteFile(inner, []byte("  --count 3\n\n"), 0644)
+
+	var name string
+	var count int
+	var args []string
+	app := &App{
+		AllowArgFiles: true,
+		Flags: []Flag{
+			&StringFlag{Name: "name", Destination: &name},
+			&IntFlag{Name: "count", Destination: &count},
+		},
+		Action: func(c *Context) error {
+			args = c.Args().Slice()
+			return nil
+		},
+	}
+
+	err = app.Run([]string{"command", "@" + outer, "second"})
+	expect(t, err, nil)
+	expect(t, name, "outer")
+	expect(t, count, 3)
+	expect(t, args, []string{"first", "second"})
+
+	ioutil.WriteFile(inner, []byte("@"+outer), 0644)
+	err = app.Run([]string{"command", "@" + outer})
+	if err == nil || !strings.Contains(err.Error(), "includes itself") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+
+	app.AllowArgFiles = false
+	err = app.Run([]string{"command", "@" + outer})
+	expect(t, err, nil)
+	expect(t, args, []string{"@" + outer})
+}
+
+func TestApp_RunTwice(t *testing.T) {
+	var tags []string
+	var name string
+	var ids []int
+	var short [2]bool
+	app := &App{
+		Writer: ioutil.Discard,
+		Flags: []Flag{
+			&StringSliceFlag{Name: "tag", Value: []string{"default"}, Destination: &tags},
+			&StringFlag{Name: "name", Value: "anonymous", Destination: &name},
+		},
+		Commands: []*Command{
+			{
+				Name: "sub",
+				Flags: []Flag{
+					&IntSliceFlag{Name: "id", Value: []int{1}},
+					&BoolFlag{Name: "a"},
+					&BoolFlag{Name: "b"},
+				},
+				Action: func(c *Context) error {
+					ids = c.IntSlice("id")
+					short = [2]bool{c.Bool("a"), c.Bool("b")}
+					return nil
+				},
+			},
+		},
+		Action: func(c *Context) error {
+			expect(t, c.IsSet("name"), name != "anonymous")
+			return nil
+		},
+	}
+
+	err := app.Run([]string{"run", "--tag", "one", "--tag", "two", "--name", "first"})
+	expect(t, err, nil)
+	expect(t, tags, []string{"one", "two"})
+	expect(t, name, "first")
+
+	err = app.Run([]string{"run"})
+	expect(t, err, nil)
+	expect(t, tags, []string{"default"})
+	expect(t, name, "anonymous")
+
+	app.UseShortOptionHandling = true
+	err = app.Run([]string{"run", "sub", "--id", "5", "-ab"})
+	expect(t, err, nil)
+	expect(t, ids, []int{5})
+	expect(t, short, [2]bool{true, true})
+
+	app.UseShortOptionHandling = false
+	err = app.Run([]string{"run", "sub"})
+	expect(t, err, nil)
+	expect(t, ids, []int{1})
+	expect(t, short, [2]bool{false, false})
+
+	err = app.Run([]string{"run", "sub", "-ab"})
+	if err == nil {
+		t.Errorf("expected short options to be disabled on the second run")
+	}
+}
+
 var commandAppTests = []struct {
 	name     string
 	expected bool
@@ -434,6 +583,122 @@ func TestApp_Command(t *testing.T) {
 	}
 }
 
+func TestApp_LookupCommand(t *testing.T) {
+	add := &Command{Name: "add", Aliases: []string{"a"}}
+	remote := &Command{
+		Name:        "remote",
+		Aliases:     []string{"r"},
+		Subcommands: []*Command{add, {Name: "remove", Aliases: []string{"rm"}}},
+	}
+	app := &App{Commands: []*Command{remote, {Name: "status"}}}
+
+	tests := []struct {
+		path     []string
+		expected *Command
+	}{
+		{[]string{"remote"}, remote},
+		{[]string{"remote", "add"}, add},
+		{[]string{"r", "a"}, add},
+		{[]string{"remote", "missing"}, nil},
+		{[]string{"add"}, nil},
+		{[]string{"status", "add"}, nil},
+		{nil, nil},
+	}
+	for _, test := range tests {
+		command, ok := app.LookupCommand(test.path...)
+		expect(t, command, test.expected)
+		expect(t, ok, test.expected != nil)
+	}
+}
+
+func TestApp_Use(t *testing.T) {
+	var events []string
+	record := func(event string) func(*Context) error {
+		return func(*Context) error {
+			events = append(events, event)
+			return nil
+		}
+	}
+	middleware := func(name string) MiddlewareFunc {
+		return func(next ActionFunc) ActionFunc {
+			return func(ctx *Context) error {
+				events = append(events, name+" start")
+				err := next(ctx)
+				events = append(events, name+" end")
+				return err
+			}
+		}
+	}
+	errDenied := errors.New("denied")
+
+	app := &App{
+		Writer: ioutil.Discard,
+		Before: record("app before"),
+		After:  record("app after"),
+		Action: record("app action"),
+		Commands: []*Command{
+			{
+				Name:   "cmd",
+				Before: record("cmd before"),
+				After:  record("cmd after"),
+				Action: record("cmd action"),
+			},
+			{
+				Name:   "parent",
+				Before: record("parent before"),
+				Subcommands: []*Command{
+					{Name: "child", Action: record("child action")},
+				},
+			},
+			{
+				Name:   "secret",
+				Action: record("secret action"),
+			},
+		},
+	}
+	app.Use(middleware("outer"), middleware("inner"))
+	app.Use(func(next ActionFunc) ActionFunc {
+		return func(ctx *Context) error {
+			if ctx.Command != nil && ctx.Command.Name == "secret" {
+				return errDenied
+			}
+			return next(ctx)
+		}
+	})
+
+	tests := []struct {
+		args   []string
+		err    error
+		events []string
+	}{
+		{
+			args:   []string{"app"},
+			events: []string{"app before", "outer start", "inner start", "app action", "inner end", "outer end", "app after"},
+		},
+		{
+			args: []string{"app", "cmd"},
+			events: []string{"app before", "outer start", "inner start",
+				"cmd before", "cmd action", "cmd after", "inner end", "outer end", "app after"},
+		},
+		{
+			args: []string{"app", "parent", "child"},
+			events: []string{"app before", "parent before", "outer start", "inner start",
+				"child action", "inner end", "outer end", "app after"},
+		},
+		{
+			args:   []string{"app", "secret"},
+			err:    errDenied,
+			events: []string{"app before", "outer start", "inner start", "inner end", "outer end", "app after"},
+		},
+	}
+	for _, test := range tests {
+		events = nil
+		err := app.Run(test.args)
+		expect(t, err, test.err)
+		expect(t, events, test.events)
+	}
+}
+
 func TestApp_Setup_defaultsWriter(t *testing.T) {
 	app := &App{}
 	app.Setup()
@@ -483,7 +748,7 @@ func TestApp_RunAsSubCommandIncorrectUsage(t *testing.T) {
 
 	err := a.RunAsSubcommand(c)
 
-	expect(t, err, errors.New("bad flag syntax: ---foo"))
+	expect(t, err, &CommandError{Command: "cmd", Err: errors.New("bad flag syntax: ---foo")})
 }
 
 func TestApp_CommandWithFlagBeforeTerminator(t *testing.T) {
@@ -641,7 +906,7 @@ func TestApp_UseShortOptionHandling_missing_value(t *testing.T) {
 	}
 
 	err := app.Run([]string{"", "-n"})
-	expect(t, err, errors.New("flag needs an argument: -n"))
+	expect(t, err, &CommandError{Command: app.Name, Err: errors.New("flag needs an argument: -n")})
 }
 
 func TestApp_UseShortOptionHandlingCommand(t *testing.T) {
@@ -685,7 +950,7 @@ func TestApp_UseShortOptionHandlingCommand_missing_value(t *testing.T) {
 	app.Commands = []*Command{command}
 
 	err := app.Run([]string{"", "cmd", "-n"})
-	expect(t, err, errors.New("flag needs an argument: -n"))
+	expect(t, err, &CommandError{Command: app.Name + " cmd", Err: errors.New("flag needs an argument: -n")})
 }
 
 func TestApp_UseShortOptionHandlingSubCommand(t *testing.T) {
@@ -738,7 +1003,7 @@ func TestApp_UseShortOptionHandlingSubCommand_missing_value(t *testing.T) {
 	app.Commands = []*Command{command}
 
 	err := app.Run([]string{"", "cmd", "sub", "-n"})
-	expect(t, err, errors.New("flag needs an argument: -n"))
+	expect(t, err, &CommandError{Command: app.Name + " cmd sub", Err: errors.New("flag needs an argument: -n")})
 }
 
 func TestApp_Float64Flag(t *testing.T) {
@@ -1902,6 +2167,412 @@ func TestApp_OnUsageError_WithWrongFlagValue(t *testing.T) {
 	}
 }
 
+func TestApp_OnUsageError_ForCommands(t *testing.T) {
+	var out bytes.Buffer
+	var errs []string
+	var subcommands []bool
+	app := &App{
+		Writer: &out,
+		OnUsageError: func(c *Context, err error, isSubcommand bool) error {
+			errs = append(errs, err.Error())
+			subcommands = append(subcommands, isSubcommand)
+			return nil
+		},
+		Commands: []*Command{
+			{
+				Name:   "bar",
+				Action: func(c *Context) error { return nil },
+			},
+			{
+				Name:        "baz",
+				Subcommands: []*Command{{Name: "qux"}},
+			},
+		},
+	}
+
+	expect(t, app.Run([]string{"foo", "--unknown"}), nil)
+	expect(t, app.Run([]string{"foo", "bar", "--unknown"}), nil)
+	expect(t, app.Run([]string{"foo", "baz", "qux", "--unknown"}), nil)
+	expect(t, errs, []string{
+		"flag provided but not defined: -unknown",
+		"flag provided but not defined: -unknown",
+		"flag provided but not defined: -unknown",
+	})
+	expect(t, subcommands, []bool{false, true, true})
+	expect(t, out.String(), "")
+}
+
+func TestApp_ParseErrorPropagation(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command string
+		afters  []string
+	}{
+		{args: []string{"app", "--unknown"}, command: "app"},
+		{args: []string{"app", "outer", "--unknown"}, command: "app outer", afters: []string{"app"}},
+		{args: []string{"app", "outer", "inner", "--unknown"}, command: "app outer inner", afters: []string{"outer", "app"}},
+		{args: []string{"app", "outer", "inner", "--count", "x"}, command: "app outer inner", afters: []string{"outer", "app"}},
+	}
+	for _, test := range tests {
+		var afters []string
+		after := func(name string) AfterFunc {
+			return func(*Context) error {
+				afters = append(afters, name)
+				return nil
+			}
+		}
+		app := &App{
+			Name:   "app",
+			Writer: ioutil.Discard,
+			After:  after("app"),
+			Commands: []*Command{
+				{
+					Name:  "outer",
+					After: after("outer"),
+					Subcommands: []*Command{
+						{
+							Name:   "inner",
+							Flags:  []Flag{&IntFlag{Name: "count"}},
+							After:  after("inner"),
+							Action: func(*Context) error { return nil },
+						},
+					},
+				},
+			},
+		}
+
+		err := app.Run(test.args)
+		var cerr *CommandError
+		if !errors.As(err, &cerr) {
+			t.Fatalf("expected a CommandError for %v, got %v (%T)", test.args, err, err)
+		}
+		expect(t, cerr.Command, test.command)
+		expect(t, strings.HasPrefix(err.Error(), test.command+": "), true)
+		expect(t, afters, test.afters)
+	}
+}
+
+func TestApp_Translator(t *testing.T) {
+	french := map[string]string{
+		MsgHelpName:           "NOM :",
+		MsgHelpUsage:          "UTILISATION :",
+		MsgHelpGlobalOptions:  "OPTIONS GLOBALES :",
+		MsgHelpOptions:        "OPTIONS :",
+		MsgUsageGlobalOptions: "[options globales]",
+		MsgUsageArguments:     "[arguments...]",
+		MsgRequiredFlag:       "L'option %q est requise",
+		MsgFlagRequires:       "%s nécessite %s",
+	}
+	var keys []string
+	translator := func(key string, args ...interface{}) string {
+		keys = append(keys, key)
+		if msg, ok := french[key]; ok {
+			return fmt.Sprintf(msg, args...)
+		}
+		return DefaultTranslator(key, args...)
+	}
+
+	var out bytes.Buffer
+	app := &App{
+		Name:       "greet",
+		HelpName:   "greet",
+		Usage:      "greets",
+		Writer:     &out,
+		Translator: translator,
+		Flags: []Flag{
+			&StringFlag{Name: "name", Usage: "who to greet", Required: true},
+		},
+		Commands: []*Command{{
+			Name:  "serve",
+			Usage: "serve greetings",
+			Flags: []Flag{
+				&StringFlag{Name: "tls-cert", Requires: []string{"tls-key"}},
+				&StringFlag{Name: "tls-key"},
+			},
+		}},
+	}
+
+	err := app.Run([]string{"greet"})
+	expect(t, err.Error(), "L'option \"name\" est requise")
+	expect(t, strings.HasPrefix(out.String(), "NOM :\n   greet - greets\n\nUTILISATION :\n   greet [options globales] command [command options] [arguments...]\n"), true)
+	expect(t, strings.Contains(out.String(), "\nOPTIONS GLOBALES :\n   --name value"), true)
+
+	out.Reset()
+	err = app.Run([]string{"greet", "--name", "n", "serve", "--tls-cert", "c"})
+	expect(t, err.Error(), "--tls-cert nécessite --tls-key")
+	expect(t, strings.Contains(out.String(), "\nOPTIONS :\n"), true)
+
+	out.Reset()
+	keys = nil
+	expect(t, app.Run([]string{"greet", "--name", "n", "help", "serve"}), nil)
+	expect(t, strings.HasPrefix(out.String(), "NOM :\n   greet serve - serve greetings\n"), true)
+	expect(t, keys[0], MsgHelpName)
+
+	expect(t, DefaultTranslator(MsgRequiredFlags, "a, b"), `Required flags "a, b" not set`)
+	expect(t, DefaultTranslator(MsgHelpName), "NAME:")
+	expect(t, DefaultTranslator("unknown.key"), "unknown.key")
+}
+
+func TestApp_Main(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	defer func() { OsExiter = fakeOsExiter }()
+	var exitCodes []int
+	OsExiter = func(rc int) {
+		exitCodes = append(exitCodes, rc)
+	}
+	osExiter := reflect.ValueOf(OsExiter).Pointer()
+
+	tests := []struct {
+		args      []string
+		err       error
+		handler   bool
+		exitCodes []int
+		output    string
+	}{
+		{[]string{"main"}, nil, false, nil, ""},
+		{[]string{"main"}, errors.New("boom"), false, []int{1}, "boom\n"},
+		{[]string{"main"}, Exit("", 3), false, []int{3}, ""},
+		{[]string{"main"}, Exit("", 3), true, []int{3}, ""},
+		{[]string{"main", "remote", "add"}, Exit("", 5), false, []int{5}, ""},
+		{[]string{"main"}, fmt.Errorf("wrapped: %w", Exit("inner", 4)), false, []int{4}, "wrapped: inner\n"},
+	}
+	for _, test := range tests {
+		exitCodes = nil
+		var buf bytes.Buffer
+		action := func(c *Context) error {
+			return test.err
+		}
+		app := &App{
+			ErrWriter: &buf,
+			Action:    action,
+			Commands: []*Command{{
+				Name:        "remote",
+				Subcommands: []*Command{{Name: "add", Action: action}},
+			}},
+		}
+		if test.handler {
+			app.ExitErrHandler = func(*Context, error) {}
+		}
+		os.Args = test.args
+		app.Main()
+		expect(t, exitCodes, test.exitCodes)
+		expect(t, buf.String(), test.output)
+		expect(t, reflect.ValueOf(OsExiter).Pointer(), osExiter)
+	}
+}
+
+func TestApp_OnComplete(t *testing.T) {
+	type completion struct {
+		command string
+		err     error
+	}
+	var completions []completion
+	cause := errors.New("boom")
+	app := &App{
+		Writer:    ioutil.Discard,
+		ErrWriter: ioutil.Discard,
+		OnComplete: func(ctx *Context, err error, dur time.Duration) {
+			if dur < 0 {
+				t.Errorf("expected a positive duration, got %v", dur)
+			}
+			c := completion{err: err}
+			if ctx != nil && ctx.Command != nil {
+				c.command = ctx.Command.Name
+			}
+			completions = append(completions, c)
+		},
+		Action: func(*Context) error { return nil },
+		Commands: []*Command{
+			{Name: "ok", Action: func(*Context) error { return nil }},
+			{Name: "fail", Action: func(*Context) error { return cause }},
+			{Name: "panic", Action: func(*Context) error { panic(cause) }},
+		},
+	}
+
+	expect(t, app.Run([]string{"run", "ok"}), nil)
+	expect(t, app.Run([]string{"run", "fail"}), cause)
+	expect(t, app.Run([]string{"run", "--help"}), nil)
+	app.RecoverPanic = true
+	err := app.Run([]string{"run", "panic"})
+	expect(t, completions[:3], []completion{{"ok", nil}, {"fail", cause}, {"", nil}})
+	expect(t, len(completions), 4)
+	expect(t, completions[3].command, "panic")
+	expect(t, completions[3].err, err)
+
+	app.RecoverPanic = false
+	defer func() {
+		expect(t, recover(), cause)
+		expect(t, len(completions), 4)
+	}()
+	app.Run([]string{"run", "panic"})
+	t.Error("expected a panic")
+}
+
+func TestApp_ErrorFormatJSON(t *testing.T) {
+	defer func() { OsExiter = fakeOsExiter }()
+	exited := false
+	OsExiter = func(rc int) {
+		exited = true
+	}
+
+	tests := []struct {
+		args   []string
+		expect string
+	}{
+		{[]string{"app", "fail"}, `{"error":"failed","code":3}`},
+		{[]string{"app", "plain"}, `{"error":"plain","code":1}`},
+		{[]string{"app", "panic"}, `{"error":"panic: boom","code":2}`},
+		{[]string{"app", "--unknown"}, `{"error":"app: flag provided but not defined: -unknown","code":1}`},
+		{[]string{"app", "serve"}, `{"error":"Required flag \"port\" not set","code":1}`},
+		{[]string{"app", "serve", "--port", "x"}, `{"error":"app serve: invalid value \"x\" for flag -port: parse error","code":1}`},
+		{[]string{"app", "ok"}, ``},
+	}
+	for _, test := range tests {
+		var out, errOut bytes.Buffer
+		app := &App{
+			Name:         "app",
+			Writer:       &out,
+			ErrWriter:    &errOut,
+			ErrorFormat:  ErrorFormatJSON,
+			RecoverPanic: true,
+			Commands: []*Command{
+				{Name: "fail", Action: func(*Context) error { return Exit("failed", 3) }},
+				{Name: "plain", Action: func(*Context) error { return errors.New("plain") }},
+				{Name: "panic", Action: func(*Context) error { panic("boom") }},
+				{Name: "serve", Flags: []Flag{&IntFlag{Name: "port", Required: true}}},
+				{Name: "ok", Action: func(*Context) error { return nil }},
+			},
+		}
+		app.Run(test.args)
+		expect(t, strings.TrimSpace(errOut.String()), test.expect)
+		expect(t, out.String(), "")
+		expect(t, exited, false)
+	}
+}
+
+func TestApp_RecoverPanic(t *testing.T) {
+	var errBuf bytes.Buffer
+	cause := errors.New("boom")
+	app := &App{
+		Writer:       ioutil.Discard,
+		ErrWriter:    &errBuf,
+		RecoverPanic: true,
+		Action: func(*Context) error {
+			panic(cause)
+		},
+		Commands: []*Command{{
+			Name: "cmd",
+			Before: func(*Context) error {
+				panic("in before")
+			},
+			Action: func(*Context) error { return nil },
+		}},
+	}
+
+	err := app.Run([]string{"run"})
+	var perr *PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a PanicError, got %v (%T)", err, err)
+	}
+	expect(t, perr.Value, cause)
+	expect(t, errors.Is(err, cause), true)
+	expect(t, len(perr.Stack) > 0, true)
+	var exitErr ExitCoder
+	expect(t, errors.As(err, &exitErr), true)
+	expect(t, exitErr.ExitCode(), 2)
+	expect(t, errBuf.String(), "panic: boom\n")
+
+	errBuf.Reset()
+	err = app.Run([]string{"run", "cmd"})
+	expect(t, err.Error(), "panic: in before")
+	expect(t, errors.Unwrap(err), nil)
+	expect(t, errBuf.String(), "panic: in before\n")
+
+	app.RecoverPanic = false
+	defer func() {
+		expect(t, recover(), cause)
+	}()
+	app.Run([]string{"run"})
+	t.Error("expected a panic")
+}
+
+func TestApp_RunFlagRequires(t *testing.T) {
+	var ran bool
+	app := &App{
+		Writer: ioutil.Discard,
+		Flags: []Flag{
+			&StringFlag{Name: "name", Required: true},
+		},
+		Commands: []*Command{{
+			Name: "serve",
+			Flags: []Flag{
+				&StringFlag{Name: "tls-cert", Requires: []string{"tls-key"}},
+				&StringFlag{Name: "tls-key"},
+			},
+			Action: func(*Context) error {
+				ran = true
+				return nil
+			},
+		}},
+	}
+
+	err := app.Run([]string{"run", "--name", "n", "serve", "--tls-cert", "cert.pem"})
+	expect(t, err.Error(), "--tls-cert requires --tls-key")
+	expect(t, ran, false)
+
+	expect(t, app.Run([]string{"run", "--name", "n", "serve", "--tls-cert", "cert.pem", "--tls-key", "key.pem"}), nil)
+	expect(t, ran, true)
+
+	app.Flags = append(app.Flags, &BoolFlag{Name: "debug", Requires: []string{"log"}}, &StringFlag{Name: "log"})
+	err = app.Run([]string{"run", "--debug"})
+	expect(t, err.Error(), "2 errors occurred:\n"+
+		"  * Required flag \"name\" not set\n"+
+		"  * --debug requires --log")
+}
+
+func TestApp_RunMultipleValidationErrors(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	newApp := func() *App {
+		return &App{
+			Writer: ioutil.Discard,
+			Flags: []Flag{
+				&StringFlag{Name: "token", FromFileFlag: "token-file"},
+				&StringFlag{Name: "token-file"},
+				&StringFlag{Name: "name", Required: true},
+				&IntFlag{Name: "count", EnvVars: []string{"APP_COUNT"}},
+				&BoolFlag{Name: "debug", EnvVars: []string{"APP_DEBUG"}},
+			},
+			Action: func(*Context) error { return nil },
+		}
+	}
+
+	err := newApp().Run([]string{"run", "--token", "x", "--token-file", "/missing"})
+	var multiErr MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected a MultiError, got %v (%T)", err, err)
+	}
+	expect(t, len(multiErr.Errors()), 2)
+	requiredErr, ok := multiErr.Errors()[1].(requiredFlagsErr)
+	expect(t, ok, true)
+	expect(t, requiredErr.getMissingFlags(), []string{"name"})
+	expect(t, err.Error(), "2 errors occurred:\n"+
+		"  * flags --token and --token-file cannot both be set\n"+
+		"  * Required flag \"name\" not set")
+
+	err = newApp().Run([]string{"run", "--token", "x", "--token-file", "/missing", "--name", "n"})
+	expect(t, err.Error(), "flags --token and --token-file cannot both be set")
+
+	os.Setenv("APP_COUNT", "many")
+	os.Setenv("APP_DEBUG", "maybe")
+	err = newApp().Run([]string{"run", "--name", "n"})
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected a MultiError, got %v (%T)", err, err)
+	}
+	expect(t, len(multiErr.Errors()), 2)
+}
+
 func TestApp_OnUsageError_WithWrongFlagValue_ForSubcommand(t *testing.T) {
 	app := &App{
 		Flags: []Flag{
@@ -1999,7 +2670,7 @@ func TestCustomHelpVersionFlags(t *testing.T) {
 
 func TestHandleExitCoder_Default(t *testing.T) {
 	app := newTestApp()
-	fs, err := flagSet(app.Name, app.Flags)
+	fs, err := flagSet(app.Name, app.Flags, false)
 	if err != nil {
 		t.Errorf("error creating FlagSet: %s", err)
 	}
@@ -2015,7 +2686,7 @@ func TestHandleExitCoder_Default(t *testing.T) {
 
 func TestHandleExitCoder_Custom(t *testing.T) {
 	app := newTestApp()
-	fs, err := flagSet(app.Name, app.Flags)
+	fs, err := flagSet(app.Name, app.Flags, false)
 	if err != nil {
 		t.Errorf("error creating FlagSet: %s", err)
 	}
@@ -2247,3 +2918,813 @@ func expectFileContent(t *testing.T, file, expected string) {
 	expect(t, err, nil)
 	expect(t, string(data), expected)
 }
+
+func TestApp_Validate(t *testing.T) {
+	fail := func(c *Context) error {
+		t.Errorf("expected no functions to run during validation")
+		return nil
+	}
+	var out bytes.Buffer
+	app := &App{
+		Writer:    &out,
+		ErrWriter: &out,
+		Flags: []Flag{
+			&StringFlag{Name: "config", Required: true},
+			&IntFlag{Name: "count"},
+		},
+		Commands: []*Command{
+			{
+				Name:  "serve",
+				Flags: []Flag{&StringFlag{Name: "addr", Required: true}},
+				Subcommands: []*Command{
+					{
+						Name:   "tls",
+						Flags:  []Flag{&StringFlag{Name: "cert", Required: true}},
+						Action: fail,
+					},
+				},
+				Before: fail,
+				After:  fail,
+			},
+		},
+		Before: fail,
+		After:  fail,
+		Action: fail,
+	}
+
+	expect(t, app.Validate([]string{"run", "--config", "c"}), nil)
+	expect(t, app.Validate([]string{"run", "--help"}), nil)
+	expect(t, app.Validate([]string{"run", "--config", "c", "serve", "--addr", ":80"}), nil)
+	expect(t, app.Validate([]string{"run", "--config", "c", "serve", "--addr", ":80", "tls", "--cert", "x"}), nil)
+	expect(t, app.Validate([]string{"run", "--config", "c", "serve", "--addr", ":80", "tls", "--help"}), nil)
+
+	err := app.Validate([]string{"run", "--config", "c", "--count", "x"})
+	if err == nil || !strings.Contains(err.Error(), `invalid value "x" for flag -count`) {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"run"},
+		{"run", "--config", "c", "serve"},
+		{"run", "--config", "c", "serve", "--addr", ":80", "tls"},
+	} {
+		err := app.Validate(args)
+		if _, ok := err.(requiredFlagsErr); !ok {
+			t.Errorf("expected a requiredFlagsErr for %v, got %v", args, err)
+		}
+	}
+
+	if out.Len() != 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
+
+func TestApp_ValidateMatchesRun(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	var namespace string
+	newApp := func() *App {
+		return &App{
+			Writer:       ioutil.Discard,
+			ErrWriter:    ioutil.Discard,
+			ValueSources: []ValueSource{mapValueSource{"namespace": "from-source"}},
+			ArgsRewriter: func(args []string) []string {
+				for i, arg := range args {
+					if arg == "ls" {
+						args[i] = "list"
+					}
+				}
+				return args
+			},
+			PersistentFlags: []Flag{
+				&StringFlag{Name: "namespace", Required: true},
+				&StringFlag{Name: "token", Required: true},
+			},
+			Action: func(ctx *Context) error { return nil },
+			Commands: []*Command{{
+				Name: "list",
+				Action: func(ctx *Context) error {
+					namespace = ctx.String("namespace")
+					return nil
+				},
+			}},
+		}
+	}
+
+	for _, args := range [][]string{
+		{"app", "--token", "t", "ls"},
+		{"app", "ls", "--token", "t"},
+		{"app", "--token", "t"},
+	} {
+		namespace = ""
+		expect(t, newApp().Validate(args), nil)
+		expect(t, newApp().Run(args), nil)
+		if args[len(args)-1] == "ls" {
+			expect(t, namespace, "from-source")
+		}
+	}
+
+	for _, args := range [][]string{
+		{"app", "ls"},
+		{"app"},
+	} {
+		verr, rerr := newApp().Validate(args), newApp().Run(args)
+		if _, ok := verr.(requiredFlagsErr); !ok {
+			t.Errorf("expected a requiredFlagsErr from Validate for %v, got %v", args, verr)
+		}
+		if _, ok := rerr.(requiredFlagsErr); !ok {
+			t.Errorf("expected a requiredFlagsErr from Run for %v, got %v", args, rerr)
+		}
+	}
+}
+
+func TestApp_ValidateMatchesRunErrors(t *testing.T) {
+	newApp := func() *App {
+		return &App{
+			Name:      "app",
+			Writer:    ioutil.Discard,
+			ErrWriter: ioutil.Discard,
+			Flags:     []Flag{&IntFlag{Name: "count"}},
+			Action:    func(ctx *Context) error { return nil },
+			Commands: []*Command{
+				{
+					Name:     "list",
+					Flags:    []Flag{&IntFlag{Name: "limit"}},
+					ArgsFlag: &IntSliceFlag{Name: "ids"},
+					Action:   func(ctx *Context) error { return nil },
+				},
+				{
+					Name:  "remote",
+					Flags: []Flag{&IntFlag{Name: "timeout"}},
+					Subcommands: []*Command{{
+						Name:   "add",
+						Flags:  []Flag{&IntFlag{Name: "port"}},
+						Action: func(ctx *Context) error { return nil },
+					}},
+				},
+			},
+		}
+	}
+
+	for _, test := range []struct {
+		args    []string
+		command string
+	}{
+		{[]string{"app", "--count", "x"}, "app"},
+		{[]string{"app", "--bogus"}, "app"},
+		{[]string{"app", "list", "--limit", "x"}, "app list"},
+		{[]string{"app", "list", "x"}, "app list"},
+		{[]string{"app", "remote", "--timeout", "x", "add"}, "app remote"},
+		{[]string{"app", "remote", "add", "--port", "x"}, "app remote add"},
+	} {
+		verr, rerr := newApp().Validate(test.args), newApp().Run(test.args)
+		var vcerr, rcerr *CommandError
+		if !errors.As(verr, &vcerr) || !errors.As(rerr, &rcerr) {
+			t.Errorf("expected a CommandError from Validate and Run for %v, got %#v and %#v", test.args, verr, rerr)
+			continue
+		}
+		expect(t, vcerr.Command, test.command)
+		expect(t, rcerr.Command, test.command)
+		expect(t, verr.Error(), rerr.Error())
+	}
+}
+
+func TestApp_DefaultCommand(t *testing.T) {
+	var verbose bool
+	var args []string
+	var port int
+	app := &App{
+		Writer:         ioutil.Discard,
+		DefaultCommand: "serve",
+		Flags:          []Flag{&BoolFlag{Name: "verbose"}},
+		Commands: []*Command{
+			{
+				Name:  "serve",
+				Flags: []Flag{&IntFlag{Name: "port", Value: 80}},
+				Action: func(c *Context) error {
+					verbose = c.Bool("verbose")
+					port = c.Int("port")
+					args = c.Args().Slice()
+					return nil
+				},
+			},
+			{
+				Name:   "version",
+				Action: func(c *Context) error { return nil },
+			},
+		},
+	}
+
+	expect(t, app.Run([]string{"app", "--verbose"}), nil)
+	expect(t, verbose, true)
+	expect(t, port, 80)
+	expect(t, args, []string{})
+
+	expect(t, app.Run([]string{"app", "--verbose", "--", "--port", "8080", "site"}), nil)
+	expect(t, port, 8080)
+	expect(t, args, []string{"site"})
+
+	verbose = false
+	expect(t, app.Run([]string{"app", "version"}), nil)
+	expect(t, verbose, false)
+
+	expect(t, app.Validate([]string{"app", "--", "--port", "x"}) != nil, true)
+
+	app.DefaultCommand = "missing"
+	app.ExitErrHandler = func(*Context, error) {}
+	err := app.Run([]string{"app"})
+	if err == nil || err.Error() != `default command "missing" not found` {
+		t.Errorf("expected a missing default command error, got %v", err)
+	}
+}
+
+func TestApp_AddCommands(t *testing.T) {
+	var ran []string
+	action := func(ctx *Context) error {
+		ran = append(ran, ctx.Command.FullName())
+		return nil
+	}
+	app := &App{Commands: []*Command{{Name: "serve", Aliases: []string{"s"}, Action: action}}}
+
+	expect(t, app.AddCommands(
+		&Command{Name: "db", Subcommands: []*Command{{Name: "migrate", Action: action}}},
+		&Command{Name: "status", Action: action},
+	), nil)
+
+	err := app.AddCommands(&Command{Name: "stop", Action: action}, &Command{Name: "start", Aliases: []string{"s"}})
+	expect(t, err.Error(), `command start: name "s" is already used by command serve`)
+	expect(t, app.Command("stop") == nil, true)
+
+	err = app.AddCommands(&Command{Name: "a", Aliases: []string{"x"}}, &Command{Name: "x"})
+	expect(t, err.Error(), `command x: name "x" is already used by command a`)
+
+	plugin := &App{Name: "plugin", Commands: []*Command{{Name: "logs", Action: action}}}
+	plugin.Setup()
+	expect(t, app.Merge(plugin), nil)
+	expect(t, app.Merge(&App{Commands: []*Command{{Name: "db"}}}).Error(), `command db: name "db" is already used by command db`)
+
+	expect(t, app.Run([]string{"app", "db", "migrate"}), nil)
+	expect(t, app.Run([]string{"app", "logs"}), nil)
+	expect(t, ran, []string{"db migrate", "logs"})
+
+	// commands added after Setup are listed in help
+	expect(t, app.AddCommands(&Command{Name: "late", Usage: "added late"}), nil)
+	buf := new(bytes.Buffer)
+	app.Writer = buf
+	expect(t, app.Run([]string{"app", "--help"}), nil)
+	expect(t, strings.Contains(buf.String(), "late      added late"), true)
+}
+
+func TestApp_PersistentFlags(t *testing.T) {
+	var namespace, source string
+	action := func(ctx *Context) error {
+		namespace = ctx.String("namespace")
+		source = ctx.Source("namespace")
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	app := &App{
+		Name:   "app",
+		Writer: buf,
+		PersistentFlags: []Flag{
+			&StringFlag{Name: "namespace", Aliases: []string{"n"}, Value: "default", Usage: "the namespace"},
+		},
+		Action: action,
+		Commands: []*Command{
+			{Name: "get", Action: action},
+			{Name: "db", Subcommands: []*Command{{Name: "migrate", Action: action}}},
+		},
+	}
+
+	tests := []struct {
+		args   []string
+		expect string
+		source string
+	}{
+		{[]string{"app"}, "default", "default"},
+		{[]string{"app", "-n", "a"}, "a", "flag"},
+		{[]string{"app", "get"}, "default", "default"},
+		{[]string{"app", "--namespace", "a", "get"}, "a", "flag"},
+		{[]string{"app", "get", "--namespace", "b"}, "b", "flag"},
+		{[]string{"app", "-n", "a", "get", "-n", "b"}, "b", "flag"},
+		{[]string{"app", "-n", "a", "db", "migrate"}, "a", "flag"},
+		{[]string{"app", "db", "-n", "d", "migrate"}, "d", "flag"},
+		{[]string{"app", "-n", "a", "db", "migrate", "--namespace", "c"}, "c", "flag"},
+	}
+	for _, test := range tests {
+		namespace, source = "", ""
+		expect(t, app.Run(test.args), nil)
+		expect(t, namespace, test.expect)
+		expect(t, source, test.source)
+	}
+
+	expect(t, app.Run([]string{"app", "get", "--help"}), nil)
+	expect(t, strings.Contains(buf.String(), "GLOBAL FLAGS:\n   --namespace value, -n value  the namespace (default: \"default\")"), true)
+	buf.Reset()
+	expect(t, app.Run([]string{"app", "--help"}), nil)
+	expect(t, strings.Contains(buf.String(), "GLOBAL FLAGS:\n   --namespace value, -n value  the namespace (default: \"default\")"), true)
+
+	required := &App{
+		Writer:          ioutil.Discard,
+		PersistentFlags: []Flag{&StringFlag{Name: "token", Required: true}},
+		Action:          func(ctx *Context) error { return nil },
+		Commands:        []*Command{{Name: "get", Action: func(ctx *Context) error { return nil }}},
+	}
+	expect(t, required.Run([]string{"app", "get", "--token", "x"}), nil)
+	expect(t, required.Run([]string{"app", "--token", "x", "get"}), nil)
+	expect(t, required.Run([]string{"app", "--token", "x"}), nil)
+	expect(t, required.Run([]string{"app", "get"}).Error(), `Required flag "token" not set`)
+	expect(t, required.Run([]string{"app"}).Error(), `Required flag "token" not set`)
+	expect(t, required.Validate([]string{"app"}).Error(), `Required flag "token" not set`)
+
+	// as for other required flags the error is only returned, not handled
+	handled := 0
+	required.ExitErrHandler = func(ctx *Context, err error) { handled++ }
+	expect(t, required.Run([]string{"app"}).Error(), `Required flag "token" not set`)
+	expect(t, handled, 0)
+}
+
+func TestApp_ArgsRewriter(t *testing.T) {
+	var calls int
+	var output string
+	app := &App{
+		Flags: []Flag{&StringFlag{Name: "output"}},
+		ArgsRewriter: func(args []string) []string {
+			calls++
+			rewritten := make([]string, len(args))
+			for i, arg := range args {
+				if arg == "--out" {
+					arg = "--output"
+				}
+				rewritten[i] = strings.Replace(arg, "--out=", "--output=", 1)
+			}
+			return rewritten
+		},
+		Commands: []*Command{{
+			Name:        "cmd",
+			Subcommands: []*Command{{Name: "sub", Action: func(ctx *Context) error { return nil }}},
+		}},
+		Action: func(ctx *Context) error {
+			output = ctx.String("output")
+			return nil
+		},
+	}
+
+	expect(t, app.Run([]string{"app", "--out", "a"}), nil)
+	expect(t, output, "a")
+	expect(t, app.Run([]string{"app", "--out=b"}), nil)
+	expect(t, output, "b")
+	expect(t, app.Run([]string{"app", "--output", "c"}), nil)
+	expect(t, output, "c")
+	expect(t, calls, 3)
+
+	// only called once for the App, not again for subcommands
+	calls = 0
+	expect(t, app.Run([]string{"app", "cmd", "sub"}), nil)
+	expect(t, calls, 1)
+}
+
+func TestApp_Warn(t *testing.T) {
+	var warnings []string
+	app := &App{
+		Warn:           func(msg string) { warnings = append(warnings, msg) },
+		WarnDeprecated: true,
+		Commands: []*Command{{
+			Name:        "db",
+			Subcommands: []*Command{{Name: "migrate"}},
+		}},
+	}
+	app.Commands[0].Subcommands[0].Action = func(ctx *Context) error {
+		return HandleAction(func(*Context) {}, ctx)
+	}
+	expect(t, app.Run([]string{"app", "db", "migrate"}), nil)
+	expect(t, warnings, []string{"Action func(*Context) is deprecated, use func(*Context) error"})
+
+	errBuf := new(bytes.Buffer)
+	app = &App{
+		ErrWriter: errBuf,
+		Action: func(ctx *Context) error {
+			return HandleAction(func(*Context) {}, ctx)
+		},
+	}
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, errBuf.String(), "")
+
+	app.WarnDeprecated = true
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, errBuf.String(), "Warning: Action func(*Context) is deprecated, use func(*Context) error\n")
+}
+
+type mapValueSource map[string]interface{}
+
+func (m mapValueSource) Value(name string) (interface{}, bool, error) {
+	if err, ok := m[name].(error); ok {
+		return nil, false, err
+	}
+	v, ok := m[name]
+	return v, ok, nil
+}
+
+func TestApp_ValueSources(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	var region, source string
+	var replicas int
+	app := &App{
+		Writer:       ioutil.Discard,
+		ValueSources: []ValueSource{mapValueSource{"r": "us-west", "replicas": 3}, mapValueSource{"region": "eu-west"}},
+		Flags:        []Flag{&StringFlag{Name: "region", Aliases: []string{"r"}, EnvVars: []string{"REGION"}, Required: true}},
+		Commands: []*Command{{
+			Name:  "scale",
+			Flags: []Flag{&IntFlag{Name: "replicas", Value: 1}},
+			Action: func(ctx *Context) error {
+				region, source, replicas = ctx.String("region"), ctx.Source("region"), ctx.Int("replicas")
+				return nil
+			},
+		}},
+	}
+	expect(t, app.Run([]string{"app", "scale"}), nil)
+	expect(t, region, "us-west")
+	expect(t, source, "source")
+	expect(t, replicas, 3)
+
+	_ = os.Setenv("REGION", "ap-south")
+	expect(t, app.Run([]string{"app", "scale"}), nil)
+	expect(t, region, "ap-south")
+	expect(t, source, "env")
+
+	expect(t, app.Run([]string{"app", "--region", "local", "scale"}), nil)
+	expect(t, region, "local")
+	expect(t, source, "flag")
+
+	var namespace string
+	app.ValueSources = []ValueSource{mapValueSource{"namespace": "prod"}}
+	app.PersistentFlags = []Flag{&StringFlag{Name: "namespace", Value: "default"}}
+	app.Commands[0].Action = func(ctx *Context) error {
+		namespace, source = ctx.String("namespace"), ctx.Source("namespace")
+		return nil
+	}
+	expect(t, app.Run([]string{"app", "--region", "local", "scale"}), nil)
+	expect(t, namespace, "prod")
+	expect(t, source, "source")
+
+	app.ValueSources = []ValueSource{mapValueSource{"replicas": errors.New("connection refused")}}
+	err := app.Run([]string{"app", "scale"})
+	if err == nil || !strings.Contains(err.Error(), "could not read value source for flag replicas: connection refused") {
+		t.Errorf("expected a value source error, got %v", err)
+	}
+}
+
+func TestApp_FlagsFile(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_REGION", "env")
+	os.Setenv("APP_TAG", "x,y")
+
+	dir, err := ioutil.TempDir("", "spur-flags-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "opts.txt")
+	if err := ioutil.WriteFile(path, []byte(`# stable flags
+--region us-west
+--tag a
+--tag=b
+verbose
+name="two words" # quoted
+--replicas 3
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var region, name string
+	var tags []string
+	var verbose bool
+	var replicas int
+	app := &App{
+		Writer:    ioutil.Discard,
+		FlagsFile: "flags-file",
+		Flags: []Flag{
+			&StringFlag{Name: "region", EnvVars: []string{"APP_REGION"}},
+			&StringSliceFlag{Name: "tag", EnvVars: []string{"APP_TAG"}},
+			&BoolFlag{Name: "verbose"},
+			&StringFlag{Name: "name"},
+		},
+		Commands: []*Command{{
+			Name:  "scale",
+			Flags: []Flag{&IntFlag{Name: "replicas", Value: 1}},
+			Action: func(ctx *Context) error {
+				region, name, tags = ctx.String("region"), ctx.String("name"), ctx.StringSlice("tag")
+				verbose, replicas = ctx.Bool("verbose"), ctx.Int("replicas")
+				return nil
+			},
+		}},
+	}
+	expect(t, app.Run([]string{"app", "--flags-file", path, "scale"}), nil)
+	expect(t, region, "us-west")
+	expect(t, name, "two words")
+	expect(t, tags, []string{"a", "b"})
+	expect(t, verbose, true)
+	expect(t, replicas, 3)
+
+	expect(t, app.Run([]string{"app", "--flags-file", path, "--region", "eu", "--tag", "c", "scale", "--replicas", "5"}), nil)
+	expect(t, region, "eu")
+	expect(t, tags, []string{"c"})
+	expect(t, replicas, 5)
+
+	expect(t, app.Run([]string{"app", "scale"}), nil)
+	expect(t, region, "env")
+	expect(t, replicas, 1)
+
+	if err := ioutil.WriteFile(path, []byte("--region us-west\n--zone a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = app.Run([]string{"app", "--flags-file", path, "scale"})
+	expect(t, err.Error(), path+":2: flag provided but not defined: -zone")
+}
+
+func TestApp_ResolveStopsAtFirstError(t *testing.T) {
+	derived := false
+	newApp := func() *App {
+		return &App{
+			Writer:    ioutil.Discard,
+			ErrWriter: ioutil.Discard,
+			FlagsFile: "flags-file",
+			Flags: []Flag{&StringFlag{Name: "name", Derive: func(ctx *Context) (interface{}, error) {
+				derived = true
+				return "derived", nil
+			}}},
+			Action: func(ctx *Context) error { return nil },
+		}
+	}
+	missing := filepath.Join(os.TempDir(), "spur-missing-flags-file")
+	for _, run := range []func(*App, []string) error{(*App).Run, (*App).Validate} {
+		derived = false
+		err := run(newApp(), []string{"app", "--flags-file", missing})
+		expect(t, err != nil, true)
+		expect(t, derived, false)
+	}
+	expect(t, newApp().Run([]string{"app"}), nil)
+	expect(t, derived, true)
+}
+
+func TestApp_SubcommandRequired(t *testing.T) {
+	var output bytes.Buffer
+	var handled error
+	ran := ""
+	app := &App{
+		Name:   "app",
+		Writer: &output,
+		Commands: []*Command{
+			{Name: "serve", Action: func(ctx *Context) error { ran = "serve"; return nil }},
+		},
+		ExitErrHandler: func(ctx *Context, err error) { handled = err },
+	}
+
+	err := app.Run([]string{"app"})
+	exitErr, ok := err.(ExitCoder)
+	if !ok {
+		t.Fatalf("expected an ExitCoder, got %#v", err)
+	}
+	expect(t, exitErr.Error(), "a subcommand is required")
+	expect(t, exitErr.ExitCode(), 1)
+	expect(t, handled, err)
+	expect(t, strings.Contains(output.String(), "COMMANDS:"), true)
+
+	expect(t, app.Run([]string{"app", "serve"}), nil)
+	expect(t, ran, "serve")
+
+	// the help command is still run without error
+	output.Reset()
+	expect(t, app.Run([]string{"app", "help"}), nil)
+	expect(t, strings.Contains(output.String(), "COMMANDS:"), true)
+	_, ok = app.Run([]string{"app"}).(ExitCoder)
+	expect(t, ok, true)
+
+	ran = ""
+	app.DefaultCommand = "serve"
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, ran, "serve")
+}
+
+func TestApp_Validator(t *testing.T) {
+	var ran []string
+	rangeValidator := func(ctx *Context) error {
+		ran = append(ran, "validate")
+		if ctx.Int("min") > ctx.Int("max") {
+			return fmt.Errorf("--min %d is greater than --max %d", ctx.Int("min"), ctx.Int("max"))
+		}
+		return nil
+	}
+	record := func(name string) func(*Context) error {
+		return func(*Context) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	app := &App{
+		Writer:    ioutil.Discard,
+		Flags:     []Flag{&IntFlag{Name: "min"}, &IntFlag{Name: "max", Value: 10}},
+		Validator: rangeValidator,
+		Before:    record("before"),
+		Action:    record("action"),
+		Commands: []*Command{
+			{
+				Name:      "scale",
+				Flags:     []Flag{&IntFlag{Name: "min"}, &IntFlag{Name: "max", Value: 10}},
+				Validator: rangeValidator,
+				Before:    record("scale before"),
+				Action:    record("scale"),
+			},
+			{
+				Name:      "pool",
+				Flags:     []Flag{&IntFlag{Name: "min"}, &IntFlag{Name: "max", Value: 10}},
+				Validator: rangeValidator,
+				Subcommands: []*Command{
+					{Name: "resize", Action: record("resize")},
+				},
+			},
+		},
+		ExitErrHandler: func(ctx *Context, err error) {},
+	}
+
+	expect(t, app.Run([]string{"app", "--min", "3"}), nil)
+	expect(t, ran, []string{"validate", "before", "action"})
+
+	ran = nil
+	err := app.Run([]string{"app", "--min", "11"})
+	expect(t, err.Error(), "--min 11 is greater than --max 10")
+	expect(t, ran, []string{"validate"})
+
+	ran = nil
+	err = app.Run([]string{"app", "scale", "--min", "5", "--max", "4"})
+	expect(t, err.Error(), "--min 5 is greater than --max 4")
+	expect(t, ran, []string{"validate", "before", "validate"})
+
+	ran = nil
+	expect(t, app.Run([]string{"app", "scale", "--min", "5"}), nil)
+	expect(t, ran, []string{"validate", "before", "validate", "scale before", "scale"})
+
+	ran = nil
+	err = app.Run([]string{"app", "pool", "--min", "5", "--max", "4", "resize"})
+	expect(t, err.Error(), "--min 5 is greater than --max 4")
+	expect(t, ran, []string{"validate", "before", "validate"})
+
+	// Validate checks the Validator of the App and of the commands
+	ran = nil
+	expect(t, app.Validate([]string{"app", "--min", "3"}), nil)
+	expect(t, app.Validate([]string{"app", "--min", "11"}).Error(), "--min 11 is greater than --max 10")
+	expect(t, app.Validate([]string{"app", "scale", "--min", "11"}).Error(), "--min 11 is greater than --max 10")
+	expect(t, app.Validate([]string{"app", "pool", "--min", "11", "resize"}).Error(), "--min 11 is greater than --max 10")
+	for _, name := range ran {
+		expect(t, name, "validate")
+	}
+}
+
+// mapSource is an InputSourceContext of a map of flag names to values
+type mapSource map[string]interface{}
+
+func (m mapSource) Source() string { return "map" }
+
+func (m mapSource) Get(name string) (interface{}, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+func TestApp_GenerateConfig(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_REGION", "us-west")
+
+	var output bytes.Buffer
+	ran := false
+	newApp := func() *App {
+		return &App{
+			Writer:         &output,
+			GenerateConfig: "generate-config",
+			Flags: []Flag{
+				&StringFlag{Name: "region", EnvVars: []string{"APP_REGION"}},
+				&IntFlag{Name: "count", Value: 1},
+				&DurationFlag{Name: "timeout", Value: time.Minute},
+				&StringSliceFlag{Name: "tag"},
+				&BoolFlag{Name: "verbose"},
+				&StringMapFlag{Name: "label"},
+				&StringFlag{Name: "token", Value: "hunter2", Secret: true},
+			},
+			Action: func(ctx *Context) error {
+				ran = true
+				return nil
+			},
+		}
+	}
+
+	err := newApp().Run([]string{"app", "--generate-config", "--count", "3", "--tag", "a", "--tag", "b", "--label", "env=prod", "--timeout=90s"})
+	expect(t, err, nil)
+	expect(t, ran, false)
+	expect(t, output.String(), `{
+  "count": 3,
+  "label": {
+    "env": "prod"
+  },
+  "region": "us-west",
+  "tag": [
+    "a",
+    "b"
+  ],
+  "timeout": "1m30s",
+  "verbose": false
+}
+`)
+
+	// the config is loaded back as an input source
+	var config map[string]interface{}
+	expect(t, json.Unmarshal(output.Bytes(), &config), nil)
+	os.Clearenv()
+	app := newApp()
+	app.Before = InitAllInputSource(func(ctx *Context) (InputSourceContext, error) {
+		return mapSource(config), nil
+	})
+	app.Action = func(ctx *Context) error {
+		expect(t, ctx.String("region"), "us-west")
+		expect(t, ctx.Int("count"), 3)
+		expect(t, ctx.Duration("timeout"), 90*time.Second)
+		expect(t, ctx.StringSlice("tag"), []string{"a", "b"})
+		expect(t, ctx.StringMap("label"), map[string]string{"env": "prod"})
+		expect(t, ctx.String("token"), "hunter2")
+		return nil
+	}
+	expect(t, app.Run([]string{"app"}), nil)
+
+	output.Reset()
+	expect(t, newApp().Run([]string{"app"}), nil)
+	expect(t, ran, true)
+	expect(t, output.String(), "")
+
+	// secrets are masked instead of omitted, and command flags are not written
+	output.Reset()
+	ran = false
+	app = newApp()
+	app.GenerateConfigMaskSecrets = true
+	app.Flags = []Flag{app.Flags[1], app.Flags[6]}
+	app.Commands = []*Command{{Name: "serve", Flags: []Flag{&IntFlag{Name: "port", Value: 80}}}}
+	expect(t, app.Run([]string{"app", "--generate-config"}), nil)
+	expect(t, ran, false)
+	expect(t, output.String(), `{
+  "count": 1,
+  "token": "***"
+}
+`)
+}
+
+func TestApp_PositionalAlias(t *testing.T) {
+	var file, mode string
+	var args []string
+	newApp := func() *App {
+		return &App{
+			Writer: ioutil.Discard,
+			Flags: []Flag{
+				&StringFlag{Name: "file", PositionalAlias: 1},
+				&StringFlag{Name: "mode", PositionalAlias: 2, Value: "read"},
+			},
+			Action: func(ctx *Context) error {
+				file, mode, args = ctx.String("file"), ctx.String("mode"), ctx.Args().Slice()
+				return nil
+			},
+			Commands: []*Command{{
+				Name:  "cat",
+				Flags: []Flag{&PathFlag{Name: "path", PositionalAlias: 1}},
+				Action: func(ctx *Context) error {
+					file, args = ctx.Path("path"), ctx.Args().Slice()
+					return nil
+				},
+			}},
+		}
+	}
+
+	expect(t, newApp().Run([]string{"app", "x.txt", "write", "extra"}), nil)
+	expect(t, file, "x.txt")
+	expect(t, mode, "write")
+	expect(t, args, []string{"extra"})
+
+	expect(t, newApp().Run([]string{"app", "--file", "y.txt", "write"}), nil)
+	expect(t, file, "y.txt")
+	expect(t, mode, "read")
+	expect(t, args, []string{"write"})
+
+	expect(t, newApp().Run([]string{"app", "z.txt"}), nil)
+	expect(t, file, "z.txt")
+	expect(t, mode, "read")
+	expect(t, args, []string{})
+
+	expect(t, newApp().Run([]string{"app", "cat", "a.txt", "b.txt"}), nil)
+	expect(t, file, "a.txt")
+	expect(t, args, []string{"b.txt"})
+
+	app := newApp()
+	app.Flags = append(app.Flags, &IntFlag{Name: "count", PositionalAlias: 2})
+	err := app.Run([]string{"app", "x.txt"})
+	expect(t, err.Error(), "flags --mode and --count both claim positional argument 2")
+}
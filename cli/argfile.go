@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// expandArgFiles replaces any argument of the form @file with the whitespace
+// separated arguments read from file. Argument files may reference other
+// argument files, and an error is returned if a file includes itself.
+func expandArgFiles(arguments []string) ([]string, error) {
+	return expandArgFilesFrom(arguments, map[string]bool{})
+}
+
+func expandArgFilesFrom(arguments []string, seen map[string]bool) ([]string, error) {
+	var result []string
+	for _, arg := range arguments {
+		if len(arg) < 2 || arg[0] != '@' {
+			result = append(result, arg)
+			continue
+		}
+		path := arg[1:]
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[abs] {
+			return nil, fmt.Errorf("argument file %s includes itself", path)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read argument file: %s", err)
+		}
+		seen[abs] = true
+		expanded, err := expandArgFilesFrom(strings.Fields(string(data)), seen)
+		if err != nil {
+			return nil, err
+		}
+		delete(seen, abs)
+		result = append(result, expanded...)
+	}
+	return result, nil
+}
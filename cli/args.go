@@ -1,5 +1,10 @@
 package cli
 
+import (
+	"fmt"
+	"sort"
+)
+
 type Args interface {
 	// Get returns the nth argument, or else a blank string
 	Get(n int) string
@@ -52,3 +57,64 @@ func (a *args) Slice() []string {
 	copy(ret, *a)
 	return ret
 }
+
+// resolvePositionalAliases sets each flag with a PositionalAlias which is
+// not given on the command line from the argument at that position, then
+// removes the arguments used from the context. It is an error for two flags
+// to claim the same position. The flags of an App are not resolved if the
+// first argument names one of its commands.
+func resolvePositionalAliases(flags []Flag, context *Context) error {
+	if context.Command == nil && context.App != nil && context.App.Command(context.Args().First()) != nil {
+		return nil
+	}
+	claims := map[int]Flag{}
+	for _, f := range flags {
+		pos, _ := getFlagPositionalAlias(f)
+		if pos <= 0 {
+			continue
+		}
+		if other, ok := claims[pos]; ok {
+			return fmt.Errorf("flags %s and %s both claim positional argument %d",
+				prefixedName(other), prefixedName(f), pos)
+		}
+		claims[pos] = f
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+	unknown := len(context.flagSet.Unknown())
+	positional := context.flagSet.Args()[unknown:]
+	used := map[int]bool{}
+	var positions []int
+	for pos := range claims {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+	for _, pos := range positions {
+		f := claims[pos]
+		names := FlagNames(f)
+		if pos > len(positional) || context.Source(names[0]) == "flag" {
+			continue
+		}
+		if err := context.Set(names[0], positional[pos-1]); err != nil {
+			return err
+		}
+		context.flagSet.NeedsVisit(names[1:]...)
+		setFlagSource(context.flagSet, names, "", "")
+		used[pos-1] = true
+	}
+	args := append([]string{}, context.flagSet.Args()[:unknown]...)
+	for i, arg := range positional {
+		if !used[i] {
+			args = append(args, arg)
+		}
+	}
+	context.flagSet.SetArgs(args)
+	return nil
+}
+
+// prefixedName returns the first name of f with its dashes
+func prefixedName(f Flag) string {
+	name := FlagNames(f)[0]
+	return prefixFor(name) + name
+}
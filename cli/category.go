@@ -1,5 +1,7 @@
 package cli
 
+import "sort"
+
 // CommandCategories interface allows for category manipulation
 type CommandCategories interface {
 	// AddCommand adds a command to a category, creating a new category if necessary.
@@ -77,3 +79,14 @@ func (c *commandCategory) VisibleCommands() []*Command {
 	}
 	return ret
 }
+
+// sortedCategory is a category with its visible commands sorted by name
+type sortedCategory struct {
+	CommandCategory
+}
+
+func (c sortedCategory) VisibleCommands() []*Command {
+	commands := c.CommandCategory.VisibleCommands()
+	sort.Stable(CommandsByName(commands))
+	return commands
+}
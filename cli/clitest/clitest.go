@@ -0,0 +1,94 @@
+// Package clitest provides helpers for testing a cli.App, running it with
+// its output captured and with a given environment.
+package clitest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rancher/spur/cli"
+)
+
+// Run runs app with the arguments following the program name, returning
+// what it wrote to its Writer and ErrWriter. The writers of the App are
+// restored once it returns. If the App has no ExitErrHandler an error which
+// is a cli.ExitCoder is written to stderr as cli.HandleExitCoder writes it,
+// and returned rather than exiting.
+func Run(app *cli.App, args ...string) (stdout, stderr string, err error) {
+	var outBuf, errBuf bytes.Buffer
+	// set up the App first so that its default writers are restored
+	app.Setup()
+	writer, errWriter, exitErrHandler := app.Writer, app.ErrWriter, app.ExitErrHandler
+	defer func() {
+		app.Writer, app.ErrWriter, app.ExitErrHandler = writer, errWriter, exitErrHandler
+	}()
+	app.Writer, app.ErrWriter = &outBuf, &errBuf
+	if app.ExitErrHandler == nil && app.ErrorFormat != cli.ErrorFormatJSON {
+		app.ExitErrHandler = func(_ *cli.Context, err error) {
+			writeExitError(&errBuf, err)
+		}
+	}
+	name := app.Name
+	if name == "" {
+		name = "app"
+	}
+	err = app.Run(append([]string{name}, args...))
+	return outBuf.String(), errBuf.String(), err
+}
+
+// writeExitError writes err to w as cli.HandleExitCoder writes it to
+// cli.ErrWriter, without calling cli.OsExiter
+func writeExitError(w io.Writer, err error) {
+	if exitErr, ok := err.(cli.ExitCoder); ok {
+		if err.Error() != "" {
+			if _, ok := exitErr.(cli.ErrorFormatter); ok {
+				fmt.Fprintf(w, "%+v\n", err)
+			} else {
+				fmt.Fprintln(w, err)
+			}
+		}
+		return
+	}
+	if multiErr, ok := err.(cli.MultiError); ok {
+		writeMultiError(w, multiErr)
+	}
+}
+
+func writeMultiError(w io.Writer, multiErr cli.MultiError) {
+	for _, merr := range multiErr.Errors() {
+		if multiErr2, ok := merr.(cli.MultiError); ok {
+			writeMultiError(w, multiErr2)
+		} else if merr != nil {
+			fmt.Fprintln(w, merr)
+		}
+	}
+}
+
+// RunWithEnv runs app as Run does with only the variables of env set in
+// the environment, restoring the environment once it returns.
+func RunWithEnv(app *cli.App, env map[string]string, args ...string) (stdout, stderr string, err error) {
+	defer SetEnv(env)()
+	return Run(app, args...)
+}
+
+// SetEnv clears the environment and sets the variables of env, returning a
+// func to restore the previous environment, such as with
+// defer clitest.SetEnv(env)()
+func SetEnv(env map[string]string) (restore func()) {
+	previous := os.Environ()
+	os.Clearenv()
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+	return func() {
+		os.Clearenv()
+		for _, e := range previous {
+			if fields := strings.SplitN(e, "=", 2); len(fields) == 2 {
+				os.Setenv(fields[0], fields[1])
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package clitest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/rancher/spur/cli"
+)
+
+func TestRun(t *testing.T) {
+	app := &cli.App{
+		Name:  "greet",
+		Flags: []cli.Flag{&cli.StringFlag{Name: "name", EnvVars: []string{"GREET_NAME"}, Value: "world"}},
+		Action: func(ctx *cli.Context) error {
+			fmt.Fprintf(ctx.App.Writer, "hello %s", ctx.String("name"))
+			fmt.Fprint(ctx.App.ErrWriter, "done")
+			return nil
+		},
+	}
+	stdout, stderr, err := Run(app, "--name", "spur")
+	if stdout != "hello spur" || stderr != "done" || err != nil {
+		t.Errorf("Run returned %q, %q, %v", stdout, stderr, err)
+	}
+	if app.Writer != os.Stdout || app.ErrWriter != os.Stderr || app.ExitErrHandler != nil {
+		t.Error("expected the App writers to be restored")
+	}
+
+	os.Setenv("CLITEST_KEEP", "1")
+	defer os.Unsetenv("CLITEST_KEEP")
+	stdout, _, err = RunWithEnv(app, map[string]string{"GREET_NAME": "env"})
+	if stdout != "hello env" || err != nil {
+		t.Errorf("RunWithEnv returned %q, %v", stdout, err)
+	}
+	if os.Getenv("GREET_NAME") != "" || os.Getenv("CLITEST_KEEP") != "1" {
+		t.Error("expected the environment to be restored")
+	}
+}
+
+func TestRunExitCoder(t *testing.T) {
+	app := &cli.App{
+		Action: func(ctx *cli.Context) error {
+			return cli.Exit("failed", 3)
+		},
+	}
+	_, stderr, err := Run(app)
+	var exitErr cli.ExitCoder
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit code 3, got %v", err)
+	}
+	if stderr != "failed\n" {
+		t.Errorf("expected the exit message on stderr, got %q", stderr)
+	}
+
+	app.Action = func(ctx *cli.Context) error {
+		return cli.Exit("", 4)
+	}
+	if _, stderr, _ := Run(app); stderr != "" {
+		t.Errorf("expected no output for an empty exit message, got %q", stderr)
+	}
+}
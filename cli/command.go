@@ -20,6 +20,9 @@ type Command struct {
 	UsageText string
 	// A longer explanation of how the command works
 	Description string
+	// Examples of invocations of the command, shown in the EXAMPLES
+	// section of help
+	Examples []*Example
 	// A short description of the arguments of this command
 	ArgsUsage string
 	// The category the command is part of
@@ -32,6 +35,11 @@ type Command struct {
 	// An action to execute after any subcommands are run, but after the subcommand has finished
 	// It is run even if Action() panics
 	After AfterFunc
+	// Validator is called once the flags of the command are parsed and
+	// have passed the built-in checks, such as for required flags, and
+	// before Before and Action are run, to check rules which span several
+	// flags. An error is returned without running the command.
+	Validator func(ctx *Context) error
 	// The function to call when this command is invoked
 	Action ActionFunc
 	// Execute this function if a usage error occurs.
@@ -40,8 +48,19 @@ type Command struct {
 	Subcommands []*Command
 	// List of flags to parse
 	Flags []Flag
+	// ArgsFlag is a slice flag, such as a StringSliceFlag, which is set to
+	// the arguments of the command so that they are parsed and validated
+	// with its type, Choices, MinItems, MaxItems and Unique, and may be
+	// read by its name, such as ctx.StringSlice("items") for "add
+	// <items...>". It is ignored for commands with subcommands.
+	ArgsFlag Flag
 	// Treat all flags as normal arguments if true
 	SkipFlagParsing bool
+	// IgnoreUnknownFlags parses the flags of the command but keeps any
+	// which are not defined at the start of its arguments, such as to pass
+	// them on to another program, see Context.UnknownFlags. It is ignored for
+	// commands with subcommands.
+	IgnoreUnknownFlags bool
 	// Boolean to hide built-in help command and help flag
 	HideHelp bool
 	// Boolean to hide built-in help command but keep help flag
@@ -57,6 +76,8 @@ type Command struct {
 	// Full name of command for help, defaults to full command name, including parent commands.
 	HelpName        string
 	commandNamePath []string
+	// the App running the command, set per run
+	app *App
 
 	// CustomHelpTemplate the text template for the command help topic.
 	// cli.go uses text/template to render templates. You can
@@ -100,40 +121,78 @@ func (c *Command) Run(ctx *Context) (err error) {
 		c.appendFlag(HelpFlag)
 	}
 
-	if ctx.App.UseShortOptionHandling {
-		c.UseShortOptionHandling = true
-	}
+	// inherit short option handling and env names from the app for this run
+	c.app = ctx.App
 
 	set, err := c.parseFlags(ctx.Args(), ctx.shellComplete)
+	if err == nil && c.ArgsFlag != nil {
+		err = applyArgsFlag(c.ArgsFlag, set)
+	}
 
 	context := NewContext(ctx.App, set, ctx)
 	context.Command = c
+	if err == nil {
+		inheritPersistentFlags(ctx.App.PersistentFlags, context)
+	}
 	if checkCommandCompletions(context, c.Name) {
 		return nil
 	}
 
 	if err != nil {
-		if c.OnUsageError != nil {
-			err = c.OnUsageError(context, err, false)
+		onUsageError, isSubcommand := c.OnUsageError, false
+		if onUsageError == nil {
+			onUsageError, isSubcommand = ctx.App.OnUsageError, true
+		}
+		if onUsageError != nil {
+			err = onUsageError(context, err, isSubcommand)
 			context.App.handleExitCoder(context, err)
 			return err
 		}
-		fmt.Fprintln(context.App.Writer, "Incorrect Usage:", err.Error())
-		fmt.Fprintln(context.App.Writer)
-		ShowCommandHelp(context, c.Name)
-		return err
+		if !context.App.jsonErrors() {
+			fmt.Fprintln(context.App.Writer, context.App.translate(MsgIncorrectUsage), err.Error())
+			fmt.Fprintln(context.App.Writer)
+			ShowCommandHelp(context, c.Name)
+		}
+		return newCommandError(ctx.App.Name+" "+c.Name, err)
 	}
 
-	if checkCommandHelp(context, c.Name) {
+	if !c.HideHelp && checkCommandHelp(context, c.Name) {
 		return nil
 	}
 
-	cerr := checkRequiredFlags(c.Flags, context)
+	flags := c.allFlags()
+	if c.ArgsFlag != nil {
+		flags = append(flags[:len(flags):len(flags)], c.ArgsFlag)
+	}
+	ferr, cerr := resolveAndCheckFlags(flags, nil, context)
 	if cerr != nil {
-		ShowCommandHelp(context, c.Name)
-		return cerr
+		if !context.App.jsonErrors() {
+			ShowCommandHelp(context, c.Name)
+		}
+		return joinErrors(ferr, cerr)
+	}
+	if ferr != nil {
+		context.App.handleExitCoder(context, ferr)
+		return ferr
+	}
+	setParsedContext(context)
+
+	if c.Validator != nil {
+		if err := c.Validator(context); err != nil {
+			context.App.handleExitCoder(context, err)
+			return err
+		}
+	}
+
+	err = context.App.withMiddleware(c.run)(context)
+	if err != nil {
+		context.App.handleExitCoder(context, err)
 	}
+	return err
+}
 
+// run runs the Before, Action and After funcs of the command
+func (c *Command) run(context *Context) (err error) {
 	if c.After != nil {
 		defer func() {
 			afterErr := c.After(context)
@@ -149,9 +208,7 @@ func (c *Command) Run(ctx *Context) (err error) {
 	}
 
 	if c.Before != nil {
-		err = c.Before(context)
-		if err != nil {
-			context.App.handleExitCoder(context, err)
+		if err = c.Before(context); err != nil {
 			return err
 		}
 	}
@@ -161,20 +218,27 @@ func (c *Command) Run(ctx *Context) (err error) {
 	}
 
 	context.Command = c
-	err = c.Action(context)
-
-	if err != nil {
-		context.App.handleExitCoder(context, err)
-	}
-	return err
+	return c.Action(context)
 }
 
 func (c *Command) newFlagSet() (*flag.FlagSet, error) {
-	return flagSet(c.Name, c.Flags)
+	flags := c.allFlags()
+	trackHistory, boolWords := false, false
+	if c.app != nil {
+		flags = c.app.envFlags(flags)
+		trackHistory = c.app.TrackFlagHistory
+		boolWords = c.app.BoolFlagWords
+	}
+	set, err := flagSet(c.Name, flags, trackHistory)
+	if err == nil {
+		set.IgnoreUnknown = c.IgnoreUnknownFlags
+		set.BoolWords = boolWords
+	}
+	return set, err
 }
 
 func (c *Command) useShortOptionHandling() bool {
-	return c.UseShortOptionHandling
+	return c.UseShortOptionHandling || c.app != nil && c.app.UseShortOptionHandling
 }
 
 func (c *Command) parseFlags(args Args, shellComplete bool) (*flag.FlagSet, error) {
@@ -192,7 +256,7 @@ func (c *Command) parseFlags(args Args, shellComplete bool) (*flag.FlagSet, erro
 		return nil, err
 	}
 
-	err = normalizeFlags(c.Flags, set)
+	err = normalizeFlags(c.allFlags(), set)
 	if err != nil {
 		return nil, err
 	}
@@ -215,6 +279,17 @@ func (c *Command) HasName(name string) bool {
 	return false
 }
 
+// findCommand returns the command in commands with the given name or alias,
+// or nil if not found
+func findCommand(commands []*Command, name string) *Command {
+	for _, c := range commands {
+		if c.HasName(name) {
+			return c
+		}
+	}
+	return nil
+}
+
 func (c *Command) startApp(ctx *Context) error {
 	app := &App{
 		Metadata: ctx.App.Metadata,
@@ -229,6 +304,7 @@ func (c *Command) startApp(ctx *Context) error {
 
 	app.Usage = c.Usage
 	app.Description = c.Description
+	app.Examples = c.Examples
 	app.ArgsUsage = c.ArgsUsage
 
 	// set CommandNotFound
@@ -246,8 +322,24 @@ func (c *Command) startApp(ctx *Context) error {
 	app.Compiled = ctx.App.Compiled
 	app.Writer = ctx.App.Writer
 	app.ErrWriter = ctx.App.ErrWriter
+	app.Warn = ctx.App.Warn
+	app.WarnDeprecated = ctx.App.WarnDeprecated
+	app.ValueSources = ctx.App.ValueSources
 	app.ExitErrHandler = ctx.App.ExitErrHandler
 	app.UseShortOptionHandling = ctx.App.UseShortOptionHandling
+	app.BoolFlagWords = ctx.App.BoolFlagWords
+	app.EnvPrefix = ctx.App.EnvPrefix
+	app.EnvNameFunc = ctx.App.EnvNameFunc
+	app.EnvAliases = ctx.App.EnvAliases
+	app.TrackFlagHistory = ctx.App.TrackFlagHistory
+	app.PromptMissing = ctx.App.PromptMissing
+	app.ErrorFormat = ctx.App.ErrorFormat
+	app.Translator = ctx.App.Translator
+	app.PersistentFlags = ctx.App.PersistentFlags
+	app.SortFlags = ctx.App.SortFlags
+	app.SortCommands = ctx.App.SortCommands
+	app.middleware = ctx.App.middleware
+	app.command = c
 
 	app.categories = newCommandCategories()
 	for _, command := range c.Subcommands {
@@ -263,6 +355,7 @@ func (c *Command) startApp(ctx *Context) error {
 	}
 
 	// set the actions
+	app.Validator = c.Validator
 	app.Before = c.Before
 	app.After = c.After
 	if c.Action != nil {
@@ -271,6 +364,9 @@ func (c *Command) startApp(ctx *Context) error {
 		app.Action = helpSubcommand.Action
 	}
 	app.OnUsageError = c.OnUsageError
+	if app.OnUsageError == nil {
+		app.OnUsageError = ctx.App.OnUsageError
+	}
 
 	for index, cc := range app.Commands {
 		app.Commands[index].commandNamePath = []string{c.Name, cc.Name}
@@ -279,9 +375,68 @@ func (c *Command) startApp(ctx *Context) error {
 	return app.RunAsSubcommand(ctx)
 }
 
-// VisibleFlags returns a slice of the Flags with Hidden=false
+// VisibleFlags returns a slice of the Flags with Hidden=false, sorted if
+// the App running the command has SortFlags set
 func (c *Command) VisibleFlags() []Flag {
-	return visibleFlags(c.Flags)
+	return c.app.sortFlags(visibleFlags(c.Flags))
+}
+
+// VisiblePersistentFlags returns a slice of the PersistentFlags of the App
+// running the command with Hidden=false
+func (c *Command) VisiblePersistentFlags() []Flag {
+	if c.app == nil {
+		return nil
+	}
+	return c.app.VisiblePersistentFlags()
+}
+
+// allFlags returns the Flags of the command followed by the
+// PersistentFlags of the App running it
+func (c *Command) allFlags() []Flag {
+	if c.app == nil || len(c.app.PersistentFlags) == 0 {
+		return c.Flags
+	}
+	return append(append([]Flag{}, c.Flags...), c.app.PersistentFlags...)
+}
+
+// applyArgsFlag applies the ArgsFlag of a command to set, then sets it to
+// each of the arguments remaining after the flags in set
+func applyArgsFlag(f Flag, set *flag.FlagSet) error {
+	if err := f.Apply(set); err != nil {
+		return err
+	}
+	name := FlagNames(f)[0]
+	for _, arg := range set.Args() {
+		if err := set.Set(name, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inheritPersistentFlags copies the value of each of the persistent flags
+// set for a parent of context to the flags of context, unless set again
+func inheritPersistentFlags(flags []Flag, context *Context) {
+	if context.parentContext == nil {
+		return
+	}
+	for _, f := range flags {
+		names := FlagNames(f)
+		if isSetIn(context.flagSet, names[0]) {
+			continue
+		}
+		for _, parent := range context.parentContext.Lineage() {
+			if parent.flagSet == nil || !isSetIn(parent.flagSet, names[0]) {
+				continue
+			}
+			ff := parent.flagSet.Lookup(names[0])
+			for _, name := range names {
+				copyFlag(name, ff, context.flagSet)
+			}
+			setFlagSource(context.flagSet, names, ff.Source, ff.Path)
+			break
+		}
+	}
 }
 
 func (c *Command) appendFlag(fl Flag) {
@@ -290,6 +445,28 @@ func (c *Command) appendFlag(fl Flag) {
 	}
 }
 
+// checkCommandNames returns an error if a name or alias of one of added is
+// already used by one of commands or another of added
+func checkCommandNames(commands, added []*Command) error {
+	used := map[string]*Command{}
+	for _, c := range commands {
+		for _, name := range c.Names() {
+			used[name] = c
+		}
+	}
+	for _, c := range added {
+		for _, name := range c.Names() {
+			if existing, ok := used[name]; ok {
+				return fmt.Errorf("command %s: name %q is already used by command %s", c.Name, name, existing.Name)
+			}
+		}
+		for _, name := range c.Names() {
+			used[name] = c
+		}
+	}
+	return nil
+}
+
 func hasCommand(commands []*Command, command *Command) bool {
 	for _, existing := range commands {
 		if command == existing {
@@ -44,7 +44,7 @@ func TestCommandFlagParsing(t *testing.T) {
 
 		err := command.Run(context)
 
-		expect(t, err, c.expectedErr)
+		expect(t, errors.Unwrap(err), c.expectedErr)
 		expect(t, context.Args().Slice(), c.testArgs)
 	}
 }
@@ -99,7 +99,7 @@ func TestParseAndRunShortOpts(t *testing.T) {
 
 		err := app.Run(c.testArgs)
 
-		expect(t, err, c.expectedErr)
+		expect(t, errors.Unwrap(err), c.expectedErr)
 		expect(t, args, c.expectedArgs)
 	}
 }
@@ -333,6 +333,61 @@ func TestCommandSkipFlagParsing(t *testing.T) {
 	}
 }
 
+func TestCommandHideHelpForwardsHelpFlag(t *testing.T) {
+	var forwarded []string
+	var human bool
+	var out bytes.Buffer
+	app := &App{
+		Commands: []*Command{
+			{
+				Name:            "exec",
+				HideHelp:        true,
+				SkipFlagParsing: true,
+				Action: func(c *Context) error {
+					forwarded = c.Args().Slice()
+					return nil
+				},
+			},
+			{
+				Name:     "du",
+				HideHelp: true,
+				Flags:    []Flag{&BoolFlag{Name: "h", Usage: "human readable sizes"}},
+				Action: func(c *Context) error {
+					human = c.Bool("h")
+					return nil
+				},
+			},
+			{
+				Name:     "remote",
+				HideHelp: true,
+				Flags:    []Flag{&BoolFlag{Name: "help"}},
+				Subcommands: []*Command{
+					{Name: "add"},
+				},
+				Action: func(c *Context) error {
+					human = c.Bool("help")
+					return nil
+				},
+			},
+		},
+		Writer: &out,
+	}
+
+	expect(t, app.Run([]string{"app", "exec", "ls", "-h"}), nil)
+	expect(t, forwarded, []string{"ls", "-h"})
+
+	expect(t, app.Run([]string{"app", "du", "-h"}), nil)
+	expect(t, human, true)
+
+	human = false
+	expect(t, app.Run([]string{"app", "remote", "--help"}), nil)
+	expect(t, human, true)
+
+	if out.Len() != 0 {
+		t.Errorf("expected no help output, got %q", out.String())
+	}
+}
+
 func TestCommand_Run_CustomShellCompleteAcceptsMalformedFlags(t *testing.T) {
 	cases := []struct {
 		testArgs    args
@@ -378,3 +433,97 @@ func TestCommand_Run_CustomShellCompleteAcceptsMalformedFlags(t *testing.T) {
 	}
 
 }
+
+func TestCommandIgnoreUnknownFlags(t *testing.T) {
+	var verbose bool
+	var name string
+	var cargs Args
+	var unknown []string
+	command := &Command{
+		Name:  "wrap",
+		Flags: []Flag{&BoolFlag{Name: "verbose", Aliases: []string{"v"}}, &StringFlag{Name: "name"}},
+		Action: func(c *Context) error {
+			verbose = c.Bool("verbose")
+			name = c.String("name")
+			cargs = c.Args()
+			unknown = c.UnknownFlags()
+			return nil
+		},
+	}
+	app := &App{Commands: []*Command{command}, Writer: ioutil.Discard}
+
+	tests := []struct {
+		args    []string
+		ignore  bool
+		skip    bool
+		verbose bool
+		name    string
+		expect  []string
+		unknown []string
+	}{
+		{[]string{"app", "wrap", "-v", "--color=auto", "--name", "x", "-q", "file"}, true, false, true, "x",
+			[]string{"--color=auto", "-q", "file"}, []string{"--color=auto", "-q"}},
+		{[]string{"app", "wrap", "--depth", "2", "-v"}, true, false, false, "",
+			[]string{"--depth", "2", "-v"}, []string{"--depth"}},
+		{[]string{"app", "wrap", "-v", "file"}, true, false, true, "",
+			[]string{"file"}, []string{}},
+		{[]string{"app", "wrap", "-v", "--color=auto", "--name", "x", "file"}, false, true, false, "",
+			[]string{"-v", "--color=auto", "--name", "x", "file"}, []string{}},
+	}
+	for _, test := range tests {
+		verbose, name, cargs, unknown = false, "", nil, nil
+		command.IgnoreUnknownFlags, command.SkipFlagParsing = test.ignore, test.skip
+		expect(t, app.Run(test.args), nil)
+		expect(t, verbose, test.verbose)
+		expect(t, name, test.name)
+		expect(t, cargs.Slice(), test.expect)
+		expect(t, unknown, test.unknown)
+	}
+
+	command.IgnoreUnknownFlags, command.SkipFlagParsing = false, false
+	if err := app.Run([]string{"app", "wrap", "--color=auto"}); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestCommandArgsFlag(t *testing.T) {
+	var ids []int
+	var rest []string
+	app := &App{
+		Name:   "app",
+		Writer: ioutil.Discard,
+		Commands: []*Command{{
+			Name:     "add",
+			Flags:    []Flag{&StringSliceFlag{Name: "tag", Unique: true}},
+			ArgsFlag: &IntSliceFlag{Name: "ids", MinItems: 1, MaxItems: 3, Unique: true},
+			Action: func(c *Context) error {
+				ids = c.IntSlice("ids")
+				rest = c.Args().Slice()
+				return nil
+			},
+		}},
+	}
+
+	expect(t, app.Run([]string{"app", "add", "--tag", "a", "1", "2", "0x10"}), nil)
+	expect(t, ids, []int{1, 2, 16})
+	expect(t, rest, []string{"1", "2", "0x10"})
+
+	tests := []struct {
+		args   []string
+		expect string
+	}{
+		{[]string{"app", "add"}, "--ids requires at least 1 values, got 0"},
+		{[]string{"app", "add", "1", "2", "3", "4"}, "--ids accepts at most 3 values, got 4"},
+		{[]string{"app", "add", "1", "2", "1"}, "--ids has the duplicate value 1"},
+		{[]string{"app", "add", "--tag", "a", "--tag", "a", "1"}, "--tag has the duplicate value a"},
+		{[]string{"app", "add", "1", "x"}, `app add: invalid value "x" for flag -ids: parse error`},
+	}
+	for _, test := range tests {
+		err := app.Run(test.args)
+		if err == nil {
+			t.Errorf("expected an error for %q", test.args)
+			continue
+		}
+		expect(t, err.Error(), test.expect)
+	}
+}
@@ -2,11 +2,14 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"reflect"
 	"strings"
 
 	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
 )
 
 // Context is a type that is passed through to
@@ -15,11 +18,16 @@ import (
 // parsed command-line options.
 type Context struct {
 	context.Context
-	App           *App
+	// App is the App being run, which for the subcommands of a command is
+	// an App created from that command
+	App *App
+	// Command is the command whose Action, Before or After func is being
+	// run, or nil for those of the App
 	Command       *Command
 	shellComplete bool
 	flagSet       *flag.FlagSet
 	parentContext *Context
+	deriver       *flagDeriver
 }
 
 // NewContext creates a new context. For use in when invoking an App or Command action.
@@ -33,8 +41,6 @@ func NewContext(app *App, set *flag.FlagSet, parentCtx *Context) *Context {
 		}
 	}
 
-	c.Command = &Command{}
-
 	if c.Context == nil {
 		c.Context = context.Background()
 	}
@@ -42,9 +48,10 @@ func NewContext(app *App, set *flag.FlagSet, parentCtx *Context) *Context {
 	return c
 }
 
-// NumFlags returns the number of flags set
+// NumFlags returns the number of flags set, counting a flag set by any of
+// its names once
 func (c *Context) NumFlags() int {
-	return c.flagSet.NFlag()
+	return len(c.LocalFlagNames())
 }
 
 // Set sets a context flag to a value.
@@ -55,32 +62,133 @@ func (c *Context) Set(name string, value interface{}) error {
 // IsSet determines if the flag was actually set
 func (c *Context) IsSet(name string) bool {
 	if fs := lookupFlagSet(name, c); fs != nil {
-		isSet := false
-		fs.Visit(func(f *flag.Flag) {
-			if f.Name == name {
-				isSet = true
-			}
-		})
-		if isSet {
-			return true
+		return isSetIn(fs, name)
+	}
+	return false
+}
+
+// GlobalIsSet determines if the named flag of the root context, that of the
+// App, was actually set, even when inside a command which defines a local
+// flag of the same name
+func (c *Context) GlobalIsSet(name string) bool {
+	lineage := c.Lineage()
+	for i := len(lineage) - 1; i >= 0; i-- {
+		// skip the parent contexts of the root which have no App
+		if fs := lineage[i].flagSet; fs != nil && lineage[i].App != nil {
+			return isSetIn(fs, name)
 		}
 	}
 	return false
 }
 
+// isSetIn returns true if the named flag was set in the flag set
+func isSetIn(set *flag.FlagSet, name string) bool {
+	isSet := false
+	set.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			isSet = true
+		}
+	})
+	return isSet
+}
+
+// Source returns where the value of the named flag was set from, which is
+// "flag" for the command line, "env" or "file" for values read from the
+// environment or a file, "resolver" for other Resolvers, "altsrc" for an
+// input source, "source" for App.ValueSources, "prompt" for a value entered
+// with App.PromptMissing, "default" if the flag is not set, or an empty
+// string if the flag is not defined
+func (c *Context) Source(name string) string {
+	fs := lookupFlagSet(name, c)
+	if fs == nil {
+		return ""
+	}
+	ff := sourceFlag(name, fs, c)
+	if ff == nil {
+		return "default"
+	}
+	if ff.Source == "" {
+		return "flag"
+	}
+	return ff.Source
+}
+
+// FilePath returns the path of the file the value of the named flag was
+// read from, or an empty string if the value was not read from a file
+func (c *Context) FilePath(name string) string {
+	if fs := lookupFlagSet(name, c); fs != nil {
+		if ff := sourceFlag(name, fs, c); ff != nil {
+			return ff.Path
+		}
+	}
+	return ""
+}
+
+// FlagAssignment is a value assigned to a flag and its source, as returned
+// by Context.Source, with the path of the file it was read from if any
+type FlagAssignment struct {
+	Value  interface{}
+	Source string
+	Path   string
+}
+
+// History returns each value assigned to the named flag in order, starting
+// with its default, or nil if App.TrackFlagHistory is not set
+func (c *Context) History(name string) []FlagAssignment {
+	fs := lookupFlagSet(name, c)
+	if fs == nil {
+		return nil
+	}
+	ff := fs.Lookup(name)
+	if ff == nil {
+		return nil
+	}
+	var history []FlagAssignment
+	for _, a := range ff.History {
+		source := a.Source
+		if source == "" {
+			source = "flag"
+		}
+		history = append(history, FlagAssignment{Value: a.Value, Source: source, Path: a.Path})
+	}
+	return history
+}
+
+// sourceFlag returns the set flag of any of the names of the named flag
+// which determines its source, preferring a name set on the command line
+func sourceFlag(name string, set *flag.FlagSet, c *Context) *flag.Flag {
+	names := []string{name}
+	if f := lookupFlag(name, c); f != nil {
+		names = FlagNames(f)
+	}
+	var result *flag.Flag
+	set.Visit(func(ff *flag.Flag) {
+		for _, name := range names {
+			if ff.Name == name && (result == nil || ff.Source == "") {
+				result = ff
+			}
+		}
+	})
+	return result
+}
+
 // LocalFlagNames returns a slice of flag names used in this context.
+// Each flag is listed once by its canonical name, regardless of which
+// alias was used.
 func (c *Context) LocalFlagNames() []string {
 	var names []string
-	c.flagSet.Visit(makeFlagNameVisitor(&names))
+	c.flagSet.Visit(c.makeFlagNameVisitor(map[string]bool{}, &names))
 	return names
 }
 
 // FlagNames returns a slice of flag names used by the this context and all of
-// its parent contexts.
+// its parent contexts. Each flag is listed once by its canonical name.
+// The declared flags may be found with GetFlags.
 func (c *Context) FlagNames() []string {
 	var names []string
+	seen := map[string]bool{}
 	for _, ctx := range c.Lineage() {
-		ctx.flagSet.Visit(makeFlagNameVisitor(&names))
+		ctx.flagSet.Visit(ctx.makeFlagNameVisitor(seen, &names))
 	}
 	return names
 }
@@ -99,6 +207,9 @@ func (c *Context) Lineage() []*Context {
 
 // Value returns the value of the flag corresponding to `name`
 func (c *Context) Value(name string) interface{} {
+	if c.deriver != nil {
+		c.deriver.lookup(name)
+	}
 	return c.flagSet.Lookup(name).Value.(flag.Getter).Get()
 }
 
@@ -108,6 +219,15 @@ func (c *Context) Args() Args {
 	return &ret
 }
 
+// UnknownFlags returns the flags which were not defined for a command with
+// IgnoreUnknownFlags, which are also at the start of the arguments
+func (c *Context) UnknownFlags() []string {
+	unknown := c.flagSet.Unknown()
+	ret := make([]string, len(unknown))
+	copy(ret, unknown)
+	return ret
+}
+
 // NArg returns the number of the command line arguments.
 func (c *Context) NArg() int {
 	return c.Args().Len()
@@ -116,10 +236,13 @@ func (c *Context) NArg() int {
 // Lookup will return the value for a flag, or the default value if
 // the flag value does not exist or is not of the same type
 func (c *Context) Lookup(name string, defaultVal interface{}) interface{} {
+	if c.deriver != nil {
+		c.deriver.lookup(name)
+	}
 	var result interface{}
 	if fs := lookupFlagSet(name, c); fs != nil {
 		if f := fs.Lookup(name); f != nil {
-			result = f.Value
+			result = unwrapGeneric(f.Value)
 		}
 	}
 	if result == nil {
@@ -145,6 +268,7 @@ func (c *Context) GetFlags() []Flag {
 	}
 	if c.App != nil {
 		flags = append(flags, c.App.Flags...)
+		flags = append(flags, c.App.PersistentFlags...)
 	}
 	return flags
 }
@@ -171,6 +295,12 @@ func lookupFlagSet(name string, ctx *Context) *flag.FlagSet {
 }
 
 func copyFlag(name string, ff *flag.Flag, set *flag.FlagSet) {
+	// aliases sharing the same value only need to be marked as set, as
+	// setting them again would repeat the value for slices
+	if f := set.Lookup(name); f != nil && f.Value == ff.Value {
+		set.NeedsVisit(name)
+		return
+	}
 	set.Set(name, ff.Value.String())
 }
 
@@ -204,7 +334,7 @@ func normalizeFlags(flags []Flag, set *flag.FlagSet) error {
 	return nil
 }
 
-func makeFlagNameVisitor(names *[]string) func(*flag.Flag) {
+func (c *Context) makeFlagNameVisitor(seen map[string]bool, names *[]string) func(*flag.Flag) {
 	return func(f *flag.Flag) {
 		nameParts := strings.Split(f.Name, ",")
 		name := strings.TrimSpace(nameParts[0])
@@ -216,12 +346,62 @@ func makeFlagNameVisitor(names *[]string) func(*flag.Flag) {
 			}
 		}
 
-		if name != "" {
+		// use the canonical name for aliases of declared flags
+		if fl := lookupFlag(name, c); fl != nil {
+			name = FlagNames(fl)[0]
+		}
+
+		if name != "" && !seen[name] {
+			seen[name] = true
 			*names = append(*names, name)
 		}
 	}
 }
 
+// resolveFromFileFlags sets the value of each flag with a FromFileFlag from
+// the contents of the file named by that flag, returning an error for each
+// flag where both flags were set or the file could not be read
+func resolveFromFileFlags(flags []Flag, context *Context) error {
+	var errs []error
+	for _, f := range flags {
+		if err := resolveFromFileFlag(f, context); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs...)
+}
+
+func resolveFromFileFlag(f Flag, context *Context) error {
+	fileFlag, ok := getFlagFromFileFlag(f)
+	if !ok || fileFlag == "" || !context.IsSet(fileFlag) {
+		return nil
+	}
+	names := FlagNames(f)
+	for _, name := range names {
+		if context.IsSet(name) {
+			return errors.New(context.App.translate(MsgFlagConflictsFile,
+				prefixFor(names[0])+names[0], prefixFor(fileFlag)+fileFlag))
+		}
+	}
+	path := context.String(fileFlag)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.New(context.App.translate(MsgFlagFileUnreadable, prefixFor(names[0])+names[0], err))
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if transform, _ := getFlagEnvTransform(f); transform != nil {
+		if value, err = transform(value); err != nil {
+			return fmt.Errorf("could not transform value for flag %s: %s", names[0], err)
+		}
+	}
+	if err := context.Set(names[0], value); err != nil {
+		return err
+	}
+	context.flagSet.NeedsVisit(names[1:]...)
+	setFlagSource(context.flagSet, names, "file", path)
+	return nil
+}
+
 type requiredFlagsErr interface {
 	error
 	getMissingFlags() []string
@@ -229,15 +409,16 @@ type requiredFlagsErr interface {
 
 type errRequiredFlags struct {
 	missingFlags []string
+	app          *App
 }
 
 func (e *errRequiredFlags) Error() string {
 	numberOfMissingFlags := len(e.missingFlags)
 	if numberOfMissingFlags == 1 {
-		return fmt.Sprintf("Required flag %q not set", e.missingFlags[0])
+		return e.app.translate(MsgRequiredFlag, e.missingFlags[0])
 	}
 	joinedMissingFlags := strings.Join(e.missingFlags, ", ")
-	return fmt.Sprintf("Required flags %q not set", joinedMissingFlags)
+	return e.app.translate(MsgRequiredFlags, joinedMissingFlags)
 }
 
 func (e *errRequiredFlags) getMissingFlags() []string {
@@ -247,29 +428,119 @@ func (e *errRequiredFlags) getMissingFlags() []string {
 func checkRequiredFlags(flags []Flag, context *Context) requiredFlagsErr {
 	var missingFlags []string
 	for _, f := range flags {
-		if required, ok := getFlagRequired(f); ok && required {
-			var flagPresent bool
-			var flagName string
-
-			for _, key := range FlagNames(f) {
-				if len(key) > 1 {
-					flagName = key
-				}
-
-				if context.IsSet(strings.TrimSpace(key)) {
-					flagPresent = true
-				}
-			}
-
-			if !flagPresent && flagName != "" {
-				missingFlags = append(missingFlags, flagName)
-			}
+		if flagName, missing := missingRequiredFlag(f, context); missing {
+			missingFlags = append(missingFlags, flagName)
 		}
 	}
 
 	if len(missingFlags) != 0 {
-		return &errRequiredFlags{missingFlags: missingFlags}
+		return &errRequiredFlags{missingFlags: missingFlags, app: context.App}
 	}
 
 	return nil
 }
+
+// checkFlagItems returns an error for each slice flag with fewer values
+// than its MinItems or more than its MaxItems, or with a repeated value if
+// Unique
+func checkFlagItems(flags []Flag, context *Context) error {
+	var errs []error
+	for _, f := range flags {
+		min, _ := getFlagMinItems(f)
+		max, _ := getFlagMaxItems(f)
+		unique, _ := getFlagUnique(f)
+		if min <= 0 && max <= 0 && !unique {
+			continue
+		}
+		names := FlagNames(f)
+		fs := lookupFlagSet(names[0], context)
+		if fs == nil {
+			continue
+		}
+		getter, ok := fs.Lookup(names[0]).Value.(flag.Getter)
+		if !ok {
+			continue
+		}
+		// the number of values of a map is its number of keys
+		v := reflect.ValueOf(getter.Get())
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Map {
+			continue
+		}
+		name := prefixFor(names[0]) + names[0]
+		if n := v.Len(); max > 0 && n > max {
+			errs = append(errs, errors.New(context.App.translate(MsgFlagMaxItems, name, max, n)))
+		} else if n < min {
+			errs = append(errs, errors.New(context.App.translate(MsgFlagMinItems, name, min, n)))
+		}
+		if !unique || v.Kind() != reflect.Slice {
+			continue
+		}
+		if dup, ok := duplicateElem(v); ok {
+			errs = append(errs, errors.New(context.App.translate(MsgFlagDuplicate, name, dup)))
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// duplicateElem returns the first element of the slice v which is equal to
+// an earlier element, or false if there is none
+func duplicateElem(v reflect.Value) (interface{}, bool) {
+	for i := 1; i < v.Len(); i++ {
+		for j := 0; j < i; j++ {
+			if generic.Equal(v.Index(i).Interface(), v.Index(j).Interface()) {
+				return v.Index(i).Interface(), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// missingRequiredFlag returns the long name of f and true if f is required
+// but has not been set
+func missingRequiredFlag(f Flag, context *Context) (string, bool) {
+	if required, ok := getFlagRequired(f); !ok || !required {
+		return "", false
+	}
+	var flagPresent bool
+	var flagName string
+
+	for _, key := range FlagNames(f) {
+		if len(key) > 1 {
+			flagName = key
+		}
+
+		if context.IsSet(strings.TrimSpace(key)) {
+			flagPresent = true
+		}
+	}
+
+	// a flag read from a file is not missing, even if the file
+	// could not be read, as that is reported separately
+	if fileFlag, ok := getFlagFromFileFlag(f); ok && fileFlag != "" && context.IsSet(fileFlag) {
+		flagPresent = true
+	}
+
+	return flagName, !flagPresent && flagName != ""
+}
+
+// checkFlagRequires returns an error for each flag which is set without the
+// flags it Requires also being set
+func checkFlagRequires(flags []Flag, context *Context) error {
+	var errs []error
+	for _, f := range flags {
+		requires, ok := getFlagRequires(f)
+		if !ok || len(requires) == 0 {
+			continue
+		}
+		names := FlagNames(f)
+		if !context.IsSet(names[0]) {
+			continue
+		}
+		for _, name := range requires {
+			if !context.IsSet(name) {
+				errs = append(errs, errors.New(context.App.translate(MsgFlagRequires, prefixFor(names[0])+names[0], prefixFor(name)+name)))
+			}
+		}
+	}
+	return joinErrors(errs...)
+}
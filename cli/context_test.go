@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"io/ioutil"
 	"os"
 	"sort"
 	"strings"
@@ -36,6 +38,59 @@ func TestNewContext(t *testing.T) {
 	expect(t, c.Command.Name, "mycommand")
 }
 
+func TestContext_AppAndCommand(t *testing.T) {
+	var names []string
+	record := func(stage string) func(*Context) error {
+		return func(ctx *Context) error {
+			name := "<nil>"
+			if ctx.Command != nil {
+				name = ctx.Command.Name
+			}
+			names = append(names, stage+" "+ctx.App.Name+": "+name)
+			return nil
+		}
+	}
+	app := &App{
+		Name:   "app",
+		Writer: ioutil.Discard,
+		Before: record("before"),
+		Action: record("action"),
+		Commands: []*Command{
+			{Name: "leaf", Before: record("before"), Action: record("action")},
+			{
+				Name:        "parent",
+				Before:      record("before"),
+				Action:      record("action"),
+				Subcommands: []*Command{{Name: "child", Action: record("action")}},
+			},
+			{Name: "nested", Subcommands: []*Command{{Name: "child"}}},
+		},
+	}
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"app"}, []string{"before app: <nil>", "action app: <nil>"}},
+		{[]string{"app", "leaf"}, []string{"before app: <nil>", "before app: leaf", "action app: leaf"}},
+		{[]string{"app", "parent"}, []string{"before app: <nil>", "before app parent: parent", "action app parent: parent"}},
+		{[]string{"app", "parent", "child"}, []string{"before app: <nil>", "before app parent: parent", "action app parent: child"}},
+	}
+	for _, test := range tests {
+		names = nil
+		expect(t, app.Run(test.args), nil)
+		expect(t, names, test.expected)
+	}
+
+	// help for a command with subcommands still lists the subcommands
+	var out bytes.Buffer
+	app.Writer = &out
+	expect(t, app.Run([]string{"app", "nested"}), nil)
+	if !strings.Contains(out.String(), "app nested - ") || !strings.Contains(out.String(), "child") {
+		t.Errorf("expected subcommand help, got %q", out.String())
+	}
+}
+
 func TestContext_Int(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 	set.Int("myflag", 12, "doc")
@@ -279,6 +334,37 @@ func TestContext_FlagNames(t *testing.T) {
 	expect(t, actualFlags, []string{"one-flag", "top-flag", "two-flag"})
 }
 
+func TestContext_FlagNamesWithAliases(t *testing.T) {
+	var local, all []string
+	var num int
+	app := &App{
+		Flags: []Flag{
+			&IntFlag{Name: "number", Aliases: []string{"n"}},
+			&BoolFlag{Name: "verbose", Aliases: []string{"V"}},
+		},
+		Commands: []*Command{
+			{
+				Name: "sub",
+				Flags: []Flag{
+					&StringFlag{Name: "output", Aliases: []string{"o", "out"}},
+					&StringFlag{Name: "format"},
+				},
+				Action: func(c *Context) error {
+					local = c.LocalFlagNames()
+					all = c.FlagNames()
+					num = c.NumFlags()
+					return nil
+				},
+			},
+		},
+	}
+
+	expect(t, app.Run([]string{"app", "-n", "1", "sub", "-o", "x"}), nil)
+	expect(t, local, []string{"output"})
+	expect(t, all, []string{"output", "number"})
+	expect(t, num, 1)
+}
+
 func TestContext_Lineage(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 	set.Bool("local-flag", false, "doc")
@@ -421,6 +507,86 @@ func TestContextAttributeAccessing(t *testing.T) {
 	}
 }
 
+type staticResolver string
+
+func (r staticResolver) Resolve() (string, bool) {
+	return string(r), true
+}
+
+func TestContext_Source(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	dir, err := ioutil.TempDir("", "cli_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configPath := dir + "/config"
+	tokenPath := dir + "/token"
+	keyPath := dir + "/key"
+	for path, data := range map[string]string{configPath: "config", tokenPath: "token", keyPath: "key"} {
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Setenv("APP_NAME", "env")
+	os.Setenv("APP_KEY_FILE", keyPath)
+
+	sources := map[string]string{}
+	paths := map[string]string{}
+	app := &App{
+		Writer: ioutil.Discard,
+		Flags: []Flag{
+			&StringFlag{Name: "name", Aliases: []string{"n"}, EnvVars: []string{"APP_NAME"}},
+			&StringFlag{Name: "config", EnvVars: []string{"APP_CONFIG"}, FilePath: configPath},
+			&StringFlag{Name: "token", FromFileFlag: "token-file"},
+			&StringFlag{Name: "token-file"},
+			&StringFlag{Name: "key", Resolvers: []Resolver{EnvNamedFileResolver{"APP_KEY_FILE"}}},
+			&StringFlag{Name: "region", Resolvers: []Resolver{staticResolver("us-east")}},
+			&StringFlag{Name: "unset"},
+		},
+		Action: func(ctx *Context) error {
+			for _, name := range []string{"name", "n", "config", "token", "token-file", "key", "region", "unset", "undefined"} {
+				sources[name] = ctx.Source(name)
+				paths[name] = ctx.FilePath(name)
+			}
+			return nil
+		},
+	}
+
+	expect(t, app.Run([]string{"run", "--token-file", tokenPath}), nil)
+	expect(t, sources, map[string]string{
+		"name":       "env",
+		"n":          "env",
+		"config":     "file",
+		"token":      "file",
+		"token-file": "flag",
+		"key":        "file",
+		"region":     "resolver",
+		"unset":      "default",
+		"undefined":  "",
+	})
+	expect(t, paths, map[string]string{
+		"name":       "",
+		"n":          "",
+		"config":     configPath,
+		"token":      tokenPath,
+		"token-file": "",
+		"key":        keyPath,
+		"region":     "",
+		"unset":      "",
+		"undefined":  "",
+	})
+
+	os.Setenv("APP_CONFIG", "env")
+	expect(t, app.Run([]string{"run", "-n", "cli", "--config=cli"}), nil)
+	expect(t, sources["name"], "flag")
+	expect(t, sources["n"], "flag")
+	expect(t, sources["config"], "flag")
+	expect(t, paths["config"], "")
+}
+
 func TestCheckRequiredFlags(t *testing.T) {
 	tdata := []struct {
 		testCase              string
@@ -562,7 +728,7 @@ func TestCheckRequiredFlags(t *testing.T) {
 
 			c := &Context{}
 			ctx := NewContext(c.App, set, c)
-			ctx.Command.Flags = test.flags
+			ctx.Command = &Command{Flags: test.flags}
 
 			// logic under test
 			err := checkRequiredFlags(test.flags, ctx)
@@ -584,3 +750,154 @@ func TestCheckRequiredFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckFlagRequires(t *testing.T) {
+	tdata := []struct {
+		testCase    string
+		parseInput  []string
+		expectedErr string
+	}{
+		{
+			testCase: "none_set",
+		},
+		{
+			testCase:   "all_set",
+			parseInput: []string{"--tls-cert", "cert.pem", "--tls-key", "key.pem", "--tls-ca", "ca.pem"},
+		},
+		{
+			testCase:   "required_only",
+			parseInput: []string{"--tls-key", "key.pem"},
+		},
+		{
+			testCase:    "missing_one",
+			parseInput:  []string{"--tls-cert", "cert.pem", "--tls-ca", "ca.pem"},
+			expectedErr: "--tls-cert requires --tls-key",
+		},
+		{
+			testCase:    "set_by_alias",
+			parseInput:  []string{"-c", "cert.pem", "--tls-key", "key.pem"},
+			expectedErr: "--tls-cert requires --tls-ca",
+		},
+		{
+			testCase:    "missing_all",
+			parseInput:  []string{"--tls-cert", "cert.pem"},
+			expectedErr: "2 errors occurred:\n  * --tls-cert requires --tls-key\n  * --tls-cert requires --tls-ca",
+		},
+	}
+	for _, test := range tdata {
+		t.Run(test.testCase, func(t *testing.T) {
+			flags := []Flag{
+				&StringFlag{Name: "tls-cert", Aliases: []string{"c"}, Requires: []string{"tls-key", "tls-ca"}},
+				&StringFlag{Name: "tls-key"},
+				&StringFlag{Name: "tls-ca"},
+			}
+			set := flag.NewFlagSet("test", 0)
+			for _, f := range flags {
+				f.Apply(set)
+			}
+			set.Parse(test.parseInput)
+			expect(t, normalizeFlags(flags, set), nil)
+			c := &Context{}
+			ctx := NewContext(c.App, set, c)
+
+			err := checkFlagRequires(flags, ctx)
+			if test.expectedErr == "" {
+				expect(t, err, nil)
+			} else if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("expected error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestContext_History(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	dir, err := ioutil.TempDir("", "cli_history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configPath := dir + "/config"
+	if err := ioutil.WriteFile(configPath, []byte("file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("APP_PORT", "8080")
+
+	history := map[string][]FlagAssignment{}
+	app := &App{
+		Writer:           ioutil.Discard,
+		TrackFlagHistory: true,
+		Flags: []Flag{
+			&IntFlag{Name: "port", Aliases: []string{"p"}, EnvVars: []string{"APP_PORT"}, Value: 80},
+			&StringFlag{Name: "config", FilePath: configPath},
+			&StringFlag{Name: "name", Value: "default"},
+		},
+		Action: func(ctx *Context) error {
+			for _, name := range []string{"port", "p", "config", "name", "undefined"} {
+				history[name] = ctx.History(name)
+			}
+			return nil
+		},
+	}
+
+	expect(t, app.Run([]string{"run", "--port", "9090", "-p", "9091"}), nil)
+	port := []FlagAssignment{
+		{Value: 80, Source: "default"},
+		{Value: 8080, Source: "env"},
+		{Value: 9090, Source: "flag"},
+		{Value: 9091, Source: "flag"},
+	}
+	expect(t, history["port"], port)
+	expect(t, history["p"], port)
+	expect(t, history["config"], []FlagAssignment{
+		{Value: "", Source: "default"},
+		{Value: "file", Source: "file", Path: configPath},
+	})
+	expect(t, history["name"], []FlagAssignment{{Value: "default", Source: "default"}})
+	expect(t, history["undefined"], []FlagAssignment(nil))
+
+	app.TrackFlagHistory = false
+	expect(t, app.Run([]string{"run", "--port", "9090"}), nil)
+	expect(t, history["port"], []FlagAssignment(nil))
+}
+
+func TestContext_GlobalIsSet(t *testing.T) {
+	tests := []struct {
+		args        []string
+		globalIsSet bool
+		isSet       bool
+	}{
+		{[]string{"app", "--verbose", "cmd", "sub"}, true, false},
+		{[]string{"app", "cmd", "sub", "--verbose"}, false, true},
+		{[]string{"app", "--verbose", "cmd", "sub", "--verbose"}, true, true},
+		{[]string{"app", "cmd", "sub"}, false, false},
+	}
+	for _, test := range tests {
+		var globalIsSet, isSet, debugIsSet bool
+		app := &App{
+			Flags: []Flag{
+				&BoolFlag{Name: "verbose"},
+				&BoolFlag{Name: "debug"},
+			},
+			Commands: []*Command{{
+				Name: "cmd",
+				Subcommands: []*Command{{
+					Name:  "sub",
+					Flags: []Flag{&BoolFlag{Name: "verbose"}},
+					Action: func(ctx *Context) error {
+						globalIsSet = ctx.GlobalIsSet("verbose")
+						isSet = ctx.IsSet("verbose")
+						debugIsSet = ctx.GlobalIsSet("debug")
+						return nil
+					},
+				}},
+			}},
+		}
+		expect(t, app.Run(test.args), nil)
+		expect(t, globalIsSet, test.globalIsSet)
+		expect(t, isSet, test.isSet)
+		expect(t, debugIsSet, false)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"reflect"
 	"sort"
 	"strings"
 	"text/template"
@@ -52,8 +53,8 @@ func (a *App) writeDocTemplate(w io.Writer) error {
 	return t.ExecuteTemplate(w, name, &cliTemplate{
 		App:          a,
 		Commands:     prepareCommands(a.Commands, 0),
-		GlobalArgs:   prepareArgsWithValues(a.VisibleFlags()),
-		SynopsisArgs: prepareArgsSynopsis(a.VisibleFlags()),
+		GlobalArgs:   prepareArgsWithValues(append(a.VisibleFlags(), a.VisiblePersistentFlags()...)),
+		SynopsisArgs: prepareArgsSynopsis(append(a.VisibleFlags(), a.VisiblePersistentFlags()...)),
 	})
 }
 
@@ -153,8 +154,16 @@ func flagDetails(f Flag) string {
 		}
 	}
 
+	if hasValue, _ := getFlagHasValue(f); !hasValue && isZeroDefault(value, reflect.ValueOf(value).Kind()) {
+		valStr = ""
+	}
+
+	if secret, _ := getFlagSecret(f); secret && valStr != "" {
+		valStr = secretMask
+	}
+
 	if valStr != "" {
-		description += " (default: " + valStr + ")"
+		description += formatDefault(valStr)
 	}
 	return ": " + description
 }
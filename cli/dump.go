@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// DumpFlags writes the name, value and source of each flag of the most
+// recently parsed command and its parents to w, such as for a hidden
+// --debug-flags option, with the values of Secret flags redacted. Nothing
+// is written if the App has not parsed any flags.
+func (a *App) DumpFlags(w io.Writer) {
+	ctx := a.parsedContext
+	if ctx == nil {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 1, 8, helpPadding, ' ', 0)
+	seen := map[string]bool{}
+	for _, f := range ctx.GetFlags() {
+		if f == HelpFlag || f == VersionFlag || f == BashCompletionFlag || f == a.versionFlag {
+			continue
+		}
+		name := FlagNames(f)[0]
+		fs := lookupFlagSet(name, ctx)
+		if seen[name] || fs == nil {
+			continue
+		}
+		seen[name] = true
+		value := fmt.Sprintf("%q", fs.Lookup(name).Value.String())
+		if secret, _ := getFlagSecret(f); secret {
+			value = secretMask
+		}
+		source := ctx.Source(name)
+		if path := ctx.FilePath(name); path != "" {
+			source += " " + path
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, value, source)
+	}
+	tw.Flush()
+}
+
+// setParsedContext records ctx as the parsed context of its App and the
+// Apps of its parent contexts for DumpFlags
+func setParsedContext(ctx *Context) {
+	for _, c := range ctx.Lineage() {
+		if c.App != nil {
+			c.App.parsedContext = ctx
+		}
+	}
+}
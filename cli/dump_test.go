@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestApp_DumpFlags(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_LEVEL", "debug")
+
+	var out bytes.Buffer
+	app := &App{
+		Writer: ioutil.Discard,
+		Flags: []Flag{
+			&StringFlag{Name: "level", Aliases: []string{"l"}, EnvVars: []string{"APP_LEVEL"}},
+			&StringFlag{Name: "token", Value: "s3cret", Secret: true},
+			&BoolFlag{Name: "debug-flags", Hidden: true},
+		},
+		Commands: []*Command{{
+			Name:  "serve",
+			Flags: []Flag{&IntFlag{Name: "port", Value: 80}},
+			Action: func(ctx *Context) error {
+				if ctx.Bool("debug-flags") {
+					ctx.App.DumpFlags(&out)
+				}
+				return nil
+			},
+		}},
+	}
+
+	app.DumpFlags(&out)
+	expect(t, out.String(), "")
+
+	expect(t, app.Run([]string{"run", "--debug-flags", "serve", "--port", "8080"}), nil)
+	expect(t, out.String(), ""+
+		"port         \"8080\"   flag\n"+
+		"level        \"debug\"  env\n"+
+		"token        ***      default\n"+
+		"debug-flags  \"true\"   flag\n")
+
+	out.Reset()
+	app.DumpFlags(&out)
+	expect(t, out.Len() > 0, true)
+}
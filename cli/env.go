@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// upperSnakeEnvName converts a flag name such as "log-level" to an
+// environment variable name such as "LOG_LEVEL"
+func upperSnakeEnvName(flagName string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(flagName))
+}
+
+// envFlags returns the flags with EnvVars derived from the EnvPrefix and
+// EnvNameFunc of the App for any flags without EnvVars or Resolvers, and
+// with the variables of EnvAliases which name them
+func (a *App) envFlags(flags []Flag) []Flag {
+	if a.EnvPrefix == "" && a.EnvNameFunc == nil && len(a.EnvAliases) == 0 {
+		return flags
+	}
+	envName := a.EnvNameFunc
+	if envName == nil {
+		envName = upperSnakeEnvName
+	}
+	result := make([]Flag, 0, len(flags))
+	for _, f := range flags {
+		if f != HelpFlag && f != VersionFlag && f != a.versionFlag && f != BashCompletionFlag {
+			if a.EnvPrefix != "" || a.EnvNameFunc != nil {
+				f = withEnvVars(f, []string{a.EnvPrefix + envName(FlagNames(f)[0])})
+			}
+			f = withEnvAliases(f, a.envAliasesFor(f))
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// envAliasesFor returns the sorted names of the EnvAliases which name
+// any of the names of the flag
+func (a *App) envAliasesFor(f Flag) []string {
+	var envVars []string
+	names := map[string]bool{}
+	for _, name := range FlagNames(f) {
+		names[name] = true
+	}
+	for envVar, flagNames := range a.EnvAliases {
+		for _, name := range flagNames {
+			if names[name] {
+				envVars = append(envVars, envVar)
+				break
+			}
+		}
+	}
+	sort.Strings(envVars)
+	return envVars
+}
+
+// withEnvVars returns a copy of the flag with EnvVars set, or the flag if
+// it already has EnvVars or Resolvers, or cannot be copied
+func withEnvVars(f Flag, envVars []string) Flag {
+	if current, ok := getFlagEnvVars(f); !ok || len(current) > 0 {
+		return f
+	}
+	if resolvers, _ := getFlagResolvers(f); resolvers != nil {
+		return f
+	}
+	copied, ok := copyFlagStruct(f)
+	if !ok {
+		return f
+	}
+	copied.FieldByName("EnvVars").Set(reflect.ValueOf(envVars))
+	return copied.Addr().Interface().(Flag)
+}
+
+// withEnvAliases returns a copy of the flag with envVars appended to its
+// EnvVars, or with an EnvResolver appended to its Resolvers if set, or the
+// flag if there are no envVars or it cannot be copied
+func withEnvAliases(f Flag, envVars []string) Flag {
+	if len(envVars) == 0 {
+		return f
+	}
+	current, ok := getFlagEnvVars(f)
+	if !ok {
+		return f
+	}
+	copied, ok := copyFlagStruct(f)
+	if !ok {
+		return f
+	}
+	if resolvers, _ := getFlagResolvers(f); resolvers != nil {
+		resolvers = append(resolvers[:len(resolvers):len(resolvers)], EnvResolver(envVars))
+		copied.FieldByName("Resolvers").Set(reflect.ValueOf(resolvers))
+	} else {
+		current = append(current[:len(current):len(current)], envVars...)
+		copied.FieldByName("EnvVars").Set(reflect.ValueOf(current))
+	}
+	return copied.Addr().Interface().(Flag)
+}
+
+// copyFlagStruct returns an addressable copy of the struct pointed to by
+// the flag, or false if the flag is not a pointer to a struct
+func copyFlagStruct(f Flag) (reflect.Value, bool) {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Ptr || fv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	copied := reflect.New(fv.Elem().Type()).Elem()
+	copied.Set(fv.Elem())
+	return copied, true
+}
+
+// EnvWithPrefix returns the environment variables with names starting with
+// prefix, keyed by their full names, such as to forward a curated
+// environment to a child process
+func (c *Context) EnvWithPrefix(prefix string) map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			continue
+		}
+		if name := kv[:i]; strings.HasPrefix(name, prefix) {
+			env[name] = kv[i+1:]
+		}
+	}
+	return env
+}
+
+// loadDotEnvFiles sets the environment variables of the .env files at paths
+// which are not already set, with later files overriding earlier ones
+func loadDotEnvFiles(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	vars := map[string]string{}
+	var names []string
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read env file: %s", err)
+		}
+		err = parseDotEnv(f, path, func(name, value string) {
+			if _, ok := vars[name]; !ok {
+				names = append(names, name)
+			}
+			vars[name] = value
+		})
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if _, ok := os.LookupEnv(name); !ok {
+			if err := os.Setenv(name, vars[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseDotEnv calls set for each variable of a .env file, which has lines
+// such as KEY=value or export KEY="value", ignoring blank lines and lines
+// starting with #. Double quoted values may contain escapes such as \n,
+// single quoted values are literal, and unquoted values end at " #".
+func parseDotEnv(r io.Reader, path string, set func(name, value string)) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return fmt.Errorf("%s:%d: expected NAME=value", path, line)
+		}
+		name := strings.TrimSpace(text[:i])
+		if !validEnvName(name) {
+			return fmt.Errorf("%s:%d: invalid variable name %q", path, line, name)
+		}
+		value, err := dotEnvValue(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s", path, line, err)
+		}
+		set(name, value)
+	}
+	return scanner.Err()
+}
+
+// dotEnvValue returns the unquoted value of a .env variable
+func dotEnvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch s[0] {
+	case '"':
+		end := strings.LastIndex(s, `"`)
+		if end == 0 || !dotEnvComment(s[end+1:]) {
+			return "", fmt.Errorf("unterminated quoted value %s", s)
+		}
+		return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(s[1:end]), nil
+	case '\'':
+		end := strings.LastIndex(s, "'")
+		if end == 0 || !dotEnvComment(s[end+1:]) {
+			return "", fmt.Errorf("unterminated quoted value %s", s)
+		}
+		return s[1:end], nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// dotEnvComment returns true if s is empty or only a comment
+func dotEnvComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+// validEnvName returns true if name is a valid environment variable name
+func validEnvName(name string) bool {
+	for i, r := range name {
+		if r != '_' && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// expandEnvDefaults replaces $VAR and ${VAR} references in s with the values
+// of environment variables as os.ExpandEnv does, also supporting the shell
+// forms ${VAR:-default} which expands to default if VAR is unset or empty,
+// and ${VAR:?message} which is an error if VAR is unset or empty. Without
+// the colon, as in ${VAR-default} and ${VAR?message}, only an unset VAR is
+// replaced or is an error.
+func expandEnvDefaults(s string) (string, error) {
+	var err error
+	result := os.Expand(s, func(ref string) string {
+		i := strings.IndexAny(ref, "-?")
+		if i < 0 {
+			return os.Getenv(ref)
+		}
+		name, op, word := ref[:i], ref[i:i+1], ref[i+1:]
+		checkEmpty := strings.HasSuffix(name, ":")
+		name = strings.TrimSuffix(name, ":")
+		value, ok := os.LookupEnv(name)
+		if ok && !(checkEmpty && value == "") {
+			return value
+		}
+		if op == "-" {
+			return word
+		}
+		if err == nil {
+			if word == "" {
+				word = "parameter not set"
+				if ok {
+					word = "parameter null"
+				}
+			}
+			err = fmt.Errorf("%s: %s", name, word)
+		}
+		return ""
+	})
+	return result, err
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppEnvNames(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_LOG_LEVEL", "debug")
+	os.Setenv("APP_PORT", "8080")
+	os.Setenv("APP_NAME", "ignored")
+	os.Setenv("NAME", "explicit")
+	os.Setenv("APP_HELP", "true")
+
+	var level, name string
+	var port int
+	app := &App{
+		EnvPrefix: "APP_",
+		Flags: []Flag{
+			&StringFlag{Name: "log-level"},
+			&StringFlag{Name: "name", EnvVars: []string{"NAME"}},
+		},
+		Commands: []*Command{
+			{
+				Name:  "serve",
+				Flags: []Flag{&IntFlag{Name: "port"}},
+				Action: func(c *Context) error {
+					level = c.String("log-level")
+					name = c.String("name")
+					port = c.Int("port")
+					return nil
+				},
+			},
+		},
+	}
+
+	expect(t, app.Run([]string{"app", "serve"}), nil)
+	expect(t, level, "debug")
+	expect(t, name, "explicit")
+	expect(t, port, 8080)
+
+	expect(t, app.Run([]string{"app", "--log-level", "info", "serve", "--port", "80"}), nil)
+	expect(t, level, "info")
+	expect(t, port, 80)
+
+	os.Setenv("app-log-level", "warn")
+	app.EnvPrefix = "app-"
+	app.EnvNameFunc = strings.ToLower
+	expect(t, app.Run([]string{"app", "serve"}), nil)
+	expect(t, level, "warn")
+	expect(t, port, 0)
+}
+
+func TestAppEnvAliases(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("LEGACY_ADDR", "10.0.0.1")
+	os.Setenv("LEGACY_DEBUG", "true")
+
+	var listen, advertise, region string
+	var debug, verbose bool
+	app := &App{
+		EnvAliases: map[string][]string{
+			"LEGACY_ADDR":   {"listen", "a"},
+			"LEGACY_DEBUG":  {"debug", "verbose"},
+			"LEGACY_REGION": {"region"},
+		},
+		Flags: []Flag{
+			&StringFlag{Name: "listen", EnvVars: []string{"LISTEN"}},
+			&StringFlag{Name: "advertise", Aliases: []string{"a"}},
+			&BoolFlag{Name: "debug"},
+		},
+		Commands: []*Command{
+			{
+				Name: "run",
+				Flags: []Flag{
+					&BoolFlag{Name: "verbose"},
+					&StringFlag{Name: "region", Resolvers: []Resolver{EnvResolver{"REGION"}}},
+				},
+				Action: func(c *Context) error {
+					listen = c.String("listen")
+					advertise = c.String("advertise")
+					debug = c.Bool("debug")
+					verbose = c.Bool("verbose")
+					region = c.String("region")
+					return nil
+				},
+			},
+		},
+	}
+
+	expect(t, app.Run([]string{"app", "run"}), nil)
+	expect(t, listen, "10.0.0.1")
+	expect(t, advertise, "10.0.0.1")
+	expect(t, debug, true)
+	expect(t, verbose, true)
+	expect(t, region, "")
+
+	// the EnvVars and Resolvers of a flag take precedence over aliases
+	os.Setenv("LISTEN", "0.0.0.0")
+	os.Setenv("LEGACY_REGION", "legacy")
+	expect(t, app.Run([]string{"app", "run"}), nil)
+	expect(t, listen, "0.0.0.0")
+	expect(t, region, "legacy")
+	os.Setenv("REGION", "us-east")
+	expect(t, app.Run([]string{"app", "--advertise", "10.0.0.2", "run"}), nil)
+	expect(t, advertise, "10.0.0.2")
+	expect(t, region, "us-east")
+
+	// each flag parses the value as its own type
+	os.Setenv("LEGACY_DEBUG", "yes")
+	app.EnvAliases["LEGACY_DEBUG"] = []string{"debug", "listen"}
+	expect(t, app.Run([]string{"app", "run"}), nil)
+	expect(t, debug, true)
+	expect(t, listen, "0.0.0.0")
+	app.EnvAliases["LEGACY_ADDR"] = []string{"debug"}
+	os.Unsetenv("LEGACY_DEBUG")
+	err := app.Run([]string{"app", "run"})
+	expect(t, err != nil && strings.HasPrefix(err.Error(), `could not parse "10.0.0.1" as bool value for flag debug`), true)
+}
+
+func TestUpperSnakeEnvName(t *testing.T) {
+	expect(t, upperSnakeEnvName("log-level"), "LOG_LEVEL")
+	expect(t, upperSnakeEnvName("db.host"), "DB_HOST")
+	expect(t, upperSnakeEnvName("port"), "PORT")
+}
+
+func TestContextEnvWithPrefix(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_TOKEN", "s3cret")
+	os.Setenv("APP_EMPTY", "")
+	os.Setenv("APP_URL", "http://host/?a=b")
+	os.Setenv("APPLE", "fruit")
+	os.Setenv("OTHER_APP_X", "x")
+
+	var env, all map[string]string
+	app := &App{
+		Action: func(c *Context) error {
+			env = c.EnvWithPrefix("APP_")
+			all = c.EnvWithPrefix("")
+			return nil
+		},
+	}
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, env, map[string]string{
+		"APP_TOKEN": "s3cret",
+		"APP_EMPTY": "",
+		"APP_URL":   "http://host/?a=b",
+	})
+	expect(t, len(all), 5)
+}
+
+func TestAppDotEnvFiles(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_TOKEN", "real")
+
+	dir, err := ioutil.TempDir("", "spur-dotenv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	env := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	if err := ioutil.WriteFile(env, []byte("# defaults\nAPP_HOST=example.com\nAPP_PORT=80 # http\nexport APP_TOKEN=from-file\nAPP_GREETING=\"hello\\nworld\"\n\nAPP_RAW='a\\nb'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(local, []byte("APP_PORT=8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var host, token string
+	var port int
+	app := &App{
+		DotEnvFiles: []string{env, local, filepath.Join(dir, "missing.env")},
+		Flags: []Flag{
+			&StringFlag{Name: "host", EnvVars: []string{"APP_HOST"}},
+			&IntFlag{Name: "port", EnvVars: []string{"APP_PORT"}},
+			&StringFlag{Name: "token", EnvVars: []string{"APP_TOKEN"}},
+		},
+		Action: func(c *Context) error {
+			host, port, token = c.String("host"), c.Int("port"), c.String("token")
+			return nil
+		},
+	}
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, host, "example.com")
+	expect(t, port, 8080)
+	expect(t, token, "real")
+	expect(t, os.Getenv("APP_GREETING"), "hello\nworld")
+	expect(t, os.Getenv("APP_RAW"), `a\nb`)
+
+	if err := ioutil.WriteFile(local, []byte("APP_PORT=8080\nnot a variable\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = app.Run([]string{"app"})
+	expect(t, err.Error(), local+":2: expected NAME=value")
+
+	if err := ioutil.WriteFile(local, []byte("APP_NAME=\"unterminated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = app.Run([]string{"app"})
+	if err == nil || !strings.HasPrefix(err.Error(), local+":1: unterminated quoted value") {
+		t.Errorf("expected an unterminated value error, got %v", err)
+	}
+}
+
+func TestExpandEnvDefaults(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("HOST", "example.com")
+	os.Setenv("EMPTY", "")
+
+	tests := []struct {
+		s, expect string
+	}{
+		{"$HOST:${PORT:-8080}", "example.com:8080"},
+		{"${HOST:-localhost}", "example.com"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${UNSET-fallback}", "fallback"},
+		{"${UNSET:-a-b}", "a-b"},
+		{"${HOST:?required}", "example.com"},
+		{"${EMPTY?required}", ""},
+	}
+	for _, test := range tests {
+		result, err := expandEnvDefaults(test.s)
+		if err != nil || result != test.expect {
+			t.Errorf("expandEnvDefaults(%q) = %q, %v, expected %q", test.s, result, err, test.expect)
+		}
+	}
+
+	for s, expect := range map[string]string{
+		"${UNSET:?the host is required}": "UNSET: the host is required",
+		"${UNSET?}":                      "UNSET: parameter not set",
+		"${EMPTY:?}":                     "EMPTY: parameter null",
+	} {
+		if _, err := expandEnvDefaults(s); err == nil || err.Error() != expect {
+			t.Errorf("expandEnvDefaults(%q) returned %v, expected %q", s, err, expect)
+		}
+	}
+
+	var addr string
+	app := &App{
+		Flags: []Flag{&StringFlag{Name: "addr", Value: "${HOST}:${PORT:-8080}", ExpandEnv: true}},
+		Action: func(ctx *Context) error {
+			addr = ctx.String("addr")
+			return nil
+		},
+	}
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, addr, "example.com:8080")
+
+	app.Flags = []Flag{&StringFlag{Name: "addr", Value: "${ADDR:?ADDR must be set}", ExpandEnv: true}}
+	err := app.Run([]string{"app"})
+	expect(t, err.Error(), "could not expand value for flag addr: ADDR: ADDR must be set")
+}
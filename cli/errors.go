@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+
+	"github.com/rancher/spur/flag"
 )
 
 // OsExiter is the function used when the app exits. If not set defaults to os.Exit.
@@ -14,7 +17,10 @@ var OsExiter = os.Exit
 // implementing the io.Writer interface and defaults to os.Stderr.
 var ErrWriter io.Writer = os.Stderr
 
-// MultiError is an error that wraps multiple errors.
+// MultiError is an error that wraps multiple errors. Use Errors to inspect
+// the wrapped errors. When built with go1.20 or later, the MultiError
+// returned by App.Run also implements Unwrap() []error, so errors.Is and
+// errors.As also check the wrapped errors.
 type MultiError interface {
 	error
 	Errors() []error
@@ -26,27 +32,101 @@ func newMultiError(err ...error) MultiError {
 	return &ret
 }
 
+// joinErrors returns nil if all errs are nil, the error if only one is not
+// nil, or otherwise a MultiError of the errors which are not nil with any
+// nested MultiError flattened
+func joinErrors(errs ...error) error {
+	var ret multiError
+	for _, err := range errs {
+		if m, ok := err.(*multiError); ok {
+			ret = append(ret, *m...)
+		} else if err != nil {
+			ret = append(ret, err)
+		}
+	}
+	switch len(ret) {
+	case 0:
+		return nil
+	case 1:
+		return ret[0]
+	}
+	return &ret
+}
+
 type multiError []error
 
-// Error implements the error interface.
+// Error implements the error interface, rendering multiple errors as a
+// bulleted list.
 func (m *multiError) Error() string {
+	if len(*m) == 1 {
+		return (*m)[0].Error()
+	}
 	errs := make([]string, len(*m))
 	for i, err := range *m {
-		errs[i] = err.Error()
+		errs[i] = "  * " + strings.Replace(err.Error(), "\n", "\n    ", -1)
 	}
-
-	return strings.Join(errs, "\n")
+	return fmt.Sprintf("%d errors occurred:\n%s", len(*m), strings.Join(errs, "\n"))
 }
 
 // Errors returns a copy of the errors slice
 func (m *multiError) Errors() []error {
 	errs := make([]error, len(*m))
-	for _, err := range *m {
-		errs = append(errs, err)
-	}
+	copy(errs, *m)
 	return errs
 }
 
+// CommandError is returned when the flags of a command could not be parsed,
+// naming the command in which the error occurred. The message of the parse
+// error is prefixed with the command, and Unwrap returns the parse error.
+type CommandError struct {
+	Command string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Command, e.Err)
+}
+
+// Unwrap returns the underlying parse error
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// newCommandError returns a CommandError for the parse error of command,
+// leaving flag.ErrHelp unwrapped so it can still be compared directly
+func newCommandError(command string, err error) error {
+	if err == flag.ErrHelp {
+		return err
+	}
+	return &CommandError{Command: strings.TrimSpace(command), Err: err}
+}
+
+// PanicError is returned by an App with RecoverPanic set when running it
+// panics, such as in a Before, Action or After func
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the goroutine which panicked
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// ExitCode returns 2, the exit code of a program which panics
+func (e *PanicError) ExitCode() int {
+	return 2
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
 // ErrorFormatter is the interface that will suitably format the error output
 type ErrorFormatter interface {
 	Format(s fmt.State, verb rune)
@@ -125,6 +205,15 @@ func HandleExitCoder(err error) {
 	}
 }
 
+// exitsFor returns true if HandleExitCoder calls OsExiter for err
+func exitsFor(err error) bool {
+	switch err.(type) {
+	case ExitCoder, MultiError:
+		return true
+	}
+	return false
+}
+
 func handleMultiError(multiErr MultiError) int {
 	code := 1
 	for _, merr := range multiErr.Errors() {
@@ -139,3 +228,40 @@ func handleMultiError(multiErr MultiError) int {
 	}
 	return code
 }
+
+// The values of App.ErrorFormat
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+// jsonError is the JSON written for an error with ErrorFormatJSON
+type jsonError struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+}
+
+// writeJSONError writes err to w as JSON along with its exit code
+func writeJSONError(w io.Writer, err error) {
+	data, _ := json.Marshal(jsonError{Error: err.Error(), Code: exitCode(err)})
+	fmt.Fprintln(w, string(data))
+}
+
+// exitCode returns the code of err if it is an ExitCoder, or of the last
+// ExitCoder of a MultiError, otherwise 1
+func exitCode(err error) int {
+	if exitErr, ok := err.(ExitCoder); ok {
+		return exitErr.ExitCode()
+	}
+	code := 1
+	if multiErr, ok := err.(MultiError); ok {
+		for _, merr := range multiErr.Errors() {
+			if _, ok := merr.(MultiError); ok {
+				code = exitCode(merr)
+			} else if exitErr, ok := merr.(ExitCoder); ok {
+				code = exitErr.ExitCode()
+			}
+		}
+	}
+	return code
+}
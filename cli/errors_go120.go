@@ -0,0 +1,10 @@
+//go:build go1.20
+// +build go1.20
+
+package cli
+
+// Unwrap returns the errors for use by errors.Is and errors.As, which only
+// check multiple wrapped errors as of go1.20
+func (m *multiError) Unwrap() []error {
+	return m.Errors()
+}
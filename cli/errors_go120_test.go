@@ -0,0 +1,20 @@
+//go:build go1.20
+// +build go1.20
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMultiErrorUnwrap(t *testing.T) {
+	errNotFound := errors.New("not found")
+	err := newMultiError(errNotFound, fmt.Errorf("other"), Exit("exit", 3))
+
+	expect(t, errors.Is(err, errNotFound), true)
+	var exitCoder ExitCoder
+	expect(t, errors.As(err, &exitCoder), true)
+	expect(t, exitCoder.ExitCode(), 3)
+}
@@ -140,3 +140,21 @@ func TestHandleExitCoder_MultiErrorWithFormat(t *testing.T) {
 	expect(t, called, true)
 	expect(t, ErrWriter.(*bytes.Buffer).String(), "This the format: err1\nThis the format: err2\n")
 }
+
+func TestMultiError(t *testing.T) {
+	errNotFound := errors.New("not found")
+	exitErr := Exit("exit", 3)
+	err := newMultiError(errNotFound, fmt.Errorf("line one\nline two"), exitErr)
+
+	expect(t, err.Error(), "3 errors occurred:\n  * not found\n  * line one\n    line two\n  * exit")
+
+	errs := err.Errors()
+	expect(t, len(errs), 3)
+	errs[0] = nil
+	expect(t, err.Errors()[0], errNotFound)
+
+	expect(t, newMultiError(errNotFound).Error(), "not found")
+	expect(t, joinErrors(nil, nil), nil)
+	expect(t, joinErrors(nil, errNotFound), errNotFound)
+	expect(t, joinErrors(errNotFound, nil, exitErr), newMultiError(errNotFound, exitErr))
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 	"text/template"
+	"unicode/utf8"
 
 	"github.com/rancher/spur/flag"
 )
@@ -35,7 +36,7 @@ func (a *App) writeFishCompletionTemplate(w io.Writer) error {
 	allCommands := []string{}
 
 	// Add global flags
-	completions := a.prepareFishFlags(a.VisibleFlags(), allCommands)
+	completions := a.prepareFishFlags(append(a.VisibleFlags(), a.VisiblePersistentFlags()...), allCommands)
 
 	// Add help flag
 	if !a.HideHelp {
@@ -46,10 +47,10 @@ func (a *App) writeFishCompletionTemplate(w io.Writer) error {
 	}
 
 	// Add version flag
-	if !a.HideVersion {
+	if versionFlag := a.newVersionFlag(); !a.HideVersion && versionFlag != nil {
 		completions = append(
 			completions,
-			a.prepareFishFlags([]Flag{VersionFlag}, allCommands)...,
+			a.prepareFishFlags([]Flag{versionFlag}, allCommands)...,
 		)
 	}
 
@@ -97,7 +98,7 @@ func (a *App) prepareFishCommands(commands []*Command, allCommands *[]string, pr
 		completions = append(completions, completion.String())
 		completions = append(
 			completions,
-			a.prepareFishFlags(command.Flags, command.Names())...,
+			a.prepareFishFlags(command.VisibleFlags(), command.Names())...,
 		)
 
 		// recursevly iterate subcommands
@@ -126,16 +127,13 @@ func (a *App) prepareFishFlags(flags []Flag, previousCommands []string) []string
 
 		fishAddFileFlag(f, completion)
 
-		for idx, opt := range FlagNames(f) {
-			if idx == 0 {
-				completion.WriteString(fmt.Sprintf(
-					" -l %s", strings.TrimSpace(opt),
-				))
+		for _, opt := range FlagNames(f) {
+			// fish only accepts single character short options
+			opt = strings.TrimSpace(opt)
+			if utf8.RuneCountInString(opt) == 1 {
+				completion.WriteString(fmt.Sprintf(" -s %s", opt))
 			} else {
-				completion.WriteString(fmt.Sprintf(
-					" -s %s", strings.TrimSpace(opt),
-				))
-
+				completion.WriteString(fmt.Sprintf(" -l %s", opt))
 			}
 		}
 
@@ -143,6 +141,11 @@ func (a *App) prepareFishFlags(flags []Flag, previousCommands []string) []string
 			completion.WriteString(" -r")
 		}
 
+		if choices, ok := getFlagChoices(f); ok && len(choices) > 0 {
+			completion.WriteString(fmt.Sprintf(" -a '%s'",
+				escapeSingleQuotes(strings.Join(choices, " "))))
+		}
+
 		if usage, ok := getFlagUsage(f); ok && usage != "" {
 			completion.WriteString(fmt.Sprintf(" -d '%s'",
 				escapeSingleQuotes(usage)))
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -15,3 +16,49 @@ func TestFishCompletion(t *testing.T) {
 	expect(t, err, nil)
 	expectFileContent(t, "testdata/expected-fish-full.fish", res)
 }
+
+func TestFishCompletionChoices(t *testing.T) {
+	app := &App{
+		Name: "greet",
+		Flags: []Flag{
+			&StringFlag{Name: "color", Usage: "output color", Choices: []string{"red", "green"}},
+			&StringFlag{Name: "config", TakesFile: true},
+		},
+	}
+
+	res, err := app.ToFishCompletion()
+	expect(t, err, nil)
+	for _, line := range []string{
+		"complete -c greet -n '__fish_greet_no_subcommand' -f -l color -r -a 'red green' -d 'output color'",
+		"complete -c greet -n '__fish_greet_no_subcommand' -l config -r",
+	} {
+		if !strings.Contains(res, line+"\n") {
+			t.Errorf("expected completion to contain %q, got %q", line, res)
+		}
+	}
+}
+
+func TestFishCompletionCommandFlags(t *testing.T) {
+	app := &App{
+		Name: "greet",
+		Commands: []*Command{
+			{
+				Name:     "run",
+				HideHelp: true,
+				Flags: []Flag{
+					&BoolFlag{Name: "v", Aliases: []string{"verbose"}},
+					&BoolFlag{Name: "secret-flag", Hidden: true},
+				},
+			},
+		},
+	}
+
+	res, err := app.ToFishCompletion()
+	expect(t, err, nil)
+	if !strings.Contains(res, "complete -c greet -n '__fish_seen_subcommand_from run' -f -s v -l verbose\n") {
+		t.Errorf("expected single character names as short options, got %q", res)
+	}
+	if strings.Contains(res, "secret-flag") {
+		t.Errorf("expected hidden flags to be omitted, got %q", res)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/rancher/spur/flag"
 	"github.com/rancher/spur/generic"
@@ -52,6 +53,16 @@ var FlagNamePrefixer FlagNamePrefixFunc = prefixedNames
 // details. This is used by the default FlagStringer.
 var FlagEnvHinter FlagEnvHintFunc = withEnvHint
 
+// DefaultTextPrefix is the text before the default value of a flag in help
+// output, such as "(par défaut : " for a French CLI. This is used by the
+// default FlagStringer.
+var DefaultTextPrefix = "(default: "
+
+// EnvHintFormat formats the environment variables of a flag, such as
+// "$APP_PORT, $PORT", as the hint added to its help message. This is used by
+// the default FlagEnvHinter.
+var EnvHintFormat = " [%s]"
+
 // FlagFileHinter annotates flag help message with the environment variable
 // details. This is used by the default FlagStringer.
 var FlagFileHinter FlagFileHintFunc = withFileHint
@@ -61,6 +72,9 @@ type FlagsByName []Flag
 
 const defaultPlaceholder = "value"
 
+// secretMask replaces the values of Secret flags in help and messages
+const secretMask = "***"
+
 func (f FlagsByName) Len() int {
 	return len(f)
 }
@@ -80,14 +94,27 @@ func (f FlagsByName) Swap(i, j int) {
 	f[i], f[j] = f[j], f[i]
 }
 
-func flagSet(name string, flags []Flag) (*flag.FlagSet, error) {
+// BuildFlagSet returns a new flag set with each of the flags applied,
+// including values from the environment, files and Resolvers. The flag set
+// is returned unparsed for the caller to Parse and inspect, and errors are
+// returned rather than handled by the flag set.
+func BuildFlagSet(name string, flags []Flag) (*flag.FlagSet, error) {
+	return flagSet(name, flags, false)
+}
+
+func flagSet(name string, flags []Flag, trackHistory bool) (*flag.FlagSet, error) {
 	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	set.TrackHistory = trackHistory
 
+	var errs []error
 	for _, f := range flags {
 		if err := f.Apply(set); err != nil {
-			return nil, err
+			errs = append(errs, err)
 		}
 	}
+	if err := joinErrors(errs...); err != nil {
+		return nil, err
+	}
 	set.SetOutput(ioutil.Discard)
 	return set, nil
 }
@@ -159,7 +186,7 @@ func withEnvHint(envVars []string, str string) string {
 			sep = "%, %"
 		}
 
-		envText = fmt.Sprintf(" [%s%s%s]", prefix, strings.Join(envVars, sep), suffix)
+		envText = fmt.Sprintf(EnvHintFormat, prefix+strings.Join(envVars, sep)+suffix)
 	}
 	return str + envText
 }
@@ -227,8 +254,8 @@ func flagValue(f Flag) reflect.Value {
 	return fv
 }
 
-func formatDefault(format string) string {
-	return " (default: " + format + ")"
+func formatDefault(value string) string {
+	return " " + DefaultTextPrefix + value + ")"
 }
 
 func stringifyFlag(f Flag) string {
@@ -237,7 +264,7 @@ func stringifyFlag(f Flag) string {
 
 	if generic.IsSlice(value) {
 		return withEnvHint(flagStringSliceField(f, "EnvVars"),
-			stringifySlice(usage, FlagNames(f), value))
+			stringifySlice(f, usage, FlagNames(f), value))
 	}
 
 	placeholder, usage := unquoteUsage(usage)
@@ -252,19 +279,33 @@ func stringifyFlag(f Flag) string {
 		needsPlaceholder = valKind != reflect.Bool
 	}
 
-	defaultValueString = fmt.Sprintf(formatDefault("%v"), value)
-	if valKind == reflect.String && value.(string) != "" {
-		defaultValueString = fmt.Sprintf(formatDefault("%q"), value)
+	defaultValueString = formatDefault(fmt.Sprintf("%v", value))
+	if s, ok := formatTime(f, value); ok {
+		defaultValueString = formatDefault(s)
 	}
-
-	if helpText, ok := getFlagDefaultText(f); ok && helpText != "" {
-		defaultValueString = fmt.Sprintf(formatDefault("%s"), helpText)
+	if _, ok := value.(time.Duration); ok || valKind == reflect.String && value.(string) != "" {
+		defaultValueString = formatDefault(generic.StringifyWith(value, stringifyOptions(f)))
+	}
+	// a zero value is only shown as the default if it was deliberately chosen
+	if isZeroDefault(value, valKind) {
+		defaultValueString = ""
+		if hasValue, _ := getFlagHasValue(f); hasValue {
+			defaultValueString = formatDefault(generic.StringifyWith(value, stringifyOptions(f)))
+		}
 	}
 
 	if defaultValueString == formatDefault("") {
 		defaultValueString = ""
 	}
 
+	if secret, _ := getFlagSecret(f); secret && defaultValueString != "" && valKind != reflect.Bool {
+		defaultValueString = formatDefault(secretMask)
+	}
+
+	if helpText, ok := getFlagDefaultText(f); ok && helpText != "" {
+		defaultValueString = formatDefault(helpText)
+	}
+
 	if needsPlaceholder && placeholder == "" {
 		placeholder = defaultPlaceholder
 	}
@@ -275,21 +316,58 @@ func stringifyFlag(f Flag) string {
 		fmt.Sprintf("%s\t%s", prefixedNames(FlagNames(f), placeholder), usageWithDefault))
 }
 
-func stringifySlice(usage string, names []string, value interface{}) string {
+// isZeroDefault returns true if the default value of a flag is the zero
+// value of its type, other than for bools and time.Time which are displayed
+// regardless
+func isZeroDefault(value interface{}, kind reflect.Kind) bool {
+	if value == nil || kind == reflect.Bool {
+		return false
+	}
+	if _, ok := value.(time.Time); ok {
+		return false
+	}
+	return reflect.ValueOf(value).IsZero()
+}
+
+// stringifyOptions returns the options used to format the default values
+// of f in help output
+func stringifyOptions(f Flag) generic.StringifyOptions {
+	humanize, _ := getFlagHumanize(f)
+	durationFormat, _ := getFlagDurationFormat(f)
+	return generic.StringifyOptions{
+		Quote:             true,
+		SliceSeparator:    ", ",
+		HumanizeDurations: humanize,
+		ISO8601Durations:  durationFormat == DurationFormatISO8601,
+	}
+}
+
+func stringifySlice(f Flag, usage string, names []string, value interface{}) string {
+	if helpText, ok := getFlagDefaultText(f); ok && helpText != "" {
+		return stringifySliceFlag(usage, names, []string{helpText})
+	}
+	formatter, _ := getFlagFormatter(f)
 	var defaults []string
 	for i := 0; i < generic.Len(value); i++ {
 		v := generic.Index(value, i)
-		s, ok := v.(string)
-		if ok && s == "" {
+		if s, ok := v.(string); ok && s == "" {
 			continue
 		}
-		if ok {
-			s = fmt.Sprintf("%q", s)
-		} else {
-			s, _ = generic.ToString(v)
+		s, isTime := formatTime(f, v)
+		if formatter != nil {
+			s = formatter(v)
+		} else if !isTime {
+			s = generic.StringifyWith(v, stringifyOptions(f))
+		}
+		// elements without text are omitted so as not to show "(default: )"
+		if s == "" {
+			continue
 		}
 		defaults = append(defaults, s)
 	}
+	if secret, _ := getFlagSecret(f); secret && len(defaults) > 0 {
+		defaults = []string{secretMask}
+	}
 	return stringifySliceFlag(usage, names, defaults)
 }
 
@@ -301,7 +379,7 @@ func stringifySliceFlag(usage string, names, defaultVals []string) string {
 
 	defaultVal := ""
 	if len(defaultVals) > 0 {
-		defaultVal = fmt.Sprintf(formatDefault("%s"), strings.Join(defaultVals, ", "))
+		defaultVal = formatDefault(strings.Join(defaultVals, ", "))
 	}
 
 	usageWithDefault := strings.TrimSpace(fmt.Sprintf("%s%s", usage, defaultVal))
@@ -0,0 +1,62 @@
+package cli
+
+// BytesFlag is a flag with type []byte, for values such as keys or binary
+// payloads which are decoded according to the Encoding of the flag
+type BytesFlag struct {
+	Name        string
+	Aliases     []string
+	EnvVars     []string
+	Usage       string
+	DefaultText string
+	FilePath    string
+	Required    bool
+	Hidden      bool
+	TakesFile   bool
+	SkipAltSrc  bool
+	Secret      bool
+
+	// Value is the default value encoded as it would be given on the
+	// command line
+	Value       string
+	Destination *[]byte
+
+	// FromFileFlag names another flag whose value is the path of a file
+	// to read the value of this flag from
+	FromFileFlag string
+
+	// Resolvers are used in order to find a value for the flag when it is
+	// not set on the command line, defaults to the EnvVars then FilePath
+	Resolvers []Resolver
+
+	// EnvTransform is applied to values from the environment, files or
+	// other resolvers before they are parsed, such as to decode base64
+	EnvTransform func(raw string) (string, error)
+
+	// Requires names other flags which must also be set whenever this
+	// flag is set, such as a --tls-cert flag requiring --tls-key
+	Requires []string
+
+	// AllowSchemes reads a value given as file://path from the file,
+	// without a trailing newline, and a value given as env://NAME from the
+	// environment variable, other values are used as given
+	AllowSchemes bool
+
+	// PositionalAlias is the position of an argument, starting from 1,
+	// which is used as the value of the flag if the flag is not given on
+	// the command line, and is then removed from the arguments
+	PositionalAlias int
+
+	// Derive computes the value of the flag once the other flags are
+	// parsed if it is not set, such as a --data-dir of <config-dir>/data.
+	// Derived flags looked up from the Context are derived first, and a
+	// cycle between them is an error. A nil result keeps the default.
+	Derive func(ctx *Context) (interface{}, error)
+
+	// HasValue marks a zero Value as deliberately chosen so that it is
+	// shown as the default in help output, where it is otherwise omitted
+	HasValue bool
+
+	// Encoding is BytesEncodingRaw by default, or BytesEncodingBase64 or
+	// BytesEncodingHex to decode each value
+	Encoding string
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
 )
 
 var _ = time.Time{}
@@ -23,14 +24,93 @@ type Int64SliceFlag struct {
 	Hidden      bool
 	TakesFile   bool
 	SkipAltSrc  bool
+	Secret      bool
 
 	Value       Int64Slice
 	Destination *Int64Slice
+
+	// FromFileFlag names another flag whose value is the path of a file
+	// to read the value of this flag from
+	FromFileFlag string
+
+	// Resolvers are used in order to find a value for the flag when it is
+	// not set on the command line, defaults to the EnvVars then FilePath
+	Resolvers []Resolver
+
+	// EnvTransform is applied to values from the environment, files or
+	// other resolvers before they are parsed, such as to decode base64
+	EnvTransform func(raw string) (string, error)
+
+	// Requires names other flags which must also be set whenever this
+	// flag is set, such as a --tls-cert flag requiring --tls-key
+	Requires []string
+
+	// AllowSchemes reads a value given as file://path from the file,
+	// without a trailing newline, and a value given as env://NAME from the
+	// environment variable, other values are used as given
+	AllowSchemes bool
+
+	// PositionalAlias is the position of an argument, starting from 1,
+	// which is used as the value of the flag if the flag is not given on
+	// the command line, and is then removed from the arguments
+	PositionalAlias int
+
+	// Derive computes the value of the flag once the other flags are
+	// parsed if it is not set, such as a --data-dir of <config-dir>/data.
+	// Derived flags looked up from the Context are derived first, and a
+	// cycle between them is an error. A nil result keeps the default.
+	Derive func(ctx *Context) (interface{}, error)
+
+	// NArgs is the maximum number of arguments consumed by each
+	// occurrence of the flag, negative values consume until the next flag
+	NArgs int
+
+	// Append adds the values given on the command line or from the
+	// environment, files or other resolvers to the default value rather
+	// than replacing it
+	Append bool
+
+	// ResetToken is a value, such as "-", which clears the values of the
+	// flag given before it on the command line along with the default, so
+	// that --tag - --tag new is only new
+	ResetToken string
+
+	// EmptyEnvClears makes a value from the environment, files or other
+	// resolvers equal to EmptyEnvValue set the flag to an empty slice
+	// rather than the default
+	EmptyEnvClears bool
+	// EmptyEnvValue is the value which clears the flag when EmptyEnvClears
+	// is set, defaults to an empty string
+	EmptyEnvValue string
+
+	// NewlineSeparated splits values from the environment, files or other
+	// resolvers on newlines instead of commas, trimming whitespace around
+	// each line and ignoring blank lines, so that each line is an element
+	// which may contain commas
+	NewlineSeparated bool
+
+	// CSVEnv parses values from the environment, files or other resolvers
+	// as CSV fields, so that quoted elements such as "a, b" may contain
+	// commas, spaces or doubled quotes
+	CSVEnv bool
+
+	// MinItems and MaxItems limit the number of values of the flag after
+	// it is resolved, a MaxItems of zero is unlimited
+	MinItems int
+	MaxItems int
+
+	// Unique rejects a value which is given more than once
+	Unique bool
+
+	// Formatter formats each element of the default value for help
+	// output, such as to show times as dates, in place of the default
+	// formatting of the element type
+	Formatter func(interface{}) string
 }
 
 // Apply populates the flag given the flag set and environment
 func (f *Int64SliceFlag) Apply(set *flag.FlagSet) error {
-	return Apply(f, "int64 slice", set)
+	return Apply(f, generic.TypeName(f.Value), set)
 }
 
 // Int64Slice looks up the value of a local Int64SliceFlag, returns
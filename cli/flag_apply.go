@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"encoding/csv"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"reflect"
 	"strings"
 	"syscall"
 
@@ -12,16 +15,22 @@ import (
 
 // Apply will attempt to apply generic flag values to a flagset
 func Apply(f Flag, typ string, set *flag.FlagSet) error {
-	name := FlagNames(f)[0]
 	value, _ := getFlagValue(f)
-	usage, _ := getFlagUsage(f)
-	envVars, _ := getFlagEnvVars(f)
-	filePath, _ := getFlagFilePath(f)
 	// make sure we have a pointer to value (for non-generic values)
 	if !generic.IsPtr(value) {
 		value, _ = getFlagValuePtr(f)
 	}
 	destination, _ := getFlagDestination(f)
+	return applyValues(f, typ, value, destination, set)
+}
+
+// applyValues applies f to set as Apply does, using value and destination
+// in place of the Value and Destination of f. It is used by flags with a
+// flag.Value of their own, whose other fields are read from f as for any
+// other flag.
+func applyValues(f Flag, typ string, value, destination interface{}, set *flag.FlagSet) error {
+	name := FlagNames(f)[0]
+	usage, _ := getFlagUsage(f)
 	// create new destination if not defined
 	if destination == nil || generic.ValueOfPtr(destination) == nil {
 		destination = generic.New(value)
@@ -30,44 +39,344 @@ func Apply(f Flag, typ string, set *flag.FlagSet) error {
 	if value == nil || generic.ValueOfPtr(value) == nil {
 		value = generic.New(destination)
 	}
+	expandEnv, _ := getFlagExpandEnv(f)
+	allowSchemes, _ := getFlagAllowSchemes(f)
+	secret, _ := getFlagSecret(f)
+	layout, _ := getFlagLayout(f)
+	keywords, _ := getFlagKeywords(f)
+	durationFormat, _ := getFlagDurationFormat(f)
+	if err := checkDurationFormat(durationFormat); err != nil {
+		return fmt.Errorf("%s for flag %s", err, name)
+	}
+	// a flag has either a time Layout or a DurationFormat for its elements
+	format := layout
+	if durationFormat != "" {
+		format = durationFormat
+	}
+	choices, _ := getFlagChoices(f)
+	emptyEnvClears, _ := getFlagEmptyEnvClears(f)
+	emptyEnvValue, _ := getFlagEmptyEnvValue(f)
+	newlineSeparated, _ := getFlagNewlineSeparated(f)
+	appendValues, _ := getFlagAppend(f)
+	csvEnv, _ := getFlagCSVEnv(f)
+	appendValues = appendValues && generic.IsSlice(value)
+	defaultValue := generic.ValueOfPtr(value)
 	wasSet := false
-	// load flags from environment or file
-	if val, ok := flagFromEnvOrFile(envVars, filePath); ok {
-		newValue := generic.New(value)
-		if err := applyValue(newValue, val); err != nil {
+	source, sourcePath := "", ""
+	load := func(val string, csv bool) error {
+		newValue := newFlagValue(value)
+		var err error
+		if keyword, ok := keywords[val]; ok {
+			generic.Set(newValue, keyword())
+		} else {
+			err = applyValue(newValue, val, format, csv)
+		}
+		if err == nil && appendValues {
+			generic.Set(newValue, appendSlices(defaultValue, generic.ValueOfPtr(newValue)))
+		}
+		if err == nil && len(choices) > 0 {
+			err = checkChoices(newValue, choices)
+		}
+		if err != nil {
+			if secret {
+				val, err = secretMask, maskSecret(err, val)
+			}
 			return fmt.Errorf("could not parse %q as %s value for flag %s: %s", val, typ, name, err)
 		}
 		value = newValue
+		return nil
+	}
+	// load flags from environment, files, or other resolvers
+	if val, r, path, ok := resolve(flagResolvers(f)); ok {
+		if allowSchemes {
+			var err error
+			if val, err = resolveValueScheme(val); err != nil {
+				return fmt.Errorf("could not resolve value for flag %s: %s", name, err)
+			}
+		}
+		if transform, _ := getFlagEnvTransform(f); transform != nil {
+			var err error
+			if val, err = transform(val); err != nil {
+				return fmt.Errorf("could not transform value for flag %s: %s", name, err)
+			}
+		}
+		if _, fromEnv := r.(EnvResolver); expandEnv && !fromEnv {
+			var err error
+			if val, err = expandEnvDefaults(val); err != nil {
+				return fmt.Errorf("could not expand value for flag %s: %s", name, err)
+			}
+		}
+		clear := emptyEnvClears && val == emptyEnvValue
+		if newlineSeparated {
+			val = joinLines(val)
+			clear = clear || val == ""
+		}
+		if clear && generic.IsSlice(value) {
+			// an explicitly empty slice rather than the default
+			newValue := newFlagValue(value)
+			generic.Set(newValue, reflect.MakeSlice(generic.TypeOf(newValue), 0, 0).Interface())
+			value = newValue
+		} else if err := load(val, csvEnv && !newlineSeparated); err != nil {
+			return err
+		}
 		wasSet = true
+		source, sourcePath = resolverSource(r), path
+	} else if s, ok := generic.ValueOfPtr(value).(string); ok && expandEnv {
+		expanded, err := expandEnvDefaults(s)
+		if err != nil {
+			return fmt.Errorf("could not expand value for flag %s: %s", name, err)
+		}
+		if err := load(expanded, false); err != nil {
+			return err
+		}
+	} else if resolver, ok := value.(DefaultResolver); ok {
+		if err := resolveDefault(resolver, &value); err != nil {
+			return fmt.Errorf("could not resolve default value for flag %s: %s", name, err)
+		}
 	}
 	// copy value to destination
 	generic.Set(destination, generic.ValueOfPtr(value))
 	dest, ok := destination.(flag.Value)
 	if !ok {
 		dest = flag.NewGenericValue(destination)
+	} else if generic.IsSlice(dest) {
+		dest = &sliceGenericValue{Value: dest}
+	}
+	var appendValue *appendSliceValue
+	if appendValues {
+		appendValue = &appendSliceValue{Value: dest, ptr: destination, def: defaultValue}
+		dest = appendValue
+	}
+	if layout != "" {
+		dest = &timeLayoutValue{Value: dest, layout: layout}
+	}
+	if len(keywords) > 0 {
+		dest = &timeKeywordValue{Value: dest, keywords: keywords}
+	}
+	if durationFormat == DurationFormatISO8601 {
+		dest = &iso8601DurationValue{Value: dest}
+	}
+	if len(choices) > 0 {
+		dest = &choiceValue{Value: dest, choices: choices}
+	}
+	if resetToken, _ := getFlagResetToken(f); resetToken != "" && generic.IsSlice(destination) {
+		dest = &resetSliceValue{Value: dest, ptr: destination, token: resetToken, append: appendValue}
+	}
+	if secret {
+		dest = &secretValue{Value: dest}
 	}
+	if allowSchemes {
+		dest = &schemeValue{Value: dest}
+	}
+	nargs, _ := getFlagNArgs(f)
 	// for all of the names set the flag variable
 	for _, name := range FlagNames(f) {
 		set.Var(dest, name, usage)
+		set.Lookup(name).NArgs = nargs
+	}
+	if set.TrackHistory {
+		recordDefault(set, FlagNames(f), defaultValue, generic.ValueOfPtr(value), wasSet)
 	}
 	// if value is not default mark as needs visit
 	if wasSet {
 		set.NeedsVisit(name)
+		setFlagSource(set, FlagNames(f), source, sourcePath)
+	}
+	return nil
+}
+
+// recordDefault starts the History of the named flags with the default
+// value, followed by the value from a resolver if wasSet
+func recordDefault(set *flag.FlagSet, names []string, def, value interface{}, wasSet bool) {
+	if !wasSet {
+		// the default may have been resolved or expanded
+		def = value
+	}
+	for _, name := range names {
+		if f := set.Lookup(name); f != nil {
+			f.History = append(f.History, flag.Assignment{Value: def, Source: "default"})
+			if wasSet {
+				f.History = append(f.History, flag.Assignment{Value: value})
+			}
+		}
+	}
+}
+
+// appendSliceValue wraps the flag.Value of a slice flag with Append set so
+// that the values given on the command line are added to the default
+type appendSliceValue struct {
+	flag.Value
+	ptr interface{}
+	def interface{}
+	set bool
+}
+
+// Set passes value to the underlying flag.Value, and after the first value
+// has replaced any previous value adds it to the default
+func (v *appendSliceValue) Set(value interface{}) error {
+	if err := v.Value.Set(value); err != nil {
+		return err
+	}
+	if !v.set {
+		v.set = true
+		generic.Set(v.ptr, appendSlices(v.def, generic.ValueOfPtr(v.ptr)))
+	}
+	return nil
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *appendSliceValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+// resetSliceValue wraps the flag.Value of a slice flag with a ResetToken so
+// that setting the token clears the values set before it and the default
+type resetSliceValue struct {
+	flag.Value
+	ptr    interface{}
+	token  string
+	append *appendSliceValue
+}
+
+// Set clears the slice if value is the reset token, otherwise passes value
+// to the underlying flag.Value
+func (v *resetSliceValue) Set(value interface{}) error {
+	if s, ok := value.(string); !ok || s != v.token {
+		return v.Value.Set(value)
+	}
+	generic.Set(v.ptr, reflect.MakeSlice(generic.TypeOf(v.ptr), 0, 0).Interface())
+	if v.append != nil {
+		// the default has been cleared so must not be added
+		v.append.set = true
+	}
+	return nil
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *resetSliceValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+// secretValue wraps the flag.Value of a Secret flag so that its values are
+// masked in parse errors
+type secretValue struct {
+	flag.Value
+}
+
+// Set passes value to the underlying flag.Value, masking value in any error
+func (v *secretValue) Set(value interface{}) error {
+	err := v.Value.Set(value)
+	if s, ok := value.(string); ok {
+		err = maskSecret(err, s)
 	}
+	return err
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *secretValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+// IsSecret returns true so that the flag package masks values in errors
+func (v *secretValue) IsSecret() bool {
+	return true
+}
+
+// maskSecret returns err with any occurrence of the secret value replaced
+func maskSecret(err error, value string) error {
+	if err == nil || value == "" || !strings.Contains(err.Error(), value) {
+		return err
+	}
+	return errors.New(strings.Replace(err.Error(), value, secretMask, -1))
+}
+
+// appendSlices returns a new slice of the elements of a followed by b
+func appendSlices(a, b interface{}) interface{} {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	result := reflect.MakeSlice(av.Type(), 0, av.Len()+bv.Len())
+	return reflect.AppendSlice(reflect.AppendSlice(result, av), bv).Interface()
+}
+
+// valueCreator is implemented by a flag.Value which needs more than a new
+// value of its type to be set, such as the destination of a jsonValue
+type valueCreator interface {
+	newValue() flag.Value
+}
+
+// newFlagValue returns a new pointer to a value of the type of value
+func newFlagValue(value interface{}) interface{} {
+	if creator, ok := value.(valueCreator); ok {
+		return creator.newValue()
+	}
+	return generic.New(value)
+}
+
+// resolveDefault replaces value with a new value set from the result of
+// the DefaultResolver
+func resolveDefault(resolver DefaultResolver, value *interface{}) error {
+	def, err := resolver.Resolve()
+	if err != nil {
+		return err
+	}
+	newValue, ok := generic.New(*value).(flag.Value)
+	if !ok {
+		return fmt.Errorf("%T is not a flag.Value", *value)
+	}
+	if err := newValue.Set(def); err != nil {
+		return err
+	}
+	*value = newValue
 	return nil
 }
 
-func applyValue(ptr interface{}, val string) error {
+// applyValue sets the value pointed to by ptr from val, splitting val into
+// elements with splitEscaped for slices, or as CSV fields if csv is set.
+// The format is the Layout of time flags or DurationFormat of duration flags.
+func applyValue(ptr interface{}, val, format string, csv bool) error {
 	if !generic.IsSlice(ptr) {
 		// if we are a slice just return the applied elem
-		return applyElem(ptr, val)
+		return applyElem(ptr, val, format)
+	}
+	elems := splitEscaped(val, ',')
+	if csv {
+		var err error
+		if elems, err = splitCSV(val); err != nil {
+			return err
+		}
+	}
+	if set, ok := ptr.(*StringSet); ok {
+		// the elements are added as they are, since Set would split them again
+		*set = nil
+		set.add(elems...)
+		return nil
+	}
+	if gen, ok := ptr.(flag.Value); ok {
+		// if we are a generic flag.Value slice then Set each split value
+		generic.Set(ptr, generic.Zero(ptr))
+		for _, val := range elems {
+			if err := gen.Set(val); err != nil {
+				return fmt.Errorf("invalid element %q: %s", val, err)
+			}
+		}
+		return nil
 	}
 	// otherwise create a new slice and apply the split values
 	values := generic.Zero(ptr)
-	for _, val := range strings.Split(val, ",") {
+	for _, val := range elems {
 		value := generic.NewElem(ptr)
+		if parseTimeElem(value, val, format) {
+			values = generic.Append(values, generic.ValueOfPtr(value))
+			continue
+		}
+		if ok, err := parseDurationElem(value, val, format); ok {
+			if err != nil {
+				return fmt.Errorf("invalid element %q: %s", val, err)
+			}
+			values = generic.Append(values, generic.ValueOfPtr(value))
+			continue
+		}
 		if err := generic.FromString(val, value); err != nil {
-			return err
+			return fmt.Errorf("invalid element %q: %s", val, err)
 		}
 		values = generic.Append(values, generic.ValueOfPtr(value))
 	}
@@ -75,11 +384,71 @@ func applyValue(ptr interface{}, val string) error {
 	return nil
 }
 
-func applyElem(ptr interface{}, val string) error {
+// joinLines joins the non-blank lines of s with commas, trimming whitespace
+// around each line. Commas and backslashes in a line are escaped so that
+// splitEscaped returns each line as an element.
+func joinLines(s string) string {
+	escape := strings.NewReplacer(`\`, `\\`, ",", `\,`)
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, escape.Replace(line))
+		}
+	}
+	return strings.Join(lines, ",")
+}
+
+// splitCSV returns the fields of all of the CSV records in s, ignoring
+// spaces before each field
+func splitCSV(s string) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(s))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var fields []string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, record...)
+	}
+}
+
+// splitEscaped splits s on sep, except where sep is escaped with a
+// backslash. An escaped backslash is replaced with a single backslash, and
+// any other backslash is kept as is.
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == sep || s[i+1] == '\\'):
+			i++
+			part.WriteByte(s[i])
+		case s[i] == sep:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(s[i])
+		}
+	}
+	return append(parts, part.String())
+}
+
+func applyElem(ptr interface{}, val, format string) error {
 	if gen, ok := ptr.(flag.Value); ok {
 		// if we are a generic flag.Value then apply Set
 		return gen.Set(val)
 	}
+	if parseTimeElem(ptr, val, format) {
+		return nil
+	}
+	if ok, err := parseDurationElem(ptr, val, format); ok {
+		return err
+	}
 	// otherwise create a new value and convert it
 	value := generic.NewElem(ptr)
 	if err := generic.FromString(val, value); err != nil {
@@ -90,16 +459,16 @@ func applyElem(ptr interface{}, val string) error {
 }
 
 func flagFromEnvOrFile(envVars []string, filePath string) (val string, ok bool) {
+	val, _, _, ok = resolve(defaultResolvers(envVars, filePath))
+	return val, ok
+}
+
+func flagFromEnv(envVars []string) (val string, ok bool) {
 	for _, envVar := range envVars {
 		envVar = strings.TrimSpace(envVar)
 		if val, ok := syscall.Getenv(envVar); ok {
 			return val, true
 		}
 	}
-	for _, fileVar := range strings.Split(filePath, ",") {
-		if data, err := ioutil.ReadFile(fileVar); err == nil {
-			return string(data), true
-		}
-	}
 	return "", false
 }
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+)
+
+const (
+	// BytesEncodingRaw uses the bytes of a value as given
+	BytesEncodingRaw = "raw"
+	// BytesEncodingBase64 decodes a value as standard or URL-safe base64,
+	// with or without padding
+	BytesEncodingBase64 = "base64"
+	// BytesEncodingHex decodes a value as hexadecimal
+	BytesEncodingHex = "hex"
+)
+
+// Apply populates the flag given the flag set and environment
+func (f *BytesFlag) Apply(set *flag.FlagSet) error {
+	name := FlagNames(f)[0]
+	encoding := f.Encoding
+	switch encoding {
+	case "":
+		encoding = BytesEncodingRaw
+	case BytesEncodingRaw, BytesEncodingBase64, BytesEncodingHex:
+	default:
+		return fmt.Errorf("unknown encoding %q for flag %s", f.Encoding, name)
+	}
+	ptr := f.Destination
+	if ptr == nil {
+		ptr = new([]byte)
+	}
+	value := &bytesValue{ptr: ptr, encoding: encoding}
+	if f.Value != "" {
+		if err := value.Set(f.Value); err != nil {
+			return fmt.Errorf("could not parse %q as bytes value for flag %s: %s", f.Value, name, err)
+		}
+	}
+	return applyValues(f, "bytes", value, value, set)
+}
+
+// Bytes looks up the value of a local BytesFlag, returns
+// nil if not found
+func (c *Context) Bytes(name string) []byte {
+	return c.Lookup(name, []byte(nil)).([]byte)
+}
+
+// bytesValue is a flag.Value for BytesFlag which decodes values with its
+// encoding into the []byte pointed to by ptr
+type bytesValue struct {
+	ptr      *[]byte
+	encoding string
+}
+
+// Set decodes a string with the encoding, or accepts a []byte
+func (v *bytesValue) Set(value interface{}) error {
+	switch val := value.(type) {
+	case []byte:
+		*v.ptr = val
+		return nil
+	case string:
+		b, err := decodeBytes(val, v.encoding)
+		if err != nil {
+			return fmt.Errorf("invalid %s value: %s", v.encoding, err)
+		}
+		*v.ptr = b
+		return nil
+	}
+	return fmt.Errorf("unable to set bytes from %T", value)
+}
+
+// Get returns the decoded bytes
+func (v *bytesValue) Get() interface{} {
+	return *v.ptr
+}
+
+// String returns the bytes encoded with the encoding
+func (v *bytesValue) String() string {
+	if v == nil || v.ptr == nil {
+		return ""
+	}
+	switch v.encoding {
+	case BytesEncodingBase64:
+		return base64.StdEncoding.EncodeToString(*v.ptr)
+	case BytesEncodingHex:
+		return hex.EncodeToString(*v.ptr)
+	}
+	return string(*v.ptr)
+}
+
+// newValue returns a bytesValue decoding into the same destination
+func (v *bytesValue) newValue() flag.Value {
+	return &bytesValue{ptr: v.ptr, encoding: v.encoding}
+}
+
+// decodeBytes decodes s with the encoding
+func decodeBytes(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case BytesEncodingBase64:
+		s = strings.NewReplacer("-", "+", "_", "/").Replace(strings.TrimRight(s, "="))
+		return base64.RawStdEncoding.DecodeString(s)
+	case BytesEncodingHex:
+		return hex.DecodeString(s)
+	}
+	return []byte(s), nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// choiceValue is a flag.Value for flags with Choices which rejects any
+// values not in the choices
+type choiceValue struct {
+	flag.Value
+	choices []string
+}
+
+// Set checks that value is one of the choices before passing it to the
+// underlying flag.Value
+func (v *choiceValue) Set(value interface{}) error {
+	if s, ok := value.(string); ok {
+		if err := checkChoice(s, v.choices); err != nil {
+			return err
+		}
+	}
+	return v.Value.Set(value)
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *choiceValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+func checkChoice(value string, choices []string) error {
+	for _, choice := range choices {
+		if value == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(choices, ", "))
+}
+
+// checkChoices checks that the string or each element of the string slice
+// pointed to by ptr is one of the choices
+func checkChoices(ptr interface{}, choices []string) error {
+	value := generic.ValueOfPtr(ptr)
+	if !generic.IsSlice(value) {
+		if s, ok := value.(string); ok {
+			return checkChoice(s, choices)
+		}
+		return nil
+	}
+	for i := 0; i < generic.Len(value); i++ {
+		if s, ok := generic.Index(value, i).(string); ok {
+			if err := checkChoice(s, choices); err != nil {
+				return fmt.Errorf("invalid element %q: %s", s, err)
+			}
+		}
+	}
+	return nil
+}
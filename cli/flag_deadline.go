@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// Apply populates the flag given the flag set and environment
+func (f *DeadlineFlag) Apply(set *flag.FlagSet) error {
+	value := &deadlineValue{}
+	if f.Value != "" {
+		if err := value.Set(f.Value); err != nil {
+			return fmt.Errorf("could not parse %q as deadline value for flag %s: %s", f.Value, FlagNames(f)[0], err)
+		}
+	}
+	var destination *deadlineValue
+	if f.Destination != nil {
+		destination = (*deadlineValue)(f.Destination)
+	}
+	return applyValues(f, "deadline", value, destination, set)
+}
+
+// Deadline looks up the value of a local DeadlineFlag, returns
+// an empty value if not found
+func (c *Context) Deadline(name string) time.Time {
+	return c.Lookup(name, time.Time{}).(time.Time)
+}
+
+// deadlineValue is a flag.Value for DeadlineFlag holding an absolute time
+type deadlineValue time.Time
+
+// Set parses a string as a duration from now or else as a timestamp, or
+// accepts a time.Duration or time.Time
+func (d *deadlineValue) Set(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		*d = deadlineValue(v)
+		return nil
+	case time.Duration:
+		*d = deadlineValue(time.Now().Add(v))
+		return nil
+	case string:
+		t, err := parseDeadline(v)
+		if err != nil {
+			return err
+		}
+		*d = deadlineValue(t)
+		return nil
+	}
+	return fmt.Errorf("unable to set deadline from %T", value)
+}
+
+// Get returns the deadline as a time.Time
+func (d *deadlineValue) Get() interface{} {
+	return time.Time(*d)
+}
+
+// String returns the deadline formatted as time.RFC3339Nano, or an empty
+// string if not set
+func (d *deadlineValue) String() string {
+	if d == nil || time.Time(*d).IsZero() {
+		return ""
+	}
+	return time.Time(*d).Format(time.RFC3339Nano)
+}
+
+// parseDeadline parses s as a duration added to the current time, otherwise
+// as a timestamp
+func parseDeadline(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(d), nil
+	}
+	var t time.Time
+	if err := generic.FromString(s, &t); err != nil {
+		return t, fmt.Errorf("expected a duration or timestamp")
+	}
+	return t, nil
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveDerivedFlags sets each flag with Derive which is not set from the
+// result of its func, deriving any flags it looks up from the context first
+func resolveDerivedFlags(flags []Flag, context *Context) error {
+	d := &flagDeriver{
+		flags:    map[string]Flag{},
+		done:     map[Flag]bool{},
+		visiting: map[Flag]bool{},
+	}
+	var derived []Flag
+	for _, f := range flags {
+		if derive, _ := getFlagDerive(f); derive != nil {
+			derived = append(derived, f)
+			for _, name := range FlagNames(f) {
+				d.flags[name] = f
+			}
+		}
+	}
+	if len(derived) == 0 {
+		return nil
+	}
+	// the funcs are passed a copy of the context which derives the flags
+	// they look up
+	ctx := *context
+	ctx.deriver = d
+	d.context = &ctx
+	var errs []error
+	for _, f := range derived {
+		if err := d.derive(f); err != nil {
+			errs = append(errs, err)
+		}
+		d.err = nil
+	}
+	return joinErrors(errs...)
+}
+
+// flagDeriver runs the Derive funcs of flags in the order of their lookups
+// of each other
+type flagDeriver struct {
+	context  *Context
+	flags    map[string]Flag // flags with Derive by each name
+	done     map[Flag]bool
+	visiting map[Flag]bool
+	path     []string // names of the flags being derived
+	err      error    // error deriving a flag looked up by a Derive func
+}
+
+// lookup derives the named flag before it is looked up by a Derive func,
+// keeping the first error to be returned once that func is done
+func (d *flagDeriver) lookup(name string) {
+	if f, ok := d.flags[name]; ok && d.err == nil {
+		d.err = d.derive(f)
+	}
+}
+
+// derive sets the value of f from its Derive func if it is not set
+func (d *flagDeriver) derive(f Flag) error {
+	if d.done[f] {
+		return nil
+	}
+	name := FlagNames(f)[0]
+	if d.visiting[f] {
+		return fmt.Errorf("cycle in derived flags: %s", strings.Join(append(d.path, name), " -> "))
+	}
+	d.visiting[f] = true
+	d.path = append(d.path, name)
+	defer func() {
+		d.visiting[f] = false
+		d.done[f] = true
+		d.path = d.path[:len(d.path)-1]
+	}()
+
+	if d.context.IsSet(name) {
+		return nil
+	}
+	derive, _ := getFlagDerive(f)
+	value, err := derive(d.context)
+	if d.err != nil {
+		return d.err
+	}
+	if err != nil {
+		return fmt.Errorf("could not derive value for flag %s: %s", name, err)
+	}
+	fs := lookupFlagSet(name, d.context)
+	if value == nil || fs == nil {
+		return nil
+	}
+	// set the value without marking the flag as set, as it is a default
+	if err := fs.Lookup(name).Value.Set(value); err != nil {
+		return fmt.Errorf("could not set derived value for flag %s: %s", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// The values of DurationFlag.DurationFormat
+const (
+	DurationFormatGo      = "go"
+	DurationFormatISO8601 = "iso8601"
+)
+
+// HumanizeDuration returns d in a human readable form, such as
+// "1 hour 30 minutes" for 90 minutes, see generic.HumanizeDuration
+func HumanizeDuration(d time.Duration) string {
+	return generic.HumanizeDuration(d)
+}
+
+// iso8601DurationValue is a flag.Value for duration flags which parses
+// values as ISO 8601 durations
+type iso8601DurationValue struct {
+	flag.Value
+}
+
+// Set parses value as an ISO 8601 duration, otherwise passes it unchanged
+// to the underlying flag.Value
+func (v *iso8601DurationValue) Set(value interface{}) error {
+	if s, ok := value.(string); ok {
+		d, err := generic.ParseISO8601Duration(s)
+		if err != nil {
+			return err
+		}
+		value = d
+	}
+	return v.Value.Set(value)
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *iso8601DurationValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+// checkDurationFormat returns an error if format is not a known
+// DurationFormat
+func checkDurationFormat(format string) error {
+	switch format {
+	case "", DurationFormatGo, DurationFormatISO8601:
+		return nil
+	}
+	return fmt.Errorf("unknown duration format %q", format)
+}
+
+// parseDurationElem sets the time.Duration pointed to by ptr from val as
+// an ISO 8601 duration if that is the format, returning false otherwise
+func parseDurationElem(ptr interface{}, val, format string) (bool, error) {
+	if _, ok := ptr.(*time.Duration); !ok || format != DurationFormatISO8601 {
+		return false, nil
+	}
+	d, err := generic.ParseISO8601Duration(val)
+	if err != nil {
+		return true, err
+	}
+	generic.Set(ptr, d)
+	return true, nil
+}
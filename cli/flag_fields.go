@@ -1,5 +1,7 @@
 package cli
 
+import "time"
+
 func getFlagName(f Flag) (result string, ok bool) {
 	if v := flagValue(f).FieldByName("Name"); v.IsValid() {
 		return v.Interface().(string), true
@@ -90,3 +92,192 @@ func getFlagDestination(f Flag) (result interface{}, ok bool) {
 	}
 	return
 }
+
+func getFlagNArgs(f Flag) (result int, ok bool) {
+	if v := flagValue(f).FieldByName("NArgs"); v.IsValid() {
+		return v.Interface().(int), true
+	}
+	return
+}
+
+func getFlagEmptyEnvClears(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("EmptyEnvClears"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagEmptyEnvValue(f Flag) (result string, ok bool) {
+	if v := flagValue(f).FieldByName("EmptyEnvValue"); v.IsValid() {
+		return v.Interface().(string), true
+	}
+	return
+}
+
+func getFlagNewlineSeparated(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("NewlineSeparated"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagAppend(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("Append"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagCSVEnv(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("CSVEnv"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagAllowSchemes(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("AllowSchemes"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagExpandEnv(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("ExpandEnv"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagSecret(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("Secret"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagFromFileFlag(f Flag) (result string, ok bool) {
+	if v := flagValue(f).FieldByName("FromFileFlag"); v.IsValid() {
+		return v.Interface().(string), true
+	}
+	return
+}
+
+func getFlagLayout(f Flag) (result string, ok bool) {
+	if v := flagValue(f).FieldByName("Layout"); v.IsValid() {
+		return v.Interface().(string), true
+	}
+	return
+}
+
+func getFlagPositionalAlias(f Flag) (result int, ok bool) {
+	if v := flagValue(f).FieldByName("PositionalAlias"); v.IsValid() {
+		return v.Interface().(int), true
+	}
+	return
+}
+
+func getFlagDerive(f Flag) (result func(ctx *Context) (interface{}, error), ok bool) {
+	if v := flagValue(f).FieldByName("Derive"); v.IsValid() {
+		return v.Interface().(func(ctx *Context) (interface{}, error)), true
+	}
+	return
+}
+
+func getFlagKeywords(f Flag) (result map[string]func() time.Time, ok bool) {
+	if v := flagValue(f).FieldByName("Keywords"); v.IsValid() {
+		return v.Interface().(map[string]func() time.Time), true
+	}
+	return
+}
+
+func getFlagFormatter(f Flag) (result func(interface{}) string, ok bool) {
+	if v := flagValue(f).FieldByName("Formatter"); v.IsValid() {
+		return v.Interface().(func(interface{}) string), true
+	}
+	return
+}
+
+func getFlagDurationFormat(f Flag) (result string, ok bool) {
+	if v := flagValue(f).FieldByName("DurationFormat"); v.IsValid() {
+		return v.Interface().(string), true
+	}
+	return
+}
+
+func getFlagHumanize(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("Humanize"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagEnvTransform(f Flag) (result func(string) (string, error), ok bool) {
+	if v := flagValue(f).FieldByName("EnvTransform"); v.IsValid() {
+		return v.Interface().(func(string) (string, error)), true
+	}
+	return
+}
+
+func getFlagMinItems(f Flag) (result int, ok bool) {
+	if v := flagValue(f).FieldByName("MinItems"); v.IsValid() {
+		return v.Interface().(int), true
+	}
+	return
+}
+
+func getFlagMaxItems(f Flag) (result int, ok bool) {
+	if v := flagValue(f).FieldByName("MaxItems"); v.IsValid() {
+		return v.Interface().(int), true
+	}
+	return
+}
+
+func getFlagUnique(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("Unique"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagHasValue(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("HasValue"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagResetToken(f Flag) (result string, ok bool) {
+	if v := flagValue(f).FieldByName("ResetToken"); v.IsValid() {
+		return v.Interface().(string), true
+	}
+	return
+}
+
+func getFlagTemplateDefault(f Flag) (result bool, ok bool) {
+	if v := flagValue(f).FieldByName("TemplateDefault"); v.IsValid() {
+		return v.Interface().(bool), true
+	}
+	return
+}
+
+func getFlagRequires(f Flag) (result []string, ok bool) {
+	if v := flagValue(f).FieldByName("Requires"); v.IsValid() {
+		return v.Interface().([]string), true
+	}
+	return
+}
+
+func getFlagResolvers(f Flag) (result []Resolver, ok bool) {
+	if v := flagValue(f).FieldByName("Resolvers"); v.IsValid() {
+		return v.Interface().([]Resolver), true
+	}
+	return
+}
+
+func getFlagChoices(f Flag) (result []string, ok bool) {
+	if v := flagValue(f).FieldByName("Choices"); v.IsValid() {
+		return v.Interface().([]string), true
+	}
+	return
+}
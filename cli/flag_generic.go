@@ -1,29 +1,16 @@
 package cli
 
 import (
+	"fmt"
+	"reflect"
+
 	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
 )
 
 // Generic is a type alias for flag.Value
 type Generic = flag.Value
 
-// GenericFlag is a flag with type flag.Value
-type GenericFlag struct {
-	Name        string
-	Aliases     []string
-	EnvVars     []string
-	Usage       string
-	DefaultText string
-	FilePath    string
-	Required    bool
-	Hidden      bool
-	TakesFile   bool
-	SkipAltSrc  bool
-
-	Value       Generic
-	Destination Generic
-}
-
 // Apply populates the flag given the flag set and environment
 func (f *GenericFlag) Apply(set *flag.FlagSet) error {
 	return Apply(f, "generic", set)
@@ -34,3 +21,56 @@ func (f *GenericFlag) Apply(set *flag.FlagSet) error {
 func (c *Context) Generic(name string) interface{} {
 	return c.Lookup(name, nil)
 }
+
+// GenericInto looks up the value of a local flag and stores it in the value
+// pointed to by dest, converting it to the type of dest with generic.Convert.
+// Returns an error if the flag is not found or cannot be converted.
+func (c *Context) GenericInto(name string, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("expected non-nil pointer, got %T", dest)
+	}
+	value, ok := c.Lookup(name, nil).(flag.Value)
+	if !ok {
+		return fmt.Errorf("flag %s not found", name)
+	}
+	if err := unmarshalValue(rv.Elem(), value); err != nil {
+		return fmt.Errorf("could not convert flag %s to %s: %s", name, rv.Elem().Type(), err)
+	}
+	return nil
+}
+
+// sliceGenericValue wraps a user defined flag.Value of a slice type so that
+// the first Set clears any default value and later calls accumulate,
+// matching the behavior of the built-in slice flags
+type sliceGenericValue struct {
+	flag.Value
+	set bool
+}
+
+// Set clears the underlying slice on the first call before passing value
+// to the underlying flag.Value
+func (v *sliceGenericValue) Set(value interface{}) error {
+	if !v.set {
+		generic.Set(v.Value, generic.Zero(v.Value))
+		v.set = true
+	}
+	return v.Value.Set(value)
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *sliceGenericValue) Get() interface{} {
+	if getter, ok := v.Value.(flag.Getter); ok {
+		return getter.Get()
+	}
+	return v.Value
+}
+
+// unwrapGeneric returns the user defined flag.Value wrapped by a
+// sliceGenericValue, or value unchanged
+func unwrapGeneric(value flag.Value) flag.Value {
+	if v, ok := value.(*sliceGenericValue); ok {
+		return v.Value
+	}
+	return value
+}
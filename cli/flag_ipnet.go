@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+)
+
+// Apply populates the flag given the flag set and environment
+func (f *IPNetSliceFlag) Apply(set *flag.FlagSet) error {
+	value := ipNetSliceValue{}
+	for _, cidr := range f.Value {
+		if err := value.Set(cidr); err != nil {
+			return fmt.Errorf("could not parse %q as ipnet slice value for flag %s: %s", cidr, FlagNames(f)[0], err)
+		}
+	}
+	var destination Generic
+	if f.Destination != nil {
+		destination = (*ipNetSliceValue)(f.Destination)
+	}
+	return applyValues(f, "ipnet slice", &value, destination, set)
+}
+
+// IPNetSlice looks up the value of a local IPNetSliceFlag, returns
+// nil if not found
+func (c *Context) IPNetSlice(name string) []*net.IPNet {
+	return c.Lookup(name, []*net.IPNet(nil)).([]*net.IPNet)
+}
+
+// ipNetSliceValue is a flag.Value for IPNetSliceFlag, where each call to
+// Set adds the comma separated CIDR blocks given
+type ipNetSliceValue []*net.IPNet
+
+// Set adds the comma separated CIDR blocks of a string, or a *net.IPNet
+// or []*net.IPNet
+func (s *ipNetSliceValue) Set(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		for _, cidr := range splitEscaped(v, ',') {
+			_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				return err
+			}
+			*s = append(*s, ipNet)
+		}
+	case *net.IPNet:
+		*s = append(*s, v)
+	case []*net.IPNet:
+		*s = append(*s, v...)
+	default:
+		return fmt.Errorf("unable to add %T to ipnet slice", value)
+	}
+	return nil
+}
+
+// Get returns the CIDR blocks as a []*net.IPNet
+func (s *ipNetSliceValue) Get() interface{} {
+	return []*net.IPNet(*s)
+}
+
+// String returns the CIDR blocks joined with commas
+func (s *ipNetSliceValue) String() string {
+	if s == nil {
+		return ""
+	}
+	cidrs := make([]string, len(*s))
+	for i, ipNet := range *s {
+		cidrs[i] = ipNet.String()
+	}
+	return strings.Join(cidrs, ",")
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// Apply populates the flag given the flag set and environment
+func (f *JSONFlag) Apply(set *flag.FlagSet) error {
+	ptr := f.Destination
+	if ptr == nil {
+		ptr = new(interface{})
+	}
+	if !generic.IsPtr(ptr) {
+		return fmt.Errorf("destination of flag %s must be a pointer, got %T", FlagNames(f)[0], ptr)
+	}
+	value := &jsonValue{ptr: ptr}
+	if f.Value != "" {
+		if err := value.Set(f.Value); err != nil {
+			return fmt.Errorf("could not parse %q as json value for flag %s: %s", f.Value, FlagNames(f)[0], err)
+		}
+	}
+	return applyValues(f, "json", value, value, set)
+}
+
+// JSON looks up the decoded value of a local JSONFlag, returns
+// nil if not found
+func (c *Context) JSON(name string) interface{} {
+	if value, ok := c.Lookup(name, nil).(flag.Getter); ok {
+		return value.Get()
+	}
+	return nil
+}
+
+// jsonValue is a flag.Value for JSONFlag which decodes JSON into ptr
+type jsonValue struct {
+	ptr interface{}
+}
+
+// Set decodes a JSON string, or the JSON encoding of any other value, and
+// replaces the value pointed to by ptr
+func (v *jsonValue) Set(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		s = string(b)
+	}
+	ptr := generic.New(v.ptr)
+	if err := json.Unmarshal([]byte(s), ptr); err != nil {
+		return err
+	}
+	generic.Set(v.ptr, generic.ValueOfPtr(ptr))
+	return nil
+}
+
+// Get returns the decoded value
+func (v *jsonValue) Get() interface{} {
+	return generic.ValueOfPtr(v.ptr)
+}
+
+// String returns the JSON encoding of the decoded value
+func (v *jsonValue) String() string {
+	if v == nil || v.ptr == nil || v.Get() == nil {
+		return ""
+	}
+	b, err := json.Marshal(v.Get())
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// IsBoolFlag returns false as a JSON value is always required, even when
+// decoding into a bool
+func (v *jsonValue) IsBoolFlag() bool {
+	return false
+}
+
+// newValue returns a jsonValue decoding into the same destination
+func (v *jsonValue) newValue() flag.Value {
+	return &jsonValue{ptr: v.ptr}
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+)
+
+// Apply populates the flag given the flag set and environment
+func (f *PathFlag) Apply(set *flag.FlagSet) error {
+	var value, destination flag.Value
+	if f.Expand {
+		value = new(expandedPathValue)
+		if f.Destination != nil {
+			destination = (*expandedPathValue)(f.Destination)
+		}
+	} else {
+		value = new(pathValue)
+		if f.Destination != nil {
+			destination = (*pathValue)(f.Destination)
+		}
+	}
+	if err := value.Set(f.Value); err != nil {
+		return fmt.Errorf("could not expand %q as path value for flag %s: %s", f.Value, FlagNames(f)[0], err)
+	}
+	return applyValues(f, "path", value, destination, set)
+}
+
+// Path looks up the value of a local PathFlag, returns
+// an empty value if not found
+func (c *Context) Path(name string) string {
+	return c.Lookup(name, "").(string)
+}
+
+// pathValue is a flag.Value for PathFlag holding a path as given
+type pathValue string
+
+// Set accepts a path as a string
+func (p *pathValue) Set(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("unable to set path from %T", value)
+	}
+	*p = pathValue(s)
+	return nil
+}
+
+// Get returns the path as a string
+func (p *pathValue) Get() interface{} {
+	return string(*p)
+}
+
+// String returns the path
+func (p *pathValue) String() string {
+	if p == nil {
+		return ""
+	}
+	return string(*p)
+}
+
+// expandedPathValue is a flag.Value for PathFlag with Expand set, holding
+// the expanded path
+type expandedPathValue string
+
+// Set expands and accepts a path as a string
+func (p *expandedPathValue) Set(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("unable to set path from %T", value)
+	}
+	s, err := expandPath(s)
+	if err != nil {
+		return err
+	}
+	*p = expandedPathValue(s)
+	return nil
+}
+
+// Get returns the expanded path as a string
+func (p *expandedPathValue) Get() interface{} {
+	return string(*p)
+}
+
+// String returns the expanded path
+func (p *expandedPathValue) String() string {
+	if p == nil {
+		return ""
+	}
+	return string(*p)
+}
+
+// expandPath replaces a leading ~ of path with the home directory of the
+// user and ${VAR} references with environment variables
+func expandPath(path string) (string, error) {
+	path, err := expandEnvDefaults(path)
+	if err != nil {
+		return "", err
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
+
+// checkPathFlags returns an error for each PathFlag with a path which does
+// not exist or is not of the kind required
+func checkPathFlags(flags []Flag, context *Context) error {
+	var errs []error
+	for _, f := range flags {
+		pf, ok := f.(*PathFlag)
+		if !ok || !(pf.MustExist || pf.MustBeDir || pf.MustBeFile) {
+			continue
+		}
+		path := context.Path(FlagNames(f)[0])
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		switch {
+		case os.IsNotExist(err):
+			errs = append(errs, errors.New(context.App.translate(MsgPathNotExist, path)))
+		case err != nil:
+			errs = append(errs, err)
+		case pf.MustBeDir && !info.IsDir():
+			errs = append(errs, errors.New(context.App.translate(MsgPathNotDir, path)))
+		case pf.MustBeFile && !info.Mode().IsRegular():
+			errs = append(errs, errors.New(context.App.translate(MsgPathNotFile, path)))
+		}
+	}
+	return joinErrors(errs...)
+}
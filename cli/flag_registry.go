@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// flagConstructors create the built-in flags by the names of their types
+// as used in parse errors and given by generic.TypeName, such as "int" or
+// "string slice"
+var flagConstructors = map[string]func(name string) Flag{
+	"bool":           func(name string) Flag { return &BoolFlag{Name: name} },
+	"bool slice":     func(name string) Flag { return &BoolSliceFlag{Name: name} },
+	"duration":       func(name string) Flag { return &DurationFlag{Name: name} },
+	"duration slice": func(name string) Flag { return &DurationSliceFlag{Name: name} },
+	"float64":        func(name string) Flag { return &Float64Flag{Name: name} },
+	"float64 slice":  func(name string) Flag { return &Float64SliceFlag{Name: name} },
+	"int":            func(name string) Flag { return &IntFlag{Name: name} },
+	"int slice":      func(name string) Flag { return &IntSliceFlag{Name: name} },
+	"int64":          func(name string) Flag { return &Int64Flag{Name: name} },
+	"int64 slice":    func(name string) Flag { return &Int64SliceFlag{Name: name} },
+	"string":         func(name string) Flag { return &StringFlag{Name: name} },
+	"string slice":   func(name string) Flag { return &StringSliceFlag{Name: name} },
+	"string set":     func(name string) Flag { return &StringSetFlag{Name: name} },
+	"time":           func(name string) Flag { return &TimeFlag{Name: name} },
+	"time slice":     func(name string) Flag { return &TimeSliceFlag{Name: name} },
+	"uint":           func(name string) Flag { return &UintFlag{Name: name} },
+	"uint slice":     func(name string) Flag { return &UintSliceFlag{Name: name} },
+	"uint64":         func(name string) Flag { return &Uint64Flag{Name: name} },
+	"uint64 slice":   func(name string) Flag { return &Uint64SliceFlag{Name: name} },
+	"deadline":       func(name string) Flag { return &DeadlineFlag{Name: name} },
+	"path":           func(name string) Flag { return &PathFlag{Name: name} },
+	"bytes":          func(name string) Flag { return &BytesFlag{Name: name} },
+	"ipnet slice":    func(name string) Flag { return &IPNetSliceFlag{Name: name} },
+	"string map":     func(name string) Flag { return &StringMapFlag{Name: name} },
+}
+
+// NewFlag returns a flag with the given name for a built-in type such as
+// "int" or "string slice", or for a type registered with
+// generic.RegisterType such as "net.IP" or "[]net.IP" as a GenericFlag,
+// so that flags may be defined from data such as JSON
+func NewFlag(typeName, name string) (Flag, error) {
+	if newFlag, ok := flagConstructors[typeName]; ok {
+		return newFlag(name), nil
+	}
+	if typ, ok := generic.LookupType(typeName); ok {
+		return &GenericFlag{Name: name, Value: flag.NewGenericValue(reflect.New(typ).Interface())}, nil
+	}
+	return nil, fmt.Errorf("unknown flag type %q", typeName)
+}
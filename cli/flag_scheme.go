@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+)
+
+// valueSchemes resolve the values of flags with AllowSchemes which are
+// given as scheme://ref by the scheme
+var valueSchemes = map[string]func(ref string) (string, error){
+	"file": func(path string) (string, error) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+	},
+	"env": func(name string) (string, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	},
+}
+
+// resolveValueScheme returns the value referred to by a value such as
+// file://path or env://NAME, or value unchanged if it has no known scheme
+func resolveValueScheme(value string) (string, error) {
+	i := strings.Index(value, "://")
+	if i < 0 {
+		return value, nil
+	}
+	resolve, ok := valueSchemes[value[:i]]
+	if !ok {
+		return value, nil
+	}
+	return resolve(value[i+len("://"):])
+}
+
+// schemeValue is a flag.Value for flags with AllowSchemes which resolves
+// values such as file://path before passing them to the underlying
+// flag.Value
+type schemeValue struct {
+	flag.Value
+}
+
+// Set resolves a string value with resolveValueScheme, otherwise passes
+// value unchanged to the underlying flag.Value
+func (v *schemeValue) Set(value interface{}) error {
+	if s, ok := value.(string); ok {
+		resolved, err := resolveValueScheme(s)
+		if err != nil {
+			return err
+		}
+		value = resolved
+	}
+	return v.Value.Set(value)
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *schemeValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+// IsSecret returns true if the underlying flag.Value is secret, so that the
+// flag package masks values in errors
+func (v *schemeValue) IsSecret() bool {
+	secret, ok := v.Value.(flag.SecretValue)
+	return ok && secret.IsSecret()
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+)
+
+// Apply populates the flag given the flag set and environment
+func (f *StringMapFlag) Apply(set *flag.FlagSet) error {
+	ptr := f.Destination
+	if ptr == nil {
+		ptr = new(map[string]string)
+	}
+	value := &stringMapValue{ptr: ptr, unique: f.Unique}
+	for _, pair := range f.Value {
+		if err := value.Set(pair); err != nil {
+			return fmt.Errorf("could not parse %q as string map value for flag %s: %s", pair, FlagNames(f)[0], err)
+		}
+	}
+	value.set = false
+	return applyValues(f, "string map", value, value, set)
+}
+
+// StringMap looks up the value of a local StringMapFlag, returns nil if
+// not found. The map returned is a copy which may be changed freely.
+func (c *Context) StringMap(name string) map[string]string {
+	m := c.Lookup(name, map[string]string(nil)).(map[string]string)
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// stringMapValue is a flag.Value for StringMapFlag, where the first call to
+// Set replaces the map pointed to by ptr and later calls add to it. With
+// unique set a key which is given more than once is an error.
+type stringMapValue struct {
+	ptr    *map[string]string
+	set    bool
+	unique bool
+}
+
+// Set adds the comma separated key=value pairs of a string, or the pairs
+// of a map such as one decoded from a JSON or YAML input source
+func (v *stringMapValue) Set(value interface{}) error {
+	pairs := map[string]string{}
+	switch val := value.(type) {
+	case string:
+		for _, pair := range splitEscaped(val, ',') {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			if _, ok := pairs[kv[0]]; ok && v.unique {
+				return fmt.Errorf("duplicate key %q", kv[0])
+			}
+			pairs[kv[0]] = kv[1]
+		}
+	case map[string]string:
+		pairs = val
+	case map[string]interface{}:
+		for k, v := range val {
+			pairs[k] = fmt.Sprint(v)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range val {
+			pairs[fmt.Sprint(k)] = fmt.Sprint(v)
+		}
+	default:
+		return fmt.Errorf("unable to add %T to string map", value)
+	}
+	// a new map is always made so that the default is never changed
+	m := map[string]string{}
+	if v.set {
+		for k, val := range *v.ptr {
+			if _, ok := pairs[k]; ok && v.unique {
+				return fmt.Errorf("duplicate key %q", k)
+			}
+			m[k] = val
+		}
+	}
+	for k, val := range pairs {
+		m[k] = val
+	}
+	*v.ptr = m
+	v.set = true
+	return nil
+}
+
+// Get returns the map of keys to values
+func (v *stringMapValue) Get() interface{} {
+	return *v.ptr
+}
+
+// String returns the key=value pairs sorted by key and joined with commas
+func (v *stringMapValue) String() string {
+	if v == nil || v.ptr == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*v.ptr))
+	for k, val := range *v.ptr {
+		pairs = append(pairs, k+"="+val)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// newValue returns a stringMapValue setting the same destination
+func (v *stringMapValue) newValue() flag.Value {
+	return &stringMapValue{ptr: v.ptr, unique: v.unique}
+}
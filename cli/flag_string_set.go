@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+)
+
+// StringSet is an ordered set of strings which keeps the first occurrence
+// of each value. It implements flag.Value, where each call to Set adds the
+// comma separated values given.
+type StringSet []string
+
+// NewStringSet returns a StringSet of the unique values in order
+func NewStringSet(values ...string) StringSet {
+	s := StringSet{}
+	s.add(values...)
+	return s
+}
+
+// Has returns true if the set contains value
+func (s StringSet) Has(value string) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Slice returns a copy of the values of the set in order
+func (s StringSet) Slice() []string {
+	return append([]string{}, s...)
+}
+
+// Set adds the comma separated values of a string, or a []string
+func (s *StringSet) Set(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		s.add(splitEscaped(v, ',')...)
+	case []string:
+		s.add(v...)
+	default:
+		return fmt.Errorf("unable to add %T to string set", value)
+	}
+	return nil
+}
+
+// Get returns the set
+func (s *StringSet) Get() interface{} {
+	return *s
+}
+
+// String returns the values of the set joined with commas
+func (s *StringSet) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *StringSet) add(values ...string) {
+	for _, value := range values {
+		if !s.Has(value) {
+			*s = append(*s, value)
+		}
+	}
+}
+
+// Apply populates the flag given the flag set and environment
+func (f *StringSetFlag) Apply(set *flag.FlagSet) error {
+	value := NewStringSet(f.Value...)
+	var destination Generic
+	if f.Destination != nil {
+		destination = f.Destination
+	}
+	return applyValues(f, "string set", &value, destination, set)
+}
+
+// StringSet looks up the value of a local StringSetFlag, returns
+// an empty set if not found
+func (c *Context) StringSet(name string) StringSet {
+	return c.Lookup(name, StringSet{}).(StringSet)
+}
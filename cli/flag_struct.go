@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// structFlagTypes maps the field types supported by FlagsFromStruct to
+// a function returning a new flag of the matching type
+var structFlagTypes = map[reflect.Type]func() Flag{
+	reflect.TypeOf(Bool(false)):        func() Flag { return &BoolFlag{} },
+	reflect.TypeOf(Int(0)):             func() Flag { return &IntFlag{} },
+	reflect.TypeOf(Int64(0)):           func() Flag { return &Int64Flag{} },
+	reflect.TypeOf(Uint(0)):            func() Flag { return &UintFlag{} },
+	reflect.TypeOf(Uint64(0)):          func() Flag { return &Uint64Flag{} },
+	reflect.TypeOf(Float64(0)):         func() Flag { return &Float64Flag{} },
+	reflect.TypeOf(String("")):         func() Flag { return &StringFlag{} },
+	reflect.TypeOf(Time{}):             func() Flag { return &TimeFlag{} },
+	reflect.TypeOf(Duration(0)):        func() Flag { return &DurationFlag{} },
+	reflect.TypeOf(BoolSlice(nil)):     func() Flag { return &BoolSliceFlag{} },
+	reflect.TypeOf(IntSlice(nil)):      func() Flag { return &IntSliceFlag{} },
+	reflect.TypeOf(Int64Slice(nil)):    func() Flag { return &Int64SliceFlag{} },
+	reflect.TypeOf(UintSlice(nil)):     func() Flag { return &UintSliceFlag{} },
+	reflect.TypeOf(Uint64Slice(nil)):   func() Flag { return &Uint64SliceFlag{} },
+	reflect.TypeOf(Float64Slice(nil)):  func() Flag { return &Float64SliceFlag{} },
+	reflect.TypeOf(StringSlice(nil)):   func() Flag { return &StringSliceFlag{} },
+	reflect.TypeOf(TimeSlice(nil)):     func() Flag { return &TimeSliceFlag{} },
+	reflect.TypeOf(DurationSlice(nil)): func() Flag { return &DurationSliceFlag{} },
+	genericType:                        func() Flag { return &GenericFlag{} },
+}
+
+var genericType = reflect.TypeOf((*Generic)(nil)).Elem()
+
+// structTag is the parsed form of a `cli:"..."` struct field tag
+type structTag struct {
+	name     string
+	usage    string
+	aliases  []string
+	envVars  []string
+	filePath string
+	required bool
+	hidden   bool
+}
+
+// parseStructTag parses a field tag of the form
+// `cli:"name,usage=text,alias=n,env=NAME,file=path,required,hidden"`.
+// The alias and env options may be repeated. Since options are comma
+// separated a `usage:"..."` tag may be used for usage text containing commas.
+// Returns false if the field has no tag or the tag name is "-".
+func parseStructTag(field reflect.StructField) (*structTag, bool, error) {
+	value, ok := field.Tag.Lookup("cli")
+	if !ok || value == "-" {
+		return nil, false, nil
+	}
+	parts := strings.Split(value, ",")
+	tag := &structTag{name: strings.TrimSpace(parts[0])}
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		switch {
+		case kv[0] == "required" && len(kv) == 1:
+			tag.required = true
+		case kv[0] == "hidden" && len(kv) == 1:
+			tag.hidden = true
+		case kv[0] == "usage" && len(kv) == 2:
+			tag.usage = kv[1]
+		case kv[0] == "alias" && len(kv) == 2:
+			tag.aliases = append(tag.aliases, kv[1])
+		case kv[0] == "env" && len(kv) == 2:
+			tag.envVars = append(tag.envVars, kv[1])
+		case kv[0] == "file" && len(kv) == 2:
+			tag.filePath = kv[1]
+		default:
+			return nil, false, fmt.Errorf("invalid option %q in tag for field %s", part, field.Name)
+		}
+	}
+	if usage, ok := field.Tag.Lookup("usage"); ok {
+		tag.usage = usage
+	}
+	return tag, true, nil
+}
+
+// FlagsFromStruct returns a flag for each field of the struct pointed to by v
+// with a `cli` tag. Each field is used as the Destination of its flag, and
+// the current value of the field is used as the flag default. Fields which
+// are structs (other than time.Time) are traversed, with the tag name of the
+// struct field prefixed to the names of the nested flags as `parent.child`,
+// unless the struct field is tagged `cli:"-"`.
+func FlagsFromStruct(v interface{}) ([]Flag, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected pointer to struct, got %T", v)
+	}
+	return flagsFromStruct(rv.Elem(), "")
+}
+
+func flagsFromStruct(rv reflect.Value, prefix string) ([]Flag, error) {
+	var flags []Flag
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			// skip unexported fields
+			continue
+		}
+		if field.Tag.Get("cli") == "-" {
+			continue
+		}
+		tag, ok, err := parseStructTag(field)
+		if err != nil {
+			return nil, err
+		}
+		fv := rv.Field(i)
+		if _, known := structFlagType(fv); !known && field.Type.Kind() == reflect.Struct {
+			nestedPrefix := prefix
+			if ok && tag.name != "" {
+				nestedPrefix = prefix + tag.name + "."
+			}
+			nested, err := flagsFromStruct(fv, nestedPrefix)
+			if err != nil {
+				return nil, err
+			}
+			flags = append(flags, nested...)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if tag.name == "" {
+			return nil, fmt.Errorf("missing flag name in tag for field %s", field.Name)
+		}
+		fl, err := newStructFlag(fv, prefix+tag.name, tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %s", field.Name, err)
+		}
+		flags = append(flags, fl)
+	}
+	return flags, nil
+}
+
+// structFlagType returns the flag type used for a struct field value
+func structFlagType(fv reflect.Value) (reflect.Type, bool) {
+	if fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Type().Implements(genericType) {
+		return genericType, true
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(genericType) {
+		return genericType, true
+	}
+	if _, ok := structFlagTypes[fv.Type()]; ok {
+		return fv.Type(), true
+	}
+	return nil, false
+}
+
+func newStructFlag(fv reflect.Value, name string, tag *structTag) (Flag, error) {
+	typ, ok := structFlagType(fv)
+	if !ok {
+		return nil, fmt.Errorf("unsupported flag type %s", fv.Type())
+	}
+	fl := structFlagTypes[typ]()
+	flv := flagValue(fl)
+	flv.FieldByName("Name").SetString(name)
+	flv.FieldByName("Usage").SetString(tag.usage)
+	flv.FieldByName("Aliases").Set(reflect.ValueOf(tag.aliases))
+	flv.FieldByName("EnvVars").Set(reflect.ValueOf(tag.envVars))
+	flv.FieldByName("FilePath").SetString(tag.filePath)
+	flv.FieldByName("Required").SetBool(tag.required)
+	flv.FieldByName("Hidden").SetBool(tag.hidden)
+	if typ == genericType {
+		ptr := fv
+		if !fv.Type().Implements(genericType) {
+			ptr = fv.Addr()
+		}
+		flv.FieldByName("Value").Set(ptr)
+		flv.FieldByName("Destination").Set(ptr)
+	} else {
+		flv.FieldByName("Value").Set(fv)
+		flv.FieldByName("Destination").Set(fv.Addr())
+	}
+	return fl, nil
+}
+
+// Unmarshal populates each field of the struct pointed to by v with a `cli`
+// tag from the value of the flag of the same name, as described by
+// FlagsFromStruct. Values are converted to the type of the field with
+// generic.Convert, and fields without a matching flag are left unchanged.
+func (c *Context) Unmarshal(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected pointer to struct, got %T", v)
+	}
+	return c.unmarshalStruct(rv.Elem(), "")
+}
+
+func (c *Context) unmarshalStruct(rv reflect.Value, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			// skip unexported fields
+			continue
+		}
+		if field.Tag.Get("cli") == "-" {
+			continue
+		}
+		tag, ok, err := parseStructTag(field)
+		if err != nil {
+			return err
+		}
+		fv := rv.Field(i)
+		if _, known := structFlagType(fv); !known && field.Type.Kind() == reflect.Struct {
+			nestedPrefix := prefix
+			if ok && tag.name != "" {
+				nestedPrefix = prefix + tag.name + "."
+			}
+			if err := c.unmarshalStruct(fv, nestedPrefix); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ok || tag.name == "" {
+			continue
+		}
+		name := prefix + tag.name
+		value, ok := c.Lookup(name, nil).(flag.Value)
+		if !ok {
+			continue
+		}
+		if err := unmarshalValue(fv, value); err != nil {
+			return fmt.Errorf("could not unmarshal flag %s into field %s: %s", name, field.Name, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalValue(fv reflect.Value, value flag.Value) error {
+	if gen, ok := fv.Addr().Interface().(Generic); ok {
+		if fv.Addr().Interface() == value {
+			return nil
+		}
+		return gen.Set(value.String())
+	}
+	var val interface{} = value
+	if getter, ok := value.(flag.Getter); ok {
+		val = getter.Get()
+	}
+	if val == nil {
+		return nil
+	}
+	if rval := reflect.ValueOf(val); rval.Type().AssignableTo(fv.Type()) {
+		fv.Set(rval)
+		return nil
+	}
+	result, err := convertValue(fv.Interface(), val)
+	if err != nil {
+		return err
+	}
+	fv.Set(reflect.ValueOf(result))
+	return nil
+}
+
+// convertValue converts value to the type of src, converting each element
+// if both are slices
+func convertValue(src interface{}, value interface{}) (interface{}, error) {
+	if !generic.IsSlice(src) || !generic.IsSlice(value) {
+		return generic.Convert(generic.Zero(src), value)
+	}
+	result := generic.Zero(src)
+	for i := 0; i < generic.Len(value); i++ {
+		elem, err := generic.ConvertElem(src, generic.Index(value, i))
+		if err != nil {
+			return nil, err
+		}
+		result = generic.Append(result, elem)
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type structFlagsDatabase struct {
+	Host string `cli:"host,usage=database host"`
+	Port int    `cli:"port"`
+}
+
+type structFlagsConfig struct {
+	Name     string              `cli:"name,alias=n,env=APP_NAME,required"`
+	Verbose  bool                `cli:"verbose,alias=v"`
+	Timeout  time.Duration       `cli:"timeout" usage:"timeout, in seconds or as a duration"`
+	Tags     []string            `cli:"tag"`
+	Database structFlagsDatabase `cli:"db"`
+	Parser   Parser              `cli:"parser"`
+	Ignored  string
+	Skipped  string              `cli:"-"`
+	Internal structFlagsDatabase `cli:"-"`
+}
+
+func TestFlagsFromStruct(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_NAME", "from-env")
+
+	config := &structFlagsConfig{
+		Timeout:  time.Second,
+		Tags:     []string{"default"},
+		Database: structFlagsDatabase{Host: "localhost", Port: 5432},
+	}
+	flags, err := FlagsFromStruct(config)
+	expect(t, err, nil)
+
+	var names []string
+	for _, f := range flags {
+		names = append(names, FlagNames(f)[0])
+	}
+	expect(t, names, []string{"name", "verbose", "timeout", "tag", "db.host", "db.port", "parser"})
+	expect(t, flags[2].(*DurationFlag).Usage, "timeout, in seconds or as a duration")
+	expect(t, flags[4].(*StringFlag).Usage, "database host")
+
+	err = (&App{
+		Flags:  flags,
+		Action: func(ctx *Context) error { return nil },
+	}).Run([]string{"run", "-v", "--tag", "a", "--tag", "b", "--db.port", "3306", "--parser", "x,y"})
+	expect(t, err, nil)
+
+	expect(t, config.Name, "from-env")
+	expect(t, config.Verbose, true)
+	expect(t, config.Timeout, time.Second)
+	expect(t, config.Tags, []string{"a", "b"})
+	expect(t, config.Database, structFlagsDatabase{Host: "localhost", Port: 3306})
+	expect(t, config.Parser, Parser{"x", "y"})
+}
+
+func TestFlagsFromStructErrors(t *testing.T) {
+	_, err := FlagsFromStruct(structFlagsConfig{})
+	if err == nil {
+		t.Errorf("expected error for non-pointer")
+	}
+
+	_, err = FlagsFromStruct(&struct {
+		Values map[string]string `cli:"values"`
+	}{})
+	if err == nil || !reflect.DeepEqual(err.Error(), "field Values: unsupported flag type map[string]string") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = FlagsFromStruct(&struct {
+		Value string `cli:"value,bogus"`
+	}{})
+	if err == nil {
+		t.Errorf("expected error for invalid tag option")
+	}
+}
+
+func TestContextUnmarshal(t *testing.T) {
+	type options struct {
+		Name     string  `cli:"name"`
+		Count    int64   `cli:"count"`
+		Ratio    string  `cli:"ratio"`
+		IDs      []int64 `cli:"id"`
+		Missing  string  `cli:"missing"`
+		Database struct {
+			Host string `cli:"host"`
+		} `cli:"db"`
+		Internal struct {
+			Host string `cli:"host"`
+		} `cli:"-"`
+	}
+	opts := &options{Missing: "unchanged"}
+
+	err := (&App{
+		Flags: []Flag{
+			&StringFlag{Name: "name", Value: "default"},
+			&IntFlag{Name: "count"},
+			&Float64Flag{Name: "ratio", Value: 0.5},
+			&IntSliceFlag{Name: "id"},
+			&StringFlag{Name: "db.host"},
+			&StringFlag{Name: "host"},
+		},
+		Action: func(ctx *Context) error {
+			return ctx.Unmarshal(opts)
+		},
+	}).Run([]string{"run", "--count", "3", "--id", "1", "--id", "2", "--db.host", "example.com", "--host", "x"})
+	expect(t, err, nil)
+
+	expect(t, opts.Name, "default")
+	expect(t, opts.Count, int64(3))
+	expect(t, opts.Ratio, "0.5")
+	expect(t, opts.IDs, []int64{1, 2})
+	expect(t, opts.Missing, "unchanged")
+	expect(t, opts.Database.Host, "example.com")
+	expect(t, opts.Internal.Host, "")
+}
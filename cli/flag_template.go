@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/rancher/spur/flag"
+)
+
+// resolveTemplateDefaults sets each flag with TemplateDefault which is not
+// set from its Value executed as a template against the values of the
+// flags of the context
+func resolveTemplateDefaults(flags []Flag, context *Context) error {
+	r := &templateResolver{
+		context:  context,
+		flags:    map[string]Flag{},
+		done:     map[Flag]bool{},
+		visiting: map[Flag]bool{},
+	}
+	var templateFlags []Flag
+	for _, f := range flags {
+		if templateDefault, _ := getFlagTemplateDefault(f); templateDefault {
+			templateFlags = append(templateFlags, f)
+			for _, name := range FlagNames(f) {
+				r.flags[name] = f
+			}
+		}
+	}
+	var errs []error
+	for _, f := range templateFlags {
+		if err := r.resolve(f, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// templateResolver executes template defaults in the order of their
+// references to each other
+type templateResolver struct {
+	context  *Context
+	flags    map[string]Flag // flags with TemplateDefault by each name
+	done     map[Flag]bool
+	visiting map[Flag]bool
+}
+
+// resolve executes the template default of f after those it references,
+// where path is the names of the flags referencing f
+func (r *templateResolver) resolve(f Flag, path []string) error {
+	if r.done[f] {
+		return nil
+	}
+	name := FlagNames(f)[0]
+	path = append(path, name)
+	if r.visiting[f] {
+		return fmt.Errorf("cycle in template defaults: %s", strings.Join(path, " -> "))
+	}
+	r.visiting[f] = true
+	defer func() {
+		r.visiting[f] = false
+		r.done[f] = true
+	}()
+
+	if r.context.IsSet(name) {
+		return nil
+	}
+	value, _ := getFlagValue(f)
+	text, _ := value.(string)
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template default for flag %s: %s", name, err)
+	}
+	refs := map[string]bool{}
+	if tmpl.Tree != nil {
+		templateRefs(tmpl.Tree.Root, refs)
+	}
+	for ref := range refs {
+		if dep, ok := r.flags[ref]; ok && dep != f {
+			if err := r.resolve(dep, path); err != nil {
+				return err
+			}
+		}
+	}
+	var result strings.Builder
+	if err := tmpl.Execute(&result, templateData(r.context)); err != nil {
+		return fmt.Errorf("could not execute template default for flag %s: %s", name, err)
+	}
+	fs := lookupFlagSet(name, r.context)
+	if fs == nil {
+		return nil
+	}
+	// set the value without marking the flag as set, as it is a default
+	if err := fs.Lookup(name).Value.Set(result.String()); err != nil {
+		return fmt.Errorf("could not parse template default for flag %s: %s", name, err)
+	}
+	return nil
+}
+
+// templateData returns the values of the flags of the context and its
+// parents by name, preferring those of the nearest context
+func templateData(context *Context) map[string]interface{} {
+	data := map[string]interface{}{}
+	lineage := context.Lineage()
+	for i := len(lineage) - 1; i >= 0; i-- {
+		if lineage[i].flagSet == nil {
+			continue
+		}
+		lineage[i].flagSet.VisitAll(func(ff *flag.Flag) {
+			if getter, ok := ff.Value.(flag.Getter); ok {
+				data[ff.Name] = getter.Get()
+			}
+		})
+	}
+	return data
+}
+
+// templateRefs adds the names of the flags referenced by node to refs, as
+// fields such as {{.region}} or with index such as {{index . "log-level"}}
+func templateRefs(node parse.Node, refs map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, child := range n.Nodes {
+				templateRefs(child, refs)
+			}
+		}
+	case *parse.ActionNode:
+		templateRefs(n.Pipe, refs)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, cmd := range n.Cmds {
+				templateRefs(cmd, refs)
+			}
+		}
+	case *parse.CommandNode:
+		if len(n.Args) > 2 {
+			if ident, ok := n.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "index" {
+				if s, ok := n.Args[2].(*parse.StringNode); ok {
+					refs[s.Text] = true
+				}
+			}
+		}
+		for _, arg := range n.Args {
+			templateRefs(arg, refs)
+		}
+	case *parse.FieldNode:
+		refs[n.Ident[0]] = true
+	case *parse.ChainNode:
+		templateRefs(n.Node, refs)
+	case *parse.IfNode:
+		templateRefs(&n.BranchNode, refs)
+	case *parse.RangeNode:
+		templateRefs(&n.BranchNode, refs)
+	case *parse.WithNode:
+		templateRefs(&n.BranchNode, refs)
+	case *parse.BranchNode:
+		templateRefs(n.Pipe, refs)
+		templateRefs(n.List, refs)
+		templateRefs(n.ElseList, refs)
+	case *parse.TemplateNode:
+		templateRefs(n.Pipe, refs)
+	}
+}
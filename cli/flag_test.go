@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"runtime"
@@ -200,6 +206,105 @@ func TestStringFlagDefaultText(t *testing.T) {
 	}
 }
 
+var defaultTextFlagTests = []struct {
+	flag     Flag
+	expected string
+}{
+	{&IntFlag{Name: "port", Value: 8080, DefaultText: "random"}, "--port value\t(default: random)"},
+	{&Float64Flag{Name: "ratio", Value: 0.5, DefaultText: "half"}, "--ratio value\t(default: half)"},
+	{&DurationFlag{Name: "timeout", Value: time.Second, DefaultText: "1 second"}, "--timeout value\t(default: 1 second)"},
+	{&TimeFlag{Name: "since", DefaultText: "current time"}, "--since value\t(default: current time)"},
+	{&StringSliceFlag{Name: "path", Value: []string{"/usr"}, DefaultText: "$HOME"}, "--path value\t(default: $HOME)"},
+	{&IntSliceFlag{Name: "ids", Value: []int{1, 2}, DefaultText: "none"}, "--ids value\t(default: none)"},
+}
+
+func TestFlagDefaultText(t *testing.T) {
+	for _, test := range defaultTextFlagTests {
+		output := FlagToString(test.flag)
+
+		if output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
+var timeFlagTests = []struct {
+	flag     Flag
+	expected string
+}{
+	{&TimeFlag{Name: "since"}, "--since value\t"},
+	{&TimeFlag{Name: "since", Value: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}, "--since value\t(default: 2020-01-02T03:04:05Z)"},
+	{&TimeFlag{Name: "since", Value: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Layout: "2006-01-02"}, "--since value\t(default: 2020-01-02)"},
+	{&TimeSliceFlag{Name: "at", Value: []time.Time{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC)}}, "--at value\t(default: 2020-01-02T03:04:05Z, 2021-06-07T00:00:00Z)"},
+	{&TimeSliceFlag{Name: "at", Value: []time.Time{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}, Layout: time.Kitchen}, "--at value\t(default: 3:04AM)"},
+}
+
+func TestTimeFlagHelpOutput(t *testing.T) {
+	for _, test := range timeFlagTests {
+		output := FlagToString(test.flag)
+
+		if output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
+var secretFlagTests = []struct {
+	flag     Flag
+	expected string
+}{
+	{&StringFlag{Name: "token", Value: "hunter2", Secret: true}, "--token value\t(default: ***)"},
+	{&StringFlag{Name: "token", Secret: true}, "--token value\t"},
+	{&IntFlag{Name: "pin", Value: 1234, Secret: true}, "--pin value\t(default: ***)"},
+	{&StringSliceFlag{Name: "keys", Value: []string{"a", "b"}, Secret: true}, "--keys value\t(default: ***)"},
+	{&StringFlag{Name: "token", Value: "hunter2", Secret: true, DefaultText: "from vault"}, "--token value\t(default: from vault)"},
+}
+
+func TestSecretFlagHelpOutput(t *testing.T) {
+	for _, test := range secretFlagTests {
+		output := FlagToString(test.flag)
+
+		if output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
+func TestSecretFlagApplyErrorMasksValue(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_PIN", "s3cret")
+
+	fl := &IntFlag{Name: "pin", EnvVars: []string{"APP_PIN"}, Secret: true}
+	err := fl.Apply(flag.NewFlagSet("test", 0))
+	if err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("expected masked error, got %v", err)
+	}
+}
+
+func TestSecretFlagParseErrorMasksValue(t *testing.T) {
+	for _, fl := range []Flag{
+		&IntFlag{Name: "pin", Secret: true},
+		&IntFlag{Name: "pin", Secret: true, AllowSchemes: true},
+		&StringFlag{Name: "pin", Secret: true, Choices: []string{"1234"}},
+	} {
+		var out bytes.Buffer
+		app := &App{
+			Writer:    &out,
+			ErrWriter: &out,
+			Flags:     []Flag{fl},
+			Action:    func(ctx *Context) error { return nil },
+		}
+		err := app.Run([]string{"app", "--pin", "s3cret"})
+		if err == nil || strings.Contains(err.Error(), "s3cret") || !strings.Contains(err.Error(), `invalid value "***" for flag -pin`) {
+			t.Errorf("expected masked error for %T, got %v", fl, err)
+		}
+		if strings.Contains(out.String(), "s3cret") {
+			t.Errorf("expected masked output for %T, got %q", fl, out.String())
+		}
+	}
+}
+
 func TestStringFlagWithEnvVarHelpOutput(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
@@ -515,6 +620,81 @@ func TestDurationFlagHelpOutput(t *testing.T) {
 	}
 }
 
+var humanizeDurationFlagTests = []struct {
+	flag     Flag
+	expected string
+}{
+	{&DurationFlag{Name: "timeout", Value: 90 * time.Minute, Humanize: true}, "--timeout value\t(default: 1 hour 30 minutes)"},
+	{&DurationFlag{Name: "timeout", Value: 90 * time.Minute}, "--timeout value\t(default: 1h30m0s)"},
+	{&DurationFlag{Name: "timeout", Humanize: true}, "--timeout value\t"},
+	{&DurationFlag{Name: "timeout"}, "--timeout value\t"},
+	{&DurationFlag{Name: "t"}, "-t value\t"},
+	{&DurationFlag{Name: "timeout", Value: time.Second, Humanize: true, DefaultText: "soon"}, "--timeout value\t(default: soon)"},
+}
+
+func TestHumanizeDurationFlagHelpOutput(t *testing.T) {
+	for _, test := range humanizeDurationFlagTests {
+		output := FlagToString(test.flag)
+
+		if output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
+var zeroDefaultFlagTests = []struct {
+	flag     Flag
+	expected string
+}{
+	{&IntFlag{Name: "n"}, "-n value\t"},
+	{&IntFlag{Name: "n", HasValue: true}, "-n value\t(default: 0)"},
+	{&IntFlag{Name: "n", Value: 3}, "-n value\t(default: 3)"},
+	{&Int64Flag{Name: "n"}, "-n value\t"},
+	{&Int64Flag{Name: "n", HasValue: true}, "-n value\t(default: 0)"},
+	{&UintFlag{Name: "n"}, "-n value\t"},
+	{&UintFlag{Name: "n", HasValue: true}, "-n value\t(default: 0)"},
+	{&Uint64Flag{Name: "n"}, "-n value\t"},
+	{&Uint64Flag{Name: "n", HasValue: true}, "-n value\t(default: 0)"},
+	{&Float64Flag{Name: "n"}, "-n value\t"},
+	{&Float64Flag{Name: "n", HasValue: true}, "-n value\t(default: 0)"},
+	{&DurationFlag{Name: "n"}, "-n value\t"},
+	{&DurationFlag{Name: "n", HasValue: true}, "-n value\t(default: 0s)"},
+	{&DurationFlag{Name: "n", HasValue: true, Humanize: true}, "-n value\t(default: 0 seconds)"},
+	{&StringFlag{Name: "n"}, "-n value\t"},
+	{&StringFlag{Name: "n", HasValue: true}, "-n value\t(default: \"\")"},
+	{&IntFlag{Name: "n", HasValue: true, Secret: true}, "-n value\t(default: ***)"},
+	{&IntFlag{Name: "n", DefaultText: "random"}, "-n value\t(default: random)"},
+	{&BoolFlag{Name: "n"}, "-n\t(default: false)"},
+}
+
+func TestZeroDefaultFlagHelpOutput(t *testing.T) {
+	for _, test := range zeroDefaultFlagTests {
+		output := FlagToString(test.flag)
+
+		if output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0 seconds"},
+		{time.Second, "1 second"},
+		{90 * time.Second, "1 minute 30 seconds"},
+		{26*time.Hour + time.Minute, "26 hours 1 minute"},
+		{1500 * time.Millisecond, "1 second 500 milliseconds"},
+		{time.Microsecond + 2*time.Nanosecond, "1 microsecond 2 nanoseconds"},
+		{-2 * time.Hour, "-2 hours"},
+	}
+	for _, test := range tests {
+		expect(t, HumanizeDuration(test.duration), test.expected)
+	}
+}
+
 func TestDurationFlagWithEnvVarHelpOutput(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
@@ -567,6 +747,50 @@ func TestIntSliceFlagHelpOutput(t *testing.T) {
 	}
 }
 
+func TestSliceFlagEmptyDefaultHelpOutput(t *testing.T) {
+	tests := []struct {
+		flag     Flag
+		expected string
+	}{
+		{&IntSliceFlag{Name: "ids"}, "--ids value\t"},
+		{&IntSliceFlag{Name: "ids", Value: []int{}}, "--ids value\t"},
+		{&IntSliceFlag{Name: "ids", Value: []int{1}}, "--ids value\t(default: 1)"},
+		{&IntSliceFlag{Name: "ids", Value: []int{}, DefaultText: "none"}, "--ids value\t(default: none)"},
+		{&StringSliceFlag{Name: "tag", Value: []string{""}}, "--tag value\t"},
+		{&StringSliceFlag{Name: "tag", Value: []string{}, DefaultText: "none"}, "--tag value\t(default: none)"},
+		{&TimeSliceFlag{Name: "at", Value: []time.Time{{}}}, "--at value\t"},
+	}
+	for _, test := range tests {
+		if output := FlagToString(test.flag); output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
+func TestSliceFlagFormatterHelpOutput(t *testing.T) {
+	date := func(v interface{}) string { return v.(time.Time).Format("2006-01-02") }
+	at := []time.Time{
+		time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC),
+	}
+	tests := []struct {
+		flag     Flag
+		expected string
+	}{
+		{&TimeSliceFlag{Name: "at", Value: at}, `--at value	(default: 2020-01-02T03:04:05Z, 2021-06-07T08:09:10Z)`},
+		{&TimeSliceFlag{Name: "at", Value: at, Formatter: date}, "--at value\t(default: 2020-01-02, 2021-06-07)"},
+		{&TimeSliceFlag{Name: "at", Value: at, Formatter: date, DefaultText: "soon"}, "--at value\t(default: soon)"},
+		{&TimeSliceFlag{Name: "at", Value: at, Formatter: date, Secret: true}, "--at value\t(default: " + secretMask + ")"},
+		{&IntSliceFlag{Name: "ids", Value: []int{1, 2}, Formatter: func(v interface{}) string { return fmt.Sprintf("#%d", v) }}, "--ids value\t(default: #1, #2)"},
+		{&StringSliceFlag{Name: "tag", Value: []string{"a"}}, `--tag value	(default: "a")`},
+	}
+	for _, test := range tests {
+		if output := FlagToString(test.flag); output != test.expected {
+			t.Errorf("%q does not match %q", output, test.expected)
+		}
+	}
+}
+
 func TestIntSliceFlagWithEnvVarHelpOutput(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
@@ -816,6 +1040,75 @@ func TestParseDestinationString(t *testing.T) {
 	}
 }
 
+func TestParseEmptyString(t *testing.T) {
+	tests := []struct {
+		args   []string
+		value  string
+		isSet  bool
+		source string
+	}{
+		{[]string{"run", "--name="}, "", true, "flag"},
+		{[]string{"run", "-n="}, "", true, "flag"},
+		{[]string{"run", "--name", ""}, "", true, "flag"},
+		{[]string{"run"}, "default", false, "default"},
+	}
+	for _, test := range tests {
+		var dest string
+		var value, source string
+		var isSet, aliasSet bool
+		err := (&App{
+			Flags: []Flag{
+				&StringFlag{Name: "name", Aliases: []string{"n"}, Value: "default", Destination: &dest},
+			},
+			Action: func(ctx *Context) error {
+				value = ctx.String("name")
+				isSet = ctx.IsSet("name")
+				aliasSet = ctx.IsSet("n")
+				source = ctx.Source("name")
+				return nil
+			},
+		}).Run(test.args)
+		expect(t, err, nil)
+		expect(t, value, test.value)
+		expect(t, dest, test.value)
+		expect(t, isSet, test.isSet)
+		expect(t, aliasSet, test.isSet)
+		expect(t, source, test.source)
+	}
+}
+
+func TestParseStringExpandEnv(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("REGION", "us-east")
+	os.Setenv("APP_RAW", "${REGION}-raw")
+
+	temp, err := ioutil.TempFile("", "urfave_cli_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(temp.Name())
+	io.WriteString(temp, "${REGION}-file")
+	temp.Close()
+
+	err = (&App{
+		Flags: []Flag{
+			&StringFlag{Name: "default", Value: "${REGION}-bucket${UNKNOWN}", ExpandEnv: true},
+			&StringFlag{Name: "file", FilePath: temp.Name(), ExpandEnv: true},
+			&StringFlag{Name: "env", EnvVars: []string{"APP_RAW"}, ExpandEnv: true},
+			&StringFlag{Name: "off", Value: "${REGION}"},
+		},
+		Action: func(ctx *Context) error {
+			expect(t, ctx.String("default"), "us-east-bucket")
+			expect(t, ctx.String("file"), "us-east-file")
+			expect(t, ctx.String("env"), "${REGION}-raw")
+			expect(t, ctx.String("off"), "${REGION}")
+			return nil
+		},
+	}).Run([]string{"run"})
+	expect(t, err, nil)
+}
+
 func TestParseMultiStringFromEnv(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
@@ -1205,6 +1498,30 @@ func TestParseMultiIntSlice(t *testing.T) {
 	}
 }
 
+func TestParseMultiIntSliceNArgs(t *testing.T) {
+	err := (&App{
+		Flags: []Flag{
+			&IntSliceFlag{Name: "points", Aliases: []string{"p"}, NArgs: -1},
+			&BoolFlag{Name: "verbose"},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(ctx.IntSlice("points"), []int{1, -2, 3, 4}) {
+				t.Errorf("points not set: %v", ctx.IntSlice("points"))
+			}
+			if !ctx.Bool("verbose") {
+				t.Errorf("verbose not set")
+			}
+			if !reflect.DeepEqual(ctx.Args().Slice(), []string{"arg"}) {
+				t.Errorf("unexpected args: %v", ctx.Args().Slice())
+			}
+			return nil
+		},
+	}).Run([]string{"run", "--points", "1", "-2", "3", "-p", "4", "--verbose", "--", "arg"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
 func TestParseMultiIntSliceWithDefaults(t *testing.T) {
 	err := (&App{
 		Flags: []Flag{
@@ -1357,6 +1674,88 @@ func TestParseMultiTimeSlice(t *testing.T) {
 	}
 }
 
+func TestTimeFlagKeywords(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	epoch := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	var result time.Time
+	app := &App{
+		Flags: []Flag{
+			&TimeFlag{
+				Name:     "since",
+				EnvVars:  []string{"APP_SINCE"},
+				Keywords: map[string]func() time.Time{"epoch": func() time.Time { return epoch }},
+			},
+		},
+		Action: func(ctx *Context) error {
+			result = ctx.Time("since")
+			return nil
+		},
+	}
+
+	expect(t, app.Run([]string{"run", "--since", "epoch"}), nil)
+	expect(t, result, epoch)
+	expect(t, app.Run([]string{"run", "--since", "2021-06-07T00:00:00Z"}), nil)
+	expect(t, result, time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC))
+
+	os.Setenv("APP_SINCE", "epoch")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, result, epoch)
+	os.Clearenv()
+
+	app.Flags = []Flag{&TimeFlag{Name: "since", Keywords: DefaultTimeKeywords}}
+	before := time.Now()
+	expect(t, app.Run([]string{"run", "--since", "now"}), nil)
+	if result.Before(before) || result.After(time.Now()) {
+		t.Errorf("expected now, got %v", result)
+	}
+	expect(t, app.Run([]string{"run", "--since", "today"}), nil)
+	expect(t, result.Equal(startOfDay(time.Now())), true)
+	expect(t, app.Run([]string{"run", "--since", "yesterday"}), nil)
+	expect(t, result.Equal(startOfDay(time.Now()).AddDate(0, 0, -1)), true)
+
+	app.Flags = []Flag{&TimeFlag{Name: "since"}}
+	app.Writer, app.ErrWriter = ioutil.Discard, ioutil.Discard
+	if err := app.Run([]string{"run", "--since", "now"}); err == nil {
+		t.Error("expected keywords to be opt-in")
+	}
+}
+
+func TestParseTimeSliceLayout(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_DATES", "2020-01-02,2021-06-07")
+
+	layout := "2006-01-02"
+	dates := []time.Time{
+		time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 7, 0, 0, 0, 0, time.UTC),
+	}
+	var result []time.Time
+	app := &App{
+		Flags: []Flag{
+			&TimeSliceFlag{Name: "date", Layout: layout, EnvVars: []string{"APP_DATES"}},
+		},
+		Action: func(ctx *Context) error {
+			result = ctx.TimeSlice("date")
+			return nil
+		},
+	}
+
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, result, dates)
+
+	expect(t, app.Run([]string{"run", "--date", "2020-01-02", "--date", "2021-06-07T00:00:00Z"}), nil)
+	expect(t, result, dates)
+
+	os.Setenv("APP_DATES", "2020-01-02,bogus")
+	err := app.Run([]string{"run"})
+	if err == nil || !strings.Contains(err.Error(), `invalid element "bogus"`) {
+		t.Errorf("expected error naming the invalid element, got %v", err)
+	}
+}
+
 func TestParseMultiInt64SliceFromEnv(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
@@ -1663,6 +2062,9 @@ func TestParseBoolFromEnv(t *testing.T) {
 		{"1", true},
 		{"false", false},
 		{"true", true},
+		{"yes", true},
+		{"No", false},
+		{"on", true},
 	}
 
 	for _, test := range boolFlagTests {
@@ -1689,274 +2091,1955 @@ func TestParseBoolFromEnv(t *testing.T) {
 	}
 }
 
-func TestParseMultiBoolT(t *testing.T) {
-	err := (&App{
+func TestSliceFlagAppend(t *testing.T) {
+	tests := []struct {
+		env     string
+		args    []string
+		replace []int
+		append  []float64
+	}{
+		{replace: []int{1, 2}, append: []float64{1, 2}},
+		{args: []string{"--n", "3"}, replace: []int{3}, append: []float64{1, 2, 3}},
+		{args: []string{"--n", "3", "--n", "4", "--n", "5"}, replace: []int{3, 4, 5}, append: []float64{1, 2, 3, 4, 5}},
+		{env: "6,7", replace: []int{6, 7}, append: []float64{1, 2, 6, 7}},
+		{env: "6,7", args: []string{"--n", "3"}, replace: []int{3}, append: []float64{1, 2, 3}},
+	}
+	for _, test := range tests {
+		func() {
+			defer resetEnv(os.Environ())
+			os.Clearenv()
+			if test.env != "" {
+				os.Setenv("APP_N", test.env)
+			}
+			var replaced []int
+			var appended, dest []float64
+			run := func(flag Flag, action func(*Context)) {
+				err := (&App{
+					Flags: []Flag{flag},
+					Action: func(ctx *Context) error {
+						action(ctx)
+						return nil
+					},
+				}).Run(append([]string{"run"}, test.args...))
+				expect(t, err, nil)
+			}
+
+			run(&IntSliceFlag{Name: "n", EnvVars: []string{"APP_N"}, Value: []int{1, 2}}, func(ctx *Context) {
+				replaced = ctx.IntSlice("n")
+			})
+			expect(t, replaced, test.replace)
+
+			value := []float64{1, 2}
+			run(&Float64SliceFlag{Name: "n", EnvVars: []string{"APP_N"}, Value: value, Destination: &dest, Append: true}, func(ctx *Context) {
+				appended = ctx.Float64Slice("n")
+			})
+			expect(t, appended, test.append)
+			expect(t, dest, test.append)
+			expect(t, value, []float64{1, 2})
+		}()
+	}
+}
+
+func TestSliceFlagResetToken(t *testing.T) {
+	tests := []struct {
+		args    []string
+		replace []string
+		append  []string
+	}{
+		{args: nil, replace: []string{"a", "b"}, append: []string{"a", "b"}},
+		{args: []string{"--tag", "c"}, replace: []string{"c"}, append: []string{"a", "b", "c"}},
+		{args: []string{"--tag", "-", "--tag", "c"}, replace: []string{"c"}, append: []string{"c"}},
+		{args: []string{"--tag", "c", "--tag", "-", "--tag", "d", "--tag", "e"}, replace: []string{"d", "e"}, append: []string{"d", "e"}},
+		{args: []string{"--tag", "c", "--tag", "-"}, replace: []string{}, append: []string{}},
+	}
+	for _, test := range tests {
+		var replaced, appended, dest []string
+		for _, f := range []*StringSliceFlag{
+			{Name: "tag", Value: []string{"a", "b"}, ResetToken: "-"},
+			{Name: "tag", Value: []string{"a", "b"}, ResetToken: "-", Append: true, Destination: &dest},
+		} {
+			var tags []string
+			err := (&App{
+				Flags: []Flag{f},
+				Action: func(ctx *Context) error {
+					tags = ctx.StringSlice("tag")
+					return nil
+				},
+			}).Run(append([]string{"run"}, test.args...))
+			expect(t, err, nil)
+			if f.Append {
+				appended = tags
+			} else {
+				replaced = tags
+			}
+		}
+		expect(t, replaced, test.replace)
+		expect(t, appended, test.append)
+		expect(t, dest, test.append)
+	}
+}
+
+func TestSliceFlagEmptyEnvClears(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	var hosts []string
+	var ports []int
+	var isSet bool
+	app := &App{
 		Flags: []Flag{
-			&BoolFlag{Name: "implode", Aliases: []string{"i"}, Value: true},
+			&StringSliceFlag{Name: "hosts", EnvVars: []string{"APP_HOSTS"}, Value: []string{"localhost"}, EmptyEnvClears: true},
+			&IntSliceFlag{Name: "ports", EnvVars: []string{"APP_PORTS"}, Value: []int{80}, EmptyEnvClears: true, EmptyEnvValue: "NO_VALUE"},
 		},
 		Action: func(ctx *Context) error {
-			if ctx.Bool("implode") {
-				t.Errorf("main name not set")
-			}
-			if ctx.Bool("i") {
-				t.Errorf("short name not set")
-			}
+			hosts = ctx.StringSlice("hosts")
+			ports = ctx.IntSlice("ports")
+			isSet = ctx.IsSet("hosts")
 			return nil
 		},
-	}).Run([]string{"run", "--implode=false"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
 	}
-}
 
-type Parser [2]string
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, hosts, []string{"localhost"})
+	expect(t, ports, []int{80})
+	expect(t, isSet, false)
 
-func (p *Parser) Set(value interface{}) error {
-	parts := strings.Split(value.(string), ",")
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid format")
-	}
+	os.Setenv("APP_HOSTS", "")
+	os.Setenv("APP_PORTS", "NO_VALUE")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, hosts, []string{})
+	expect(t, ports, []int{})
+	expect(t, isSet, true)
 
-	(*p)[0] = parts[0]
-	(*p)[1] = parts[1]
+	expect(t, app.Run([]string{"run", "--hosts", "a", "--ports", "1"}), nil)
+	expect(t, hosts, []string{"a"})
+	expect(t, ports, []int{1})
 
-	return nil
-}
+	os.Setenv("APP_HOSTS", "a,b")
+	os.Setenv("APP_PORTS", "")
+	err := app.Run([]string{"run"})
+	if err == nil || !strings.HasPrefix(err.Error(), `could not parse "" as int slice value for flag ports`) {
+		t.Errorf("expected parse error for empty value, got %v", err)
+	}
 
-func (p *Parser) String() string {
-	return fmt.Sprintf("%s,%s", p[0], p[1])
+	// without EmptyEnvClears an empty value is parsed as a single element
+	os.Setenv("APP_HOSTS", "")
+	app.Flags = []Flag{&StringSliceFlag{Name: "hosts", EnvVars: []string{"APP_HOSTS"}, Value: []string{"localhost"}}}
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, hosts, []string{""})
 }
 
-func (p *Parser) Get() interface{} {
-	return p
-}
+func TestSliceFlagNewlineSeparated(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
 
-func TestParseGeneric(t *testing.T) {
-	err := (&App{
+	var hosts, tags []string
+	var ports []int
+	app := &App{
 		Flags: []Flag{
-			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Value: &Parser{}},
+			&StringSliceFlag{Name: "hosts", EnvVars: []string{"APP_HOSTS"}, Value: []string{"localhost"}, NewlineSeparated: true},
+			&IntSliceFlag{Name: "ports", EnvVars: []string{"APP_PORTS"}, NewlineSeparated: true},
+			&StringSetFlag{Name: "tags", EnvVars: []string{"APP_TAGS"}, NewlineSeparated: true},
 		},
 		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(ctx.Generic("serve"), &Parser{"10", "20"}) {
-				t.Errorf("main name not set")
-			}
-			if !reflect.DeepEqual(ctx.Generic("s"), &Parser{"10", "20"}) {
-				t.Errorf("short name not set")
-			}
+			hosts = ctx.StringSlice("hosts")
+			ports = ctx.IntSlice("ports")
+			tags = ctx.StringSet("tags")
 			return nil
 		},
-	}).Run([]string{"run", "-s", "10,20"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
 	}
-}
 
-func TestParseGenericNoFlags(t *testing.T) {
-	err := (&App{
-		Flags: []Flag{
-			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Value: &Parser{"hello", "world"}},
-		},
-		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(ctx.Generic("serve"), &Parser{"hello", "world"}) {
-				t.Errorf("main name not set")
-			}
-			if !reflect.DeepEqual(ctx.Generic("s"), &Parser{"hello", "world"}) {
-				t.Errorf("short name not set")
-			}
-			return nil
-		},
-	}).Run([]string{"run"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
-	}
+	os.Setenv("APP_HOSTS", "a\nb,c\nd\\\ne")
+	os.Setenv("APP_PORTS", "  80\r\n\n443\n  \n")
+	os.Setenv("APP_TAGS", "x,y\nz\nx,y")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, hosts, []string{"a", "b,c", "d\\", "e"})
+	expect(t, ports, []int{80, 443})
+	expect(t, tags, []string{"x,y", "z"})
+
+	// the command line is not split on newlines
+	expect(t, app.Run([]string{"run", "--hosts", "a\nb"}), nil)
+	expect(t, hosts, []string{"a\nb"})
+
+	os.Setenv("APP_HOSTS", "\n \n")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, hosts, []string{})
+
+	// a comma in a line is not a separator
+	os.Setenv("APP_PORTS", "443,8080")
+	expect(t, app.Run([]string{"run"}) != nil, true)
 }
 
-func TestParseGenericDestination(t *testing.T) {
-	val := &Parser{"hello", "world"}
-	dest := &Parser{}
-	err := (&App{
+func TestSliceFlagCSVEnv(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	var args []string
+	app := &App{
 		Flags: []Flag{
-			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Value: val, Destination: dest},
+			&StringSliceFlag{Name: "args", EnvVars: []string{"APP_ARGS"}, CSVEnv: true},
 		},
 		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(dest, &Parser{"10", "20"}) {
-				t.Errorf("destination not set")
-			}
-			if !reflect.DeepEqual(val, &Parser{"hello", "world"}) {
-				t.Errorf("value was set")
-			}
+			args = ctx.StringSlice("args")
 			return nil
 		},
-	}).Run([]string{"run", "-s", "10,20"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
 	}
-}
 
-func TestParseGenericDestinationNoValue(t *testing.T) {
+	tests := []struct {
+		env    string
+		expect []string
+	}{
+		{`"a b","c d"`, []string{"a b", "c d"}},
+		{`"a b", "c d"`, []string{"a b", "c d"}},
+		{`"a, b",c`, []string{"a, b", "c"}},
+		{`"say ""hi""",x`, []string{`say "hi"`, "x"}},
+		{`a,,b`, []string{"a", "", "b"}},
+		{`a,""`, []string{"a", ""}},
+		{`a\,b`, []string{`a\`, "b"}},
+	}
+	for _, test := range tests {
+		os.Setenv("APP_ARGS", test.env)
+		expect(t, app.Run([]string{"run"}), nil)
+		expect(t, args, test.expect)
+	}
+
+	// the command line is not parsed as CSV
+	expect(t, app.Run([]string{"run", "--args", `"a b"`}), nil)
+	expect(t, args, []string{`"a b"`})
+
+	os.Setenv("APP_ARGS", `"a b,c`)
+	if err := app.Run([]string{"run"}); err == nil {
+		t.Error("expected an error for an unterminated quote")
+	}
+}
+
+func TestSliceFlagItems(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	newApp := func() *App {
+		return &App{
+			Writer: ioutil.Discard,
+			Flags: []Flag{
+				&StringSliceFlag{Name: "tag", EnvVars: []string{"APP_TAGS"}, MaxItems: 3},
+				&StringSetFlag{Name: "zone", MinItems: 1, MaxItems: 2, Value: NewStringSet("a")},
+				&IntSliceFlag{Name: "port", MinItems: 2},
+			},
+			Action: func(ctx *Context) error { return nil },
+		}
+	}
+
+	err := newApp().Run([]string{"run", "--port", "80", "--port", "443"})
+	expect(t, err, nil)
+
+	// duplicates in a set are only counted once
+	err = newApp().Run([]string{"run", "--port", "80", "--port", "443", "--zone", "b", "--zone", "b"})
+	expect(t, err, nil)
+
+	os.Setenv("APP_TAGS", "a,b,c,d")
+	err = newApp().Run([]string{"run", "--port", "80", "--port", "443"})
+	expect(t, err.Error(), "--tag accepts at most 3 values, got 4")
+	os.Unsetenv("APP_TAGS")
+
+	err = newApp().Run([]string{"run", "--port", "80", "--zone", "b,c,d"})
+	expect(t, err.Error(), "2 errors occurred:\n  * --zone accepts at most 2 values, got 3\n  * --port requires at least 2 values, got 1")
+
+	err = newApp().Validate([]string{"run", "--port", "80", "--port", "443", "--tag", "a", "--tag", "b", "--tag", "c", "--tag", "d"})
+	expect(t, err.Error(), "--tag accepts at most 3 values, got 4")
+}
+
+func TestParseBoolSliceFromEnv(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_X", "yes,no,1,0,On,off,true,F")
+	err := (&App{
+		Flags: []Flag{
+			&BoolSliceFlag{Name: "x", EnvVars: []string{"APP_X"}},
+		},
+		Action: func(ctx *Context) error {
+			expect(t, ctx.BoolSlice("x"), []bool{true, false, true, false, true, false, true, false})
+			return nil
+		},
+	}).Run([]string{"run"})
+	expect(t, err, nil)
+
+	err = (&App{
+		Flags: []Flag{
+			&BoolSliceFlag{Name: "x"},
+		},
+		Action: func(ctx *Context) error {
+			expect(t, ctx.BoolSlice("x"), []bool{true, false})
+			return nil
+		},
+	}).Run([]string{"run", "--x=yes", "--x=off"})
+	expect(t, err, nil)
+}
+
+func TestDeadlineFlag(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	absolute := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := func(flag *DeadlineFlag, args ...string) (time.Time, error) {
+		var deadline time.Time
+		err := (&App{
+			Writer: ioutil.Discard,
+			Flags:  []Flag{flag},
+			Action: func(ctx *Context) error {
+				deadline = ctx.Deadline("deadline")
+				return nil
+			},
+		}).Run(append([]string{"run"}, args...))
+		return deadline, err
+	}
+	expectRelative := func(deadline time.Time, start time.Time, d time.Duration) {
+		if deadline.Before(start.Add(d)) || deadline.After(time.Now().Add(d)) {
+			t.Errorf("expected deadline %s from %s, got %s", d, start, deadline)
+		}
+	}
+
+	start := time.Now()
+	deadline, err := run(&DeadlineFlag{Name: "deadline"}, "--deadline", "30s")
+	expect(t, err, nil)
+	expectRelative(deadline, start, 30*time.Second)
+
+	deadline, err = run(&DeadlineFlag{Name: "deadline"}, "--deadline", "2025-01-01T00:00:00Z")
+	expect(t, err, nil)
+	expect(t, deadline.Equal(absolute), true)
+
+	start = time.Now()
+	deadline, err = run(&DeadlineFlag{Name: "deadline", Value: "1h"})
+	expect(t, err, nil)
+	expectRelative(deadline, start, time.Hour)
+
+	os.Setenv("APP_DEADLINE", "2025-01-01T00:00:00Z")
+	var dest time.Time
+	deadline, err = run(&DeadlineFlag{Name: "deadline", Value: "1h", EnvVars: []string{"APP_DEADLINE"}, Destination: &dest})
+	expect(t, err, nil)
+	expect(t, deadline.Equal(absolute), true)
+	expect(t, dest.Equal(absolute), true)
+
+	deadline, err = run(&DeadlineFlag{Name: "deadline"})
+	expect(t, err, nil)
+	expect(t, deadline.IsZero(), true)
+
+	_, err = run(&DeadlineFlag{Name: "deadline"}, "--deadline", "soon")
+	if err == nil || !strings.Contains(err.Error(), "expected a duration or timestamp") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+	_, err = run(&DeadlineFlag{Name: "deadline", Value: "soon"})
+	if err == nil || !strings.Contains(err.Error(), `could not parse "soon" as deadline value for flag deadline`) {
+		t.Errorf("expected default parse error, got %v", err)
+	}
+}
+
+func TestPathFlag(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	dir, err := ioutil.TempDir("", "spur-path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOME", dir)
+	os.Setenv("APP_DIR", dir)
+
+	run := func(flag *PathFlag, args ...string) (string, error) {
+		var path string
+		err := (&App{
+			Writer:    ioutil.Discard,
+			ErrWriter: ioutil.Discard,
+			Flags:     []Flag{flag},
+			Action: func(ctx *Context) error {
+				path = ctx.Path("path")
+				return nil
+			},
+		}).Run(append([]string{"run"}, args...))
+		return path, err
+	}
+
+	path, err := run(&PathFlag{Name: "path"}, "--path", "~/config.yaml")
+	expect(t, err, nil)
+	expect(t, path, "~/config.yaml")
+
+	var dest string
+	path, err = run(&PathFlag{Name: "path", Expand: true, Destination: &dest}, "--path", "~/config.yaml")
+	expect(t, err, nil)
+	expect(t, path, file)
+	expect(t, dest, file)
+
+	path, err = run(&PathFlag{Name: "path", Expand: true, Value: "${APP_DIR}/new"})
+	expect(t, err, nil)
+	expect(t, path, filepath.Join(dir, "new"))
+
+	_, err = run(&PathFlag{Name: "path", MustExist: true}, "--path", filepath.Join(dir, "missing"))
+	expect(t, err.Error(), fmt.Sprintf("path %q does not exist", filepath.Join(dir, "missing")))
+
+	_, err = run(&PathFlag{Name: "path", MustBeDir: true}, "--path", file)
+	expect(t, err.Error(), fmt.Sprintf("path %q is not a directory", file))
+
+	_, err = run(&PathFlag{Name: "path", MustBeFile: true}, "--path", dir)
+	expect(t, err.Error(), fmt.Sprintf("path %q is not a file", dir))
+
+	_, err = run(&PathFlag{Name: "path", MustBeFile: true, Expand: true}, "--path", "~/config.yaml")
+	expect(t, err, nil)
+	_, err = run(&PathFlag{Name: "path", MustExist: true})
+	expect(t, err, nil)
+}
+
+func TestBytesFlag(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	run := func(flag *BytesFlag, args ...string) ([]byte, error) {
+		var result []byte
+		err := (&App{
+			Writer:    ioutil.Discard,
+			ErrWriter: ioutil.Discard,
+			Flags:     []Flag{flag},
+			Action: func(ctx *Context) error {
+				result = ctx.Bytes("key")
+				return nil
+			},
+		}).Run(append([]string{"run"}, args...))
+		return result, err
+	}
+
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	result, err := run(&BytesFlag{Name: "key"}, "--key", "abc")
+	expect(t, err, nil)
+	expect(t, result, []byte("abc"))
+
+	result, err = run(&BytesFlag{Name: "key", Encoding: BytesEncodingHex}, "--key", "deadbeef")
+	expect(t, err, nil)
+	expect(t, result, key)
+
+	for _, s := range []string{"3q2+7w==", "3q2-7w", "3q2+7w"} {
+		result, err = run(&BytesFlag{Name: "key", Encoding: BytesEncodingBase64}, "--key", s)
+		expect(t, err, nil)
+		expect(t, result, key)
+	}
+
+	os.Setenv("APP_KEY", "3q2+7w==")
+	var dest []byte
+	result, err = run(&BytesFlag{Name: "key", Encoding: BytesEncodingBase64, EnvVars: []string{"APP_KEY"}, Destination: &dest})
+	expect(t, err, nil)
+	expect(t, result, key)
+	expect(t, dest, key)
+	os.Clearenv()
+
+	result, err = run(&BytesFlag{Name: "key", Encoding: BytesEncodingHex, Value: "beef"})
+	expect(t, err, nil)
+	expect(t, result, []byte{0xbe, 0xef})
+
+	result, err = run(&BytesFlag{Name: "key"})
+	expect(t, err, nil)
+	expect(t, result, []byte(nil))
+
+	_, err = run(&BytesFlag{Name: "key", Encoding: BytesEncodingHex}, "--key", "xyz")
+	if err == nil || !strings.Contains(err.Error(), `invalid value "xyz" for flag -key: invalid hex value`) {
+		t.Errorf("expected a hex error, got %v", err)
+	}
+	os.Setenv("APP_KEY", "!!")
+	_, err = run(&BytesFlag{Name: "key", Encoding: BytesEncodingBase64, EnvVars: []string{"APP_KEY"}})
+	if err == nil || !strings.Contains(err.Error(), `could not parse "!!" as bytes value for flag key: invalid base64 value`) {
+		t.Errorf("expected a base64 error, got %v", err)
+	}
+	_, err = run(&BytesFlag{Name: "key", Encoding: "base32"})
+	expect(t, err.Error(), `unknown encoding "base32" for flag key`)
+
+	expect(t, FlagToString(&BytesFlag{Name: "key", Encoding: BytesEncodingHex, Value: "beef"}), "--key value\t(default: \"beef\")")
+}
+
+func TestIPNetSliceFlag(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	run := func(flag *IPNetSliceFlag, args ...string) ([]string, error) {
+		var cidrs []string
+		err := (&App{
+			Writer:    ioutil.Discard,
+			ErrWriter: ioutil.Discard,
+			Flags:     []Flag{flag},
+			Action: func(ctx *Context) error {
+				for _, ipNet := range ctx.IPNetSlice("allow") {
+					cidrs = append(cidrs, ipNet.String())
+				}
+				return nil
+			},
+		}).Run(append([]string{"run"}, args...))
+		return cidrs, err
+	}
+
+	cidrs, err := run(&IPNetSliceFlag{Name: "allow"}, "--allow", "10.0.0.0/8,192.168.0.0/16", "--allow", "fd00::/8")
+	expect(t, err, nil)
+	expect(t, cidrs, []string{"10.0.0.0/8", "192.168.0.0/16", "fd00::/8"})
+
+	cidrs, err = run(&IPNetSliceFlag{Name: "allow", Value: []string{"127.0.0.0/8"}}, "--allow", "10.1.2.3/16")
+	expect(t, err, nil)
+	expect(t, cidrs, []string{"10.1.0.0/16"})
+
+	os.Setenv("APP_ALLOW", "10.0.0.0/8, 172.16.0.0/12")
+	var dest []*net.IPNet
+	cidrs, err = run(&IPNetSliceFlag{Name: "allow", Value: []string{"127.0.0.0/8"}, EnvVars: []string{"APP_ALLOW"}, Destination: &dest})
+	expect(t, err, nil)
+	expect(t, cidrs, []string{"10.0.0.0/8", "172.16.0.0/12"})
+	expect(t, len(dest), 2)
+
+	os.Setenv("APP_ALLOW", "10.0.0.0/8,10.0.0/8")
+	_, err = run(&IPNetSliceFlag{Name: "allow", EnvVars: []string{"APP_ALLOW"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid CIDR address: 10.0.0/8") {
+		t.Errorf("expected an error naming the invalid CIDR, got %v", err)
+	}
+	_, err = run(&IPNetSliceFlag{Name: "allow"}, "--allow", "10.0.0.0/8,bogus")
+	if err == nil || !strings.Contains(err.Error(), "invalid CIDR address: bogus") {
+		t.Errorf("expected an error naming the invalid CIDR, got %v", err)
+	}
+	_, err = run(&IPNetSliceFlag{Name: "allow", Value: []string{"x"}})
+	if err == nil || !strings.Contains(err.Error(), `could not parse "x" as ipnet slice value for flag allow`) {
+		t.Errorf("expected a default parse error, got %v", err)
+	}
+
+	expect(t, FlagToString(&IPNetSliceFlag{Name: "allow", Value: []string{"10.0.0.0/8", "fd00::/8"}}), `--allow value	(default: "10.0.0.0/8", "fd00::/8")`)
+}
+
+func TestStringMapFlag(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	run := func(flag *StringMapFlag, args ...string) (map[string]string, error) {
+		var labels map[string]string
+		err := (&App{
+			Writer:    ioutil.Discard,
+			ErrWriter: ioutil.Discard,
+			Flags:     []Flag{flag},
+			Action: func(ctx *Context) error {
+				labels = ctx.StringMap("label")
+				// the map returned is a copy which does not change the flag
+				if m := ctx.StringMap("label"); m != nil {
+					m["mutated"] = "true"
+				}
+				return nil
+			},
+		}).Run(append([]string{"run"}, args...))
+		return labels, err
+	}
+
+	labels, err := run(&StringMapFlag{Name: "label"}, "--label", "env=prod,team=web", "--label", "tier=a=b")
+	expect(t, err, nil)
+	expect(t, labels, map[string]string{"env": "prod", "team": "web", "tier": "a=b"})
+
+	defaults := []string{"env=dev"}
+	labels, err = run(&StringMapFlag{Name: "label", Value: defaults})
+	expect(t, err, nil)
+	expect(t, labels, map[string]string{"env": "dev"})
+
+	var dest map[string]string
+	labels, err = run(&StringMapFlag{Name: "label", Value: defaults, Destination: &dest}, "--label", "team=web")
+	expect(t, err, nil)
+	expect(t, labels, map[string]string{"team": "web"})
+	expect(t, dest, map[string]string{"team": "web"})
+
+	os.Setenv("APP_LABELS", "env=prod, team=web")
+	labels, err = run(&StringMapFlag{Name: "label", Value: defaults, EnvVars: []string{"APP_LABELS"}})
+	expect(t, err, nil)
+	expect(t, labels, map[string]string{"env": "prod", "team": "web"})
+
+	labels, err = run(&StringMapFlag{Name: "other"})
+	expect(t, err, nil)
+	expect(t, labels, map[string]string(nil))
+
+	_, err = run(&StringMapFlag{Name: "label"}, "--label", "env=prod,team")
+	if err == nil || !strings.Contains(err.Error(), `expected key=value, got "team"`) {
+		t.Errorf("expected an error naming the invalid pair, got %v", err)
+	}
+
+	expect(t, FlagToString(&StringMapFlag{Name: "label", Value: []string{"b=2", "a=1"}}), `--label value	(default: "b=2", "a=1")`)
+}
+
+func TestWrapperFlagCommonFields(t *testing.T) {
+	derive := func(value string) func(ctx *Context) (interface{}, error) {
+		return func(ctx *Context) (interface{}, error) {
+			return value, nil
+		}
+	}
+	tests := []struct {
+		flag     func(positional int, derive func(ctx *Context) (interface{}, error)) Flag
+		arg      string
+		expected string
+	}{
+		{func(p int, d func(*Context) (interface{}, error)) Flag {
+			return &PathFlag{Name: "v", PositionalAlias: p, Derive: d}
+		}, "/tmp", "/tmp"},
+		{func(p int, d func(*Context) (interface{}, error)) Flag {
+			return &BytesFlag{Name: "v", PositionalAlias: p, Derive: d, Encoding: BytesEncodingHex}
+		}, "cafe", "cafe"},
+		{func(p int, d func(*Context) (interface{}, error)) Flag {
+			return &IPNetSliceFlag{Name: "v", PositionalAlias: p, Derive: d}
+		}, "10.0.0.0/8", "10.0.0.0/8"},
+		{func(p int, d func(*Context) (interface{}, error)) Flag {
+			return &StringMapFlag{Name: "v", PositionalAlias: p, Derive: d}
+		}, "a=b", "a=b"},
+		{func(p int, d func(*Context) (interface{}, error)) Flag {
+			return &StringSetFlag{Name: "v", PositionalAlias: p, Derive: d}
+		}, "a,b,a", "a,b"},
+		{func(p int, d func(*Context) (interface{}, error)) Flag {
+			return &DeadlineFlag{Name: "v", PositionalAlias: p, Derive: d}
+		}, "2020-01-02T03:04:05Z", "2020-01-02T03:04:05Z"},
+		{func(p int, d func(*Context) (interface{}, error)) Flag {
+			return &JSONFlag{Name: "v", PositionalAlias: p, Derive: d}
+		}, `{"a":1}`, `{"a":1}`},
+	}
+	for _, test := range tests {
+		for _, fl := range []Flag{test.flag(1, nil), test.flag(0, derive(test.arg))} {
+			var value string
+			err := (&App{
+				Writer: ioutil.Discard,
+				Flags:  []Flag{fl},
+				Action: func(ctx *Context) error {
+					value = lookupFlagSet("v", ctx).Lookup("v").Value.String()
+					return nil
+				},
+			}).Run([]string{"app", test.arg})
+			expect(t, err, nil)
+			if value != test.expected {
+				t.Errorf("%T: expected %q, got %q", fl, test.expected, value)
+			}
+		}
+	}
+
+	upper := func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) }
+	expect(t, FlagToString(&IPNetSliceFlag{Name: "allow", Value: []string{"fd00::/8"}, Formatter: upper}), `--allow value	(default: FD00::/8)`)
+	expect(t, FlagToString(&StringMapFlag{Name: "label", Value: []string{"a=b"}, Formatter: upper}), `--label value	(default: A=B)`)
+
+	run := func(fl Flag, args ...string) error {
+		return (&App{
+			Writer:    ioutil.Discard,
+			ErrWriter: ioutil.Discard,
+			Flags:     []Flag{fl},
+			Action:    func(ctx *Context) error { return nil },
+		}).Run(append([]string{"app"}, args...))
+	}
+	err := run(&IPNetSliceFlag{Name: "allow", Unique: true}, "--allow", "10.0.0.0/8", "--allow", "10.0.0.0/8")
+	if err == nil || !strings.Contains(err.Error(), "--allow has the duplicate value 10.0.0.0/8") {
+		t.Errorf("expected a duplicate value error, got %v", err)
+	}
+	err = run(&StringMapFlag{Name: "label", Value: []string{"a=1"}, Unique: true}, "--label", "a=2,b=1", "--label", "b=2")
+	if err == nil || !strings.Contains(err.Error(), `duplicate key "b"`) {
+		t.Errorf("expected a duplicate key error, got %v", err)
+	}
+	expect(t, run(&StringMapFlag{Name: "label", Unique: true}, "--label", "a=1", "--label", "b=2"), nil)
+	err = run(&StringMapFlag{Name: "label", MaxItems: 1}, "--label", "a=1,b=2")
+	if err == nil || !strings.Contains(err.Error(), "--label accepts at most 1 values, got 2") {
+		t.Errorf("expected a max items error, got %v", err)
+	}
+}
+
+func TestFlagValueSyntaxEquivalence(t *testing.T) {
+	defer resetEnv(os.Environ())
+
+	// each value is given as --value arg and --value=arg, and joined with
+	// commas in the environment
+	tests := []struct {
+		typ  string
+		args []string
+	}{
+		{"bool", []string{"true"}},
+		{"bool slice", []string{"true", "false"}},
+		{"duration", []string{"1h30m"}},
+		{"duration slice", []string{"1s", "2m"}},
+		{"float64", []string{"-1.5"}},
+		{"float64 slice", []string{"1.5", "-2"}},
+		{"int", []string{"-3"}},
+		{"int slice", []string{"1", "-2", "3"}},
+		{"int64", []string{"-4"}},
+		{"int64 slice", []string{"4", "-5"}},
+		{"string", []string{"a=b c"}},
+		{"string slice", []string{"a", "b=c", "-d"}},
+		{"string set", []string{"a", "b"}},
+		{"time", []string{"2020-01-02T03:04:05Z"}},
+		{"time slice", []string{"2020-01-02T03:04:05Z", "2021-06-07T08:09:10Z"}},
+		{"uint", []string{"5"}},
+		{"uint slice", []string{"5", "6"}},
+		{"uint64", []string{"7"}},
+		{"uint64 slice", []string{"7", "8"}},
+		{"deadline", []string{"2030-01-02T03:04:05Z"}},
+		{"path", []string{"dir/file"}},
+		{"bytes", []string{"raw"}},
+		{"ipnet slice", []string{"10.0.0.0/8", "fd00::/8"}},
+		{"string map", []string{"a=1", "b=2"}},
+	}
+	for _, test := range tests {
+		run := func(args ...string) interface{} {
+			f, err := NewFlag(test.typ, "value")
+			if err != nil {
+				t.Fatal(err)
+			}
+			flagValue(f).FieldByName("EnvVars").Set(reflect.ValueOf([]string{"APP_VALUE"}))
+			var result interface{}
+			err = (&App{
+				Writer:    ioutil.Discard,
+				ErrWriter: ioutil.Discard,
+				// bools are given as --value true
+				BoolFlagWords: true,
+				Flags:         []Flag{f},
+				Action: func(ctx *Context) error {
+					result = ctx.Value("value")
+					return nil
+				},
+			}).Run(append([]string{"run"}, args...))
+			if err != nil {
+				t.Errorf("%s %q: %s", test.typ, args, err)
+			}
+			return result
+		}
+
+		var separate, joined []string
+		for _, arg := range test.args {
+			separate = append(separate, "--value", arg)
+			joined = append(joined, "--value="+arg)
+		}
+		os.Clearenv()
+		separateValue := run(separate...)
+		joinedValue := run(joined...)
+		os.Setenv("APP_VALUE", strings.Join(test.args, ","))
+		envValue := run()
+		if !reflect.DeepEqual(separateValue, joinedValue) {
+			t.Errorf("%s: %q is %#v but %q is %#v", test.typ, separate, separateValue, joined, joinedValue)
+		}
+		if !reflect.DeepEqual(separateValue, envValue) {
+			t.Errorf("%s: %q is %#v but APP_VALUE=%q is %#v", test.typ, separate, separateValue, os.Getenv("APP_VALUE"), envValue)
+		}
+	}
+	for typeName := range flagConstructors {
+		found := false
+		for _, test := range tests {
+			found = found || test.typ == typeName
+		}
+		if !found {
+			t.Errorf("flag type %q is not tested", typeName)
+		}
+	}
+}
+
+func TestFlagAllowSchemes(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	dir, err := ioutil.TempDir("", "spur-schemes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(path, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("APP_COUNT", "3")
+	os.Setenv("APP_TAG", "from-env")
+
+	var password, url, literal string
+	var count int
+	var tags []string
+	app := &App{
+		Writer:    ioutil.Discard,
+		ErrWriter: ioutil.Discard,
+		Flags: []Flag{
+			&StringFlag{Name: "password", AllowSchemes: true, EnvVars: []string{"APP_PASSWORD"}},
+			&IntFlag{Name: "count", AllowSchemes: true},
+			&StringSliceFlag{Name: "tag", AllowSchemes: true},
+			&StringFlag{Name: "url", AllowSchemes: true},
+			&StringFlag{Name: "literal"},
+		},
+		Action: func(ctx *Context) error {
+			password, count, tags = ctx.String("password"), ctx.Int("count"), ctx.StringSlice("tag")
+			url, literal = ctx.String("url"), ctx.String("literal")
+			return nil
+		},
+	}
+
+	err = app.Run([]string{"app", "--password", "file://" + path, "--count=env://APP_COUNT", "--tag", "env://APP_TAG", "--tag", "b",
+		"--url", "https://example.com", "--literal", "file://" + path})
+	expect(t, err, nil)
+	expect(t, password, "s3cret")
+	expect(t, count, 3)
+	expect(t, tags, []string{"from-env", "b"})
+	expect(t, url, "https://example.com")
+	expect(t, literal, "file://"+path)
+
+	os.Setenv("APP_PASSWORD", "file://"+path)
+	expect(t, app.Run([]string{"app"}), nil)
+	expect(t, password, "s3cret")
+
+	err = app.Run([]string{"app", "--count", "env://APP_MISSING"})
+	if err == nil || !strings.Contains(err.Error(), "environment variable APP_MISSING is not set") {
+		t.Errorf("expected an error for the missing variable, got %v", err)
+	}
+	os.Setenv("APP_PASSWORD", "file://"+filepath.Join(dir, "missing"))
+	err = app.Run([]string{"app"})
+	if err == nil || !strings.Contains(err.Error(), "could not resolve value for flag password") {
+		t.Errorf("expected an error for the missing file, got %v", err)
+	}
+}
+
+func TestParseNegativeNumberValues(t *testing.T) {
+	tests := []struct {
+		args   []string
+		offset int
+		o      float64
+		nums   []float64
+		rest   []string
+	}{
+		{args: []string{"--offset", "-5"}, offset: -5},
+		{args: []string{"-o", "-3.2"}, o: -3.2},
+		{args: []string{"--offset", "-5", "-o", "-3.2", "--", "-1"}, offset: -5, o: -3.2, rest: []string{"-1"}},
+		{args: []string{"--nums", "1", "-2", "-3.5", "--offset", "-1"}, offset: -1, nums: []float64{1, -2, -3.5}},
+		{args: []string{"cmd", "--offset", "-5", "-o", "-3.2"}, offset: -5, o: -3.2},
+	}
+	for _, useShortOptionHandling := range []bool{false, true} {
+		for _, test := range tests {
+			var offset int
+			var o float64
+			var nums []float64
+			var rest []string
+			flags := []Flag{
+				&IntFlag{Name: "offset", Destination: &offset},
+				&Float64Flag{Name: "o", Destination: &o},
+				&Float64SliceFlag{Name: "nums", NArgs: -1, Destination: &nums},
+			}
+			action := func(ctx *Context) error {
+				rest = ctx.Args().Slice()
+				return nil
+			}
+			err := (&App{
+				Writer:                 ioutil.Discard,
+				UseShortOptionHandling: useShortOptionHandling,
+				Flags:                  flags,
+				Action:                 action,
+				Commands:               []*Command{{Name: "cmd", Flags: flags, Action: action}},
+			}).Run(append([]string{"run"}, test.args...))
+			expect(t, err, nil)
+			expect(t, offset, test.offset)
+			expect(t, o, test.o)
+			expect(t, generic.Equal(nums, test.nums), true)
+			expect(t, generic.Equal(rest, test.rest), true)
+		}
+	}
+}
+
+func TestParseMultiBoolT(t *testing.T) {
+	err := (&App{
+		Flags: []Flag{
+			&BoolFlag{Name: "implode", Aliases: []string{"i"}, Value: true},
+		},
+		Action: func(ctx *Context) error {
+			if ctx.Bool("implode") {
+				t.Errorf("main name not set")
+			}
+			if ctx.Bool("i") {
+				t.Errorf("short name not set")
+			}
+			return nil
+		},
+	}).Run([]string{"run", "--implode=false"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseBoolSpaceSeparated(t *testing.T) {
+	tests := []struct {
+		args    []string
+		implode bool
+		rest    []string
+	}{
+		{[]string{"run", "--implode=false"}, false, []string{}},
+		{[]string{"run", "--implode", "false"}, false, []string{}},
+		{[]string{"run", "-i", "FALSE", "x"}, false, []string{"x"}},
+		{[]string{"run", "--implode", "no"}, false, []string{}},
+		{[]string{"run", "--implode", "off"}, false, []string{}},
+		{[]string{"run", "--implode", "true"}, true, []string{}},
+		{[]string{"run", "--serve", "--implode", "Off"}, false, []string{}},
+		{[]string{"run", "--serve", "x"}, false, []string{"x"}},
+		{[]string{"run", "--implode", "0"}, true, []string{"0"}},
+		{[]string{"run", "--implode", "falsey"}, true, []string{"falsey"}},
+		{[]string{"run", "--implode", "--serve", "false"}, true, []string{}},
+	}
+	for _, test := range tests {
+		var implode bool
+		var rest []string
+		err := (&App{
+			BoolFlagWords: true,
+			Flags: []Flag{
+				&BoolFlag{Name: "implode", Aliases: []string{"i"}},
+				&BoolFlag{Name: "serve"},
+			},
+			Action: func(ctx *Context) error {
+				implode = ctx.Bool("implode")
+				rest = ctx.Args().Slice()
+				return nil
+			},
+		}).Run(test.args)
+		expect(t, err, nil)
+		expect(t, implode, test.implode)
+		expect(t, rest, test.rest)
+	}
+
+	// without BoolFlagWords the word is an argument, here of a command
+	var force bool
+	var rest []string
+	err := (&App{
+		Flags: []Flag{&BoolFlag{Name: "force"}},
+		Commands: []*Command{{
+			Name: "on",
+			Action: func(ctx *Context) error {
+				force = ctx.Bool("force")
+				rest = ctx.Args().Slice()
+				return nil
+			},
+		}},
+	}).Run([]string{"run", "--force", "on", "start"})
+	expect(t, err, nil)
+	expect(t, force, true)
+	expect(t, rest, []string{"start"})
+}
+
+type Parser [2]string
+
+func (p *Parser) Set(value interface{}) error {
+	parts := strings.Split(value.(string), ",")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid format")
+	}
+
+	(*p)[0] = parts[0]
+	(*p)[1] = parts[1]
+
+	return nil
+}
+
+func (p *Parser) String() string {
+	return fmt.Sprintf("%s,%s", p[0], p[1])
+}
+
+func (p *Parser) Get() interface{} {
+	return p
+}
+
+func TestParseGeneric(t *testing.T) {
+	err := (&App{
+		Flags: []Flag{
+			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Value: &Parser{}},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(ctx.Generic("serve"), &Parser{"10", "20"}) {
+				t.Errorf("main name not set")
+			}
+			if !reflect.DeepEqual(ctx.Generic("s"), &Parser{"10", "20"}) {
+				t.Errorf("short name not set")
+			}
+			return nil
+		},
+	}).Run([]string{"run", "-s", "10,20"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseGenericInto(t *testing.T) {
+	err := (&App{
+		Flags: []Flag{
+			&GenericFlag{Name: "serve", Value: &Parser{}},
+			&IntFlag{Name: "port", Value: 80},
+		},
+		Action: func(ctx *Context) error {
+			var p Parser
+			expect(t, ctx.GenericInto("serve", &p), nil)
+			expect(t, p, Parser{"10", "20"})
+
+			var port int64
+			expect(t, ctx.GenericInto("port", &port), nil)
+			expect(t, port, int64(8080))
+
+			var s string
+			expect(t, ctx.GenericInto("port", &s), nil)
+			expect(t, s, "8080")
+
+			var d time.Duration
+			err := ctx.GenericInto("serve", &d)
+			if err == nil || !strings.Contains(err.Error(), "could not convert flag serve to time.Duration") {
+				t.Errorf("expected conversion error, got %v", err)
+			}
+			err = ctx.GenericInto("missing", &s)
+			if err == nil || !strings.Contains(err.Error(), "flag missing not found") {
+				t.Errorf("expected missing flag error, got %v", err)
+			}
+			err = ctx.GenericInto("port", port)
+			if err == nil || !strings.Contains(err.Error(), "expected non-nil pointer") {
+				t.Errorf("expected pointer error, got %v", err)
+			}
+			return nil
+		},
+	}).Run([]string{"run", "--serve", "10,20", "--port", "8080"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseGenericNoFlags(t *testing.T) {
+	err := (&App{
+		Flags: []Flag{
+			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Value: &Parser{"hello", "world"}},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(ctx.Generic("serve"), &Parser{"hello", "world"}) {
+				t.Errorf("main name not set")
+			}
+			if !reflect.DeepEqual(ctx.Generic("s"), &Parser{"hello", "world"}) {
+				t.Errorf("short name not set")
+			}
+			return nil
+		},
+	}).Run([]string{"run"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseGenericDestination(t *testing.T) {
+	val := &Parser{"hello", "world"}
+	dest := &Parser{}
+	err := (&App{
+		Flags: []Flag{
+			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Value: val, Destination: dest},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(dest, &Parser{"10", "20"}) {
+				t.Errorf("destination not set")
+			}
+			if !reflect.DeepEqual(val, &Parser{"hello", "world"}) {
+				t.Errorf("value was set")
+			}
+			return nil
+		},
+	}).Run([]string{"run", "-s", "10,20"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseGenericDestinationNoValue(t *testing.T) {
 	dest := &Parser{"a", "ok"}
 	err := (&App{
 		Flags: []Flag{
-			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Destination: dest},
+			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Destination: dest},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(dest, &Parser{"10", "20"}) {
+				t.Errorf("destination not set")
+			}
+			return nil
+		},
+	}).Run([]string{"run", "-s", "10,20"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseGenericDestinationNoValueNoFlags(t *testing.T) {
+	dest := &Parser{"a", "ok"}
+	err := (&App{
+		Flags: []Flag{
+			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Destination: dest},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(dest, &Parser{}) {
+				t.Errorf("destination not set")
+			}
+			return nil
+		},
+	}).Run([]string{"run"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseStringSliceDestinationNoValueNoFlags(t *testing.T) {
+	dest := []string{"a", "ok"}
+	err := (&App{
+		Flags: []Flag{
+			&StringSliceFlag{Name: "serve", Aliases: []string{"s"}, Destination: &dest},
+		},
+		Action: func(ctx *Context) error {
+			if len(dest) != 0 {
+				t.Errorf("destination not set")
+			}
+			return nil
+		},
+	}).Run([]string{"run"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseGenericFromEnv(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_SERVE", "20,30")
+	err := (&App{
+		Flags: []Flag{
+			&GenericFlag{
+				Name:    "serve",
+				Aliases: []string{"s"},
+				Value:   &Parser{},
+				EnvVars: []string{"APP_SERVE"},
+			},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(ctx.Generic("serve"), &Parser{"20", "30"}) {
+				t.Errorf("main name not set from env")
+			}
+			if !reflect.DeepEqual(ctx.Generic("s"), &Parser{"20", "30"}) {
+				t.Errorf("short name not set from env")
+			}
+			return nil
+		},
+	}).Run([]string{"run"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestParseGenericFromEnvCascade(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_FOO", "99,2000")
+	err := (&App{
+		Flags: []Flag{
+			&GenericFlag{
+				Name:    "foos",
+				Value:   &Parser{},
+				EnvVars: []string{"COMPAT_FOO", "APP_FOO"},
+			},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(ctx.Generic("foos"), &Parser{"99", "2000"}) {
+				t.Errorf("value not set from env")
+			}
+			return nil
+		},
+	}).Run([]string{"run"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+type listValue []string
+
+func (l *listValue) Set(value interface{}) error {
+	*l = append(*l, value.(string))
+	return nil
+}
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func TestParseGenericSlice(t *testing.T) {
+	tests := []struct {
+		env    string
+		args   []string
+		expect listValue
+	}{
+		{expect: listValue{"default"}},
+		{args: []string{"--list", "a"}, expect: listValue{"a"}},
+		{args: []string{"--list", "a", "-l", "b", "--list", "c"}, expect: listValue{"a", "b", "c"}},
+		{env: "x,y", expect: listValue{"x", "y"}},
+		{env: `x\,y`, expect: listValue{"x,y"}},
+		{env: "x,y", args: []string{"--list", "a", "--list", "b"}, expect: listValue{"a", "b"}},
+	}
+	for _, test := range tests {
+		func() {
+			defer resetEnv(os.Environ())
+			os.Clearenv()
+			if test.env != "" {
+				os.Setenv("APP_LIST", test.env)
+			}
+			value := listValue{"default"}
+			dest := listValue{}
+			err := (&App{
+				Flags: []Flag{
+					&GenericFlag{
+						Name:        "list",
+						Aliases:     []string{"l"},
+						Value:       &value,
+						Destination: &dest,
+						EnvVars:     []string{"APP_LIST"},
+					},
+				},
+				Action: func(ctx *Context) error {
+					expect(t, ctx.Generic("list"), &test.expect)
+					expect(t, dest, test.expect)
+					return nil
+				},
+			}).Run(append([]string{"run"}, test.args...))
+			expect(t, err, nil)
+			// the flag default must not be modified
+			expect(t, value, listValue{"default"})
+		}()
+	}
+}
+
+func TestFromEnvWithAlias(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_IMPLODE", "true")
+	err := (&App{
+		Flags: []Flag{
+			&BoolFlag{
+				Name:    "implode",
+				Aliases: []string{"i"},
+				Value:   true,
+				EnvVars: []string{"APP_IMPLODE"},
+			},
+		},
+		Action: func(ctx *Context) error {
+			if !reflect.DeepEqual(ctx.Bool("implode"), false) {
+				t.Errorf("alias with env failure")
+			}
+			return nil
+		},
+	}).Run([]string{"run", "-i=false"})
+	if !reflect.DeepEqual(err, nil) {
+		t.Errorf("test failure: %v", err)
+	}
+}
+
+func TestJSONFlag(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	type filter struct {
+		Key    string   `json:"k"`
+		Values []string `json:"values"`
+	}
+	var dest filter
+	var decoded interface{}
+	app := &App{
+		Writer: ioutil.Discard,
+		Flags: []Flag{
+			&JSONFlag{Name: "filter", EnvVars: []string{"APP_FILTER"}, Value: `{"k":"default"}`, Destination: &dest},
+			&JSONFlag{Name: "extra"},
+		},
+		Action: func(ctx *Context) error {
+			decoded = ctx.JSON("extra")
+			expect(t, ctx.JSON("filter"), dest)
+			return nil
+		},
+	}
+
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, dest, filter{Key: "default"})
+	expect(t, decoded, nil)
+
+	expect(t, app.Run([]string{"run", "--filter", `{"k":"v","values":["a","b"]}`, "--extra", `{"n":1,"l":[true]}`}), nil)
+	expect(t, dest, filter{Key: "v", Values: []string{"a", "b"}})
+	expect(t, decoded, map[string]interface{}{"n": float64(1), "l": []interface{}{true}})
+
+	// a new value replaces the default rather than being merged into it
+	os.Setenv("APP_FILTER", `{"values":["env"]}`)
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, dest, filter{Values: []string{"env"}})
+
+	err := app.Run([]string{"run", "--filter", `{"k":`})
+	if err == nil || !strings.Contains(err.Error(), "invalid value") {
+		t.Errorf("expected invalid value error, got %v", err)
+	}
+	os.Setenv("APP_FILTER", "nope")
+	err = app.Run([]string{"run"})
+	if err == nil || !strings.HasPrefix(err.Error(), `could not parse "nope" as json value for flag filter`) {
+		t.Errorf("expected env parse error, got %v", err)
+	}
+
+	expect(t, FlagToString(&JSONFlag{Name: "filter", Value: `{"k":"v"}`, Usage: "filter results"}),
+		"--filter value\tfilter results (default: \"{\\\"k\\\":\\\"v\\\"}\")")
+	err = (&JSONFlag{Name: "filter", Destination: filter{}}).Apply(flag.NewFlagSet("test", flag.ContinueOnError))
+	if err == nil || !strings.Contains(err.Error(), "must be a pointer") {
+		t.Errorf("expected pointer error, got %v", err)
+	}
+}
+
+func TestDefaultTextPrefixAndEnvHintFormat(t *testing.T) {
+	defer func(prefix, format string) {
+		DefaultTextPrefix, EnvHintFormat = prefix, format
+	}(DefaultTextPrefix, EnvHintFormat)
+	DefaultTextPrefix = "(par défaut : "
+	EnvHintFormat = " (env %s)"
+
+	envHint := "$APP_PORT"
+	if runtime.GOOS == "windows" {
+		envHint = "%APP_PORT%"
+	}
+	expect(t, FlagToString(&IntFlag{Name: "port", Value: 80, EnvVars: []string{"APP_PORT"}, Usage: "port 100%"}),
+		"--port value\tport 100% (par défaut : 80) (env "+envHint+")")
+	expect(t, FlagToString(&StringSliceFlag{Name: "host", Value: []string{"a", "b"}}),
+		`--host value	(par défaut : "a", "b")`)
+}
+
+func TestStringSetFlag(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	var dest, enabled StringSet
+	app := &App{
+		Flags: []Flag{
+			&StringSetFlag{Name: "enable", Aliases: []string{"e"}, EnvVars: []string{"APP_ENABLE"}, Value: StringSet{"a", "b", "a"}, Destination: &dest},
+		},
+		Action: func(ctx *Context) error {
+			enabled = ctx.StringSet("enable")
+			return nil
+		},
+	}
+
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, enabled, StringSet{"a", "b"})
+	expect(t, dest, enabled)
+
+	expect(t, app.Run([]string{"run", "--enable", "c,b,c", "-e", "a", "--enable", "b"}), nil)
+	expect(t, enabled, StringSet{"c", "b", "a"})
+	expect(t, dest, enabled)
+	expect(t, enabled.Has("a"), true)
+	expect(t, enabled.Has("d"), false)
+	expect(t, enabled.Slice(), []string{"c", "b", "a"})
+
+	os.Setenv("APP_ENABLE", "x,y,x")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, enabled, StringSet{"x", "y"})
+	expect(t, app.Run([]string{"run", "--enable", "z"}), nil)
+	expect(t, enabled, StringSet{"z"})
+
+	var s StringSet
+	expect(t, s.Has("a"), false)
+	expect(t, s.Set([]string{"a", "b", "a"}), nil)
+	expect(t, s.String(), "a,b")
+	expect(t, s.Set(1) != nil, true)
+	expect(t, NewStringSet("b", "a", "b"), StringSet{"b", "a"})
+
+	expect(t, FlagToString(&StringSetFlag{Name: "enable", Value: StringSet{"a", "b"}, Usage: "features to enable"}),
+		`--enable value	features to enable (default: "a", "b")`)
+}
+
+func TestFlagEnvTransform(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	temp, err := ioutil.TempFile("", "urfave_cli_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(temp.Name())
+	io.WriteString(temp, base64.StdEncoding.EncodeToString([]byte("from file"))+"\n")
+	temp.Close()
+
+	decode := func(raw string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(raw)
+		return string(b), err
+	}
+	fromJSON := func(raw string) (string, error) {
+		var values []string
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return "", err
+		}
+		return strings.Join(values, ","), nil
+	}
+
+	os.Setenv("APP_SECRET", base64.StdEncoding.EncodeToString([]byte("hunter2")))
+	os.Setenv("APP_HOSTS", `["a", "b"]`)
+	var secret, fromFile, token string
+	var hosts []string
+	app := &App{
+		Flags: []Flag{
+			&StringFlag{Name: "secret", EnvVars: []string{"APP_SECRET"}, EnvTransform: decode, Destination: &secret},
+			&StringSliceFlag{Name: "hosts", EnvVars: []string{"APP_HOSTS"}, EnvTransform: fromJSON, Destination: &hosts},
+			&StringFlag{Name: "file", FilePath: temp.Name(), EnvTransform: decode, Destination: &fromFile},
+			&StringFlag{Name: "token", FromFileFlag: "token-file", EnvTransform: decode, Destination: &token},
+			&StringFlag{Name: "token-file"},
+		},
+		Action: func(*Context) error { return nil },
+	}
+	expect(t, app.Run([]string{"run", "--token-file", temp.Name()}), nil)
+	expect(t, secret, "hunter2")
+	expect(t, hosts, []string{"a", "b"})
+	expect(t, fromFile, "from file")
+	expect(t, token, "from file")
+
+	// values from the command line are not transformed
+	expect(t, app.Run([]string{"run", "--secret", "plain", "--token", "plain"}), nil)
+	expect(t, secret, "plain")
+	expect(t, token, "plain")
+
+	os.Setenv("APP_SECRET", "not base64!")
+	err = app.Run([]string{"run"})
+	if err == nil || !strings.HasPrefix(err.Error(), "could not transform value for flag secret: ") {
+		t.Errorf("expected transform error, got %v", err)
+	}
+}
+
+func TestFlagFromFileFlag(t *testing.T) {
+	temp, err := ioutil.TempFile("", "urfave_cli_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(temp.Name())
+	io.WriteString(temp, "hunter2\n")
+	temp.Close()
+
+	var password string
+	app := &App{
+		Flags: []Flag{
+			&StringFlag{Name: "password", Aliases: []string{"p"}, FromFileFlag: "password-file", Required: true},
+			&StringFlag{Name: "password-file"},
+		},
+		Action: func(ctx *Context) error {
+			password = ctx.String("p")
+			return nil
+		},
+	}
+
+	err = app.Run([]string{"run", "--password-file", temp.Name()})
+	expect(t, err, nil)
+	expect(t, password, "hunter2")
+
+	err = app.Run([]string{"run", "--password", "direct"})
+	expect(t, err, nil)
+	expect(t, password, "direct")
+
+	err = app.Run([]string{"run", "-p", "direct", "--password-file", temp.Name()})
+	if err == nil || err.Error() != "flags --password and --password-file cannot both be set" {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+
+	err = app.Run([]string{"run", "--password-file", "file-does-not-exist"})
+	if err == nil || !strings.HasPrefix(err.Error(), "unable to read --password from file") {
+		t.Errorf("expected error for missing file, got %v", err)
+	}
+}
+
+func TestFlagFromFile(t *testing.T) {
+	temp, err := ioutil.TempFile("", "urfave_cli_test")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_FOO", "123")
+
+	io.WriteString(temp, "abc")
+	temp.Close()
+	defer func() {
+		os.Remove(temp.Name())
+	}()
+
+	var filePathTests = []struct {
+		path     string
+		name     []string
+		expected string
+	}{
+		{"file-does-not-exist", []string{"APP_BAR"}, ""},
+		{"file-does-not-exist", []string{"APP_FOO"}, "123"},
+		{"file-does-not-exist", []string{"APP_FOO", "APP_BAR"}, "123"},
+		{temp.Name(), []string{"APP_FOO"}, "123"},
+		{temp.Name(), []string{"APP_BAR"}, "abc"},
+	}
+
+	for _, filePathTest := range filePathTests {
+		got, _ := flagFromEnvOrFile(filePathTest.name, filePathTest.path)
+		if want := filePathTest.expected; got != want {
+			t.Errorf("Did not expect %v - Want %v", got, want)
+		}
+	}
+}
+
+func TestFlagResolvers(t *testing.T) {
+	temp, err := ioutil.TempFile("", "resolvers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(temp, "from-file\n")
+	temp.Close()
+	defer os.Remove(temp.Name())
+
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_CONFIG_PATH", temp.Name())
+
+	var result string
+	app := &App{
+		Flags: []Flag{
+			&StringFlag{
+				Name:    "config",
+				EnvVars: []string{"APP_CONFIG"},
+				Resolvers: []Resolver{
+					EnvResolver{"APP_CONFIG"},
+					EnvNamedFileResolver{"APP_CONFIG_PATH"},
+					FileResolver{"file-does-not-exist"},
+				},
+			},
 		},
 		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(dest, &Parser{"10", "20"}) {
-				t.Errorf("destination not set")
-			}
+			result = ctx.String("config")
 			return nil
 		},
-	}).Run([]string{"run", "-s", "10,20"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
 	}
+
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, result, "from-file\n")
+
+	os.Setenv("APP_CONFIG", "from-env")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, result, "from-env")
+
+	expect(t, app.Run([]string{"run", "--config", "from-args"}), nil)
+	expect(t, result, "from-args")
+
+	os.Clearenv()
+	os.Setenv("APP_CONFIG_PATH", "file-does-not-exist")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, result, "")
 }
 
-func TestParseGenericDestinationNoValueNoFlags(t *testing.T) {
-	dest := &Parser{"a", "ok"}
+func TestSplitEscaped(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`a,b,c`, []string{"a", "b", "c"}},
+		{`\,a,b`, []string{",a", "b"}},
+		{`a\,b,c`, []string{"a,b", "c"}},
+		{`a,b\,`, []string{"a", "b,"}},
+		{`a\\,b`, []string{`a\`, "b"}},
+		{`C:\dir,D:\dir`, []string{`C:\dir`, `D:\dir`}},
+		{`a,b\`, []string{"a", `b\`}},
+		{`a,`, []string{"a", ""}},
+		{``, []string{""}},
+	}
+	for _, test := range tests {
+		expect(t, splitEscaped(test.input, ','), test.expected)
+	}
+}
+
+func TestParseStringSliceEscapedFromEnv(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("APP_ITEMS", `a\,b,c`)
+
+	var items []string
 	err := (&App{
 		Flags: []Flag{
-			&GenericFlag{Name: "serve", Aliases: []string{"s"}, Destination: dest},
+			&StringSliceFlag{Name: "items", EnvVars: []string{"APP_ITEMS"}},
 		},
 		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(dest, &Parser{}) {
-				t.Errorf("destination not set")
-			}
+			items = ctx.StringSlice("items")
 			return nil
 		},
 	}).Run([]string{"run"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
+	expect(t, err, nil)
+	expect(t, items, []string{"a,b", "c"})
+}
+
+var resolvedDefault string
+
+type resolvedValue string
+
+func (v *resolvedValue) Set(value interface{}) error {
+	*v = resolvedValue(value.(string))
+	return nil
+}
+
+func (v *resolvedValue) String() string {
+	return string(*v)
+}
+
+func (v *resolvedValue) Get() interface{} {
+	return string(*v)
+}
+
+func (v *resolvedValue) Resolve() (interface{}, error) {
+	if resolvedDefault == "" {
+		return nil, fmt.Errorf("no default")
 	}
+	return resolvedDefault, nil
 }
 
-func TestParseStringSliceDestinationNoValueNoFlags(t *testing.T) {
-	dest := []string{"a", "ok"}
-	err := (&App{
+func TestFlagDefaultResolver(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	var result interface{}
+	app := &App{
 		Flags: []Flag{
-			&StringSliceFlag{Name: "serve", Aliases: []string{"s"}, Destination: &dest},
+			&GenericFlag{Name: "dir", Value: new(resolvedValue), EnvVars: []string{"APP_DIR"}},
 		},
 		Action: func(ctx *Context) error {
-			if len(dest) != 0 {
-				t.Errorf("destination not set")
-			}
+			result = ctx.Value("dir")
 			return nil
 		},
-	}).Run([]string{"run"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
+	}
+
+	resolvedDefault = "runtime"
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, result, "runtime")
+
+	os.Setenv("APP_DIR", "env")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, result, "env")
+
+	expect(t, app.Run([]string{"run", "--dir", "args"}), nil)
+	expect(t, result, "args")
+
+	os.Clearenv()
+	resolvedDefault = ""
+	err := app.Run([]string{"run"})
+	if err == nil || err.Error() != "could not resolve default value for flag dir: no default" {
+		t.Errorf("expected a resolve error, got %v", err)
 	}
 }
 
-func TestParseGenericFromEnv(t *testing.T) {
+func TestFlagChoices(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
-	os.Setenv("APP_SERVE", "20,30")
-	err := (&App{
+
+	var color string
+	var colors []string
+	app := &App{
 		Flags: []Flag{
-			&GenericFlag{
-				Name:    "serve",
-				Aliases: []string{"s"},
-				Value:   &Parser{},
-				EnvVars: []string{"APP_SERVE"},
-			},
+			&StringFlag{Name: "color", Value: "red", Choices: []string{"red", "green"}, EnvVars: []string{"APP_COLOR"}},
+			&StringSliceFlag{Name: "colors", Choices: []string{"red", "green"}, EnvVars: []string{"APP_COLORS"}},
 		},
 		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(ctx.Generic("serve"), &Parser{"20", "30"}) {
-				t.Errorf("main name not set from env")
-			}
-			if !reflect.DeepEqual(ctx.Generic("s"), &Parser{"20", "30"}) {
-				t.Errorf("short name not set from env")
-			}
+			color = ctx.String("color")
+			colors = ctx.StringSlice("colors")
 			return nil
 		},
-	}).Run([]string{"run"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
+		Writer:         ioutil.Discard,
+		ExitErrHandler: func(*Context, error) {},
 	}
+
+	expect(t, app.Run([]string{"run", "--color", "green", "--colors", "red", "--colors", "green"}), nil)
+	expect(t, color, "green")
+	expect(t, colors, []string{"red", "green"})
+
+	err := app.Run([]string{"run", "--color", "blue"})
+	expect(t, errors.Unwrap(err).Error(), `invalid value "blue" for flag -color: must be one of red, green`)
+
+	err = app.Run([]string{"run", "--colors", "red", "--colors", "blue"})
+	expect(t, errors.Unwrap(err).Error(), `invalid value "blue" for flag -colors: must be one of red, green`)
+
+	os.Setenv("APP_COLORS", "red,blue")
+	err = app.Run([]string{"run"})
+	expect(t, err.Error(), `could not parse "red,blue" as string slice value for flag colors: invalid element "blue": must be one of red, green`)
 }
 
-func TestParseGenericFromEnvCascade(t *testing.T) {
+func TestBuildFlagSet(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
-	os.Setenv("APP_FOO", "99,2000")
+	os.Setenv("APP_PORT", "8080")
+
+	set, err := BuildFlagSet("test", []Flag{
+		&IntFlag{Name: "port", Aliases: []string{"p"}, EnvVars: []string{"APP_PORT"}},
+		&StringFlag{Name: "host", Value: "localhost"},
+	})
+	expect(t, err, nil)
+	expect(t, set.Lookup("port").Value.String(), "8080")
+	expect(t, set.Lookup("p").Value.String(), "8080")
+
+	expect(t, set.Parse([]string{"--host", "example.com", "arg"}), nil)
+	expect(t, set.Lookup("host").Value.String(), "example.com")
+	expect(t, set.Args(), []string{"arg"})
+
+	expect(t, set.Parse([]string{"--missing"}).Error(), "flag provided but not defined: -missing")
+
+	os.Setenv("APP_PORT", "http")
+	_, err = BuildFlagSet("test", []Flag{
+		&IntFlag{Name: "port", EnvVars: []string{"APP_PORT"}},
+	})
+	if err == nil {
+		t.Errorf("expected an error for an invalid env value")
+	}
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func TestNewFlag(t *testing.T) {
+	generic.RegisterType(testPoint{}, func(s string) (interface{}, error) {
+		var p testPoint
+		if _, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}, nil)
+
+	var flags []Flag
+	for _, def := range []struct{ typ, name string }{
+		{"int", "count"},
+		{"string slice", "tag"},
+		{"duration", "timeout"},
+		{"cli.testPoint", "origin"},
+		{"[]cli.testPoint", "path"},
+	} {
+		f, err := NewFlag(def.typ, def.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect(t, FlagNames(f), []string{def.name})
+		flags = append(flags, f)
+	}
+	// flags which wrap a GenericFlag are named for their own type
+	wrappers := map[string]bool{"string set": true, "deadline": true, "path": true, "bytes": true, "ipnet slice": true, "string map": true}
+	for typeName, newFlag := range flagConstructors {
+		if value, ok := getFlagValue(newFlag("x")); ok && generic.TypeName(value) != typeName && !wrappers[typeName] {
+			t.Errorf("flag type %q has the value type name %q", typeName, generic.TypeName(value))
+		}
+	}
+	_, err := NewFlag("complex128", "c")
+	expect(t, err.Error(), `unknown flag type "complex128"`)
+
+	err = (&App{
+		Flags: flags,
+		Action: func(ctx *Context) error {
+			expect(t, ctx.Int("count"), 3)
+			expect(t, ctx.StringSlice("tag"), []string{"a", "b"})
+			expect(t, ctx.Duration("timeout"), time.Minute)
+			expect(t, ctx.Value("origin"), testPoint{1, 2})
+			expect(t, ctx.Value("path"), []testPoint{{3, 4}, {5, 6}})
+			return nil
+		},
+	}).Run([]string{"run", "--count", "3", "--tag", "a", "--tag", "b", "--timeout", "1m",
+		"--origin", "1,2", "--path", "3,4", "--path", "5,6"})
+	expect(t, err, nil)
+}
+
+func TestStringFlagTemplateDefault(t *testing.T) {
+	var bucket, dest, path, logFile, source string
+	newApp := func() *App {
+		return &App{
+			Flags: []Flag{
+				&StringFlag{Name: "region", Value: "us-east"},
+				&StringFlag{Name: "bucket", Value: "{{.region}}-bucket", TemplateDefault: true, Destination: &dest},
+				&StringFlag{Name: "path", Value: "{{.dir}}/{{.port}}", TemplateDefault: true},
+				&StringFlag{Name: "dir", Value: "/{{.bucket}}", TemplateDefault: true},
+				&IntFlag{Name: "port", Value: 80},
+				&StringFlag{Name: "log-level", Value: "info"},
+			},
+			Commands: []*Command{{
+				Name: "run",
+				Flags: []Flag{
+					&StringFlag{Name: "log-file", Value: `{{index . "log-level"}}-{{.region}}.log`, TemplateDefault: true},
+				},
+				Action: func(ctx *Context) error {
+					bucket = ctx.String("bucket")
+					path = ctx.String("path")
+					logFile = ctx.String("log-file")
+					source = ctx.Source("bucket")
+					return nil
+				},
+			}},
+		}
+	}
+
+	expect(t, newApp().Run([]string{"app", "run"}), nil)
+	expect(t, bucket, "us-east-bucket")
+	expect(t, dest, "us-east-bucket")
+	expect(t, path, "/us-east-bucket/80")
+	expect(t, logFile, "info-us-east.log")
+	expect(t, source, "default")
+
+	expect(t, newApp().Run([]string{"app", "--region", "eu", "--port", "8080", "--log-level", "debug", "run"}), nil)
+	expect(t, bucket, "eu-bucket")
+	expect(t, path, "/eu-bucket/8080")
+	expect(t, logFile, "debug-eu.log")
+
+	expect(t, newApp().Run([]string{"app", "--bucket", "mine", "run", "--log-file", "x.log"}), nil)
+	expect(t, bucket, "mine")
+	expect(t, source, "flag")
+	expect(t, path, "/mine/80")
+	expect(t, logFile, "x.log")
+
 	err := (&App{
 		Flags: []Flag{
-			&GenericFlag{
-				Name:    "foos",
-				Value:   &Parser{},
-				EnvVars: []string{"COMPAT_FOO", "APP_FOO"},
-			},
+			&StringFlag{Name: "a", Value: "{{.b}}", TemplateDefault: true},
+			&StringFlag{Name: "b", Value: "{{.c}}", TemplateDefault: true},
+			&StringFlag{Name: "c", Value: "{{.a}}", TemplateDefault: true},
 		},
-		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(ctx.Generic("foos"), &Parser{"99", "2000"}) {
-				t.Errorf("value not set from env")
-			}
-			return nil
+		Action: func(ctx *Context) error { return nil },
+	}).Run([]string{"app"})
+	expect(t, err.Error(), "cycle in template defaults: a -> b -> c -> a")
+
+	err = (&App{
+		Flags: []Flag{
+			&StringFlag{Name: "a", Value: "{{.missing}}", TemplateDefault: true},
 		},
-	}).Run([]string{"run"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
+		Action: func(ctx *Context) error { return nil },
+	}).Run([]string{"app"})
+	expect(t, strings.HasPrefix(err.Error(), "could not execute template default for flag a: "), true)
+}
+
+func TestFlagDerive(t *testing.T) {
+	var dataDir, cacheDir string
+	var workers int
+	var dataDirSet bool
+	newApp := func() *App {
+		return &App{
+			Flags: []Flag{
+				&StringFlag{Name: "cache-dir", Derive: func(ctx *Context) (interface{}, error) {
+					return filepath.Join(ctx.String("data-dir"), "cache"), nil
+				}},
+				&StringFlag{Name: "data-dir", Derive: func(ctx *Context) (interface{}, error) {
+					return filepath.Join(ctx.String("config-dir"), "data"), nil
+				}},
+				&StringFlag{Name: "config-dir", Value: "/etc/app"},
+				&IntFlag{Name: "workers", Value: 1, Derive: func(ctx *Context) (interface{}, error) {
+					return nil, nil
+				}},
+			},
+			Action: func(ctx *Context) error {
+				dataDir, cacheDir, workers = ctx.String("data-dir"), ctx.String("cache-dir"), ctx.Int("workers")
+				dataDirSet = ctx.IsSet("data-dir")
+				return nil
+			},
+		}
+	}
+
+	expect(t, newApp().Run([]string{"app"}), nil)
+	expect(t, dataDir, "/etc/app/data")
+	expect(t, cacheDir, "/etc/app/data/cache")
+	expect(t, workers, 1)
+	expect(t, dataDirSet, false)
+
+	expect(t, newApp().Run([]string{"app", "--config-dir", "/tmp"}), nil)
+	expect(t, dataDir, "/tmp/data")
+	expect(t, cacheDir, "/tmp/data/cache")
+
+	expect(t, newApp().Run([]string{"app", "--data-dir", "/var/lib/app"}), nil)
+	expect(t, dataDir, "/var/lib/app")
+	expect(t, cacheDir, "/var/lib/app/cache")
+	expect(t, dataDirSet, true)
+
+	lookup := func(name string) func(ctx *Context) (interface{}, error) {
+		return func(ctx *Context) (interface{}, error) {
+			return ctx.String(name), nil
+		}
 	}
+	err := (&App{
+		Flags: []Flag{
+			&StringFlag{Name: "a", Derive: lookup("b")},
+			&StringFlag{Name: "b", Derive: lookup("c")},
+			&StringFlag{Name: "c", Derive: lookup("a")},
+		},
+		Action: func(ctx *Context) error { return nil },
+	}).Run([]string{"app"})
+	expect(t, err.Error(), "cycle in derived flags: a -> b -> c -> a")
+
+	err = (&App{
+		Flags: []Flag{
+			&StringFlag{Name: "a", Derive: lookup("b")},
+			&StringFlag{Name: "b", Derive: func(ctx *Context) (interface{}, error) {
+				return nil, errors.New("unavailable")
+			}},
+			&IntFlag{Name: "c", Derive: func(ctx *Context) (interface{}, error) {
+				return "x", nil
+			}},
+		},
+		Action: func(ctx *Context) error { return nil },
+	}).Run([]string{"app"})
+	expect(t, strings.Contains(err.Error(), "could not derive value for flag b: unavailable\n"), true)
+	expect(t, strings.Contains(err.Error(), "could not set derived value for flag c: "), true)
 }
 
-func TestFromEnvWithAlias(t *testing.T) {
+func TestDurationFlagISO8601(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
-	os.Setenv("APP_IMPLODE", "true")
-	err := (&App{
+
+	var timeout time.Duration
+	var intervals []time.Duration
+	app := &App{
+		Name:      "app",
+		Writer:    ioutil.Discard,
+		ErrWriter: ioutil.Discard,
 		Flags: []Flag{
-			&BoolFlag{
-				Name:    "implode",
-				Aliases: []string{"i"},
-				Value:   true,
-				EnvVars: []string{"APP_IMPLODE"},
-			},
+			&DurationFlag{Name: "timeout", EnvVars: []string{"APP_TIMEOUT"}, DurationFormat: DurationFormatISO8601},
+			&DurationSliceFlag{Name: "interval", EnvVars: []string{"APP_INTERVAL"}, DurationFormat: DurationFormatISO8601},
 		},
 		Action: func(ctx *Context) error {
-			if !reflect.DeepEqual(ctx.Bool("implode"), false) {
-				t.Errorf("alias with env failure")
-			}
+			timeout = ctx.Duration("timeout")
+			intervals = ctx.DurationSlice("interval")
 			return nil
 		},
-	}).Run([]string{"run", "-i=false"})
-	if !reflect.DeepEqual(err, nil) {
-		t.Errorf("test failure: %v", err)
 	}
-}
 
-func TestFlagFromFile(t *testing.T) {
-	temp, err := ioutil.TempFile("", "urfave_cli_test")
-	if err != nil {
-		t.Error(err)
-		return
+	expect(t, app.Run([]string{"run", "--timeout", "P1DT2H", "--interval", "PT30S", "--interval", "P1W"}), nil)
+	expect(t, timeout, 26*time.Hour)
+	expect(t, intervals, []time.Duration{30 * time.Second, 7 * 24 * time.Hour})
+
+	os.Setenv("APP_TIMEOUT", "PT1.5S")
+	os.Setenv("APP_INTERVAL", "PT1M,PT1H")
+	expect(t, app.Run([]string{"run"}), nil)
+	expect(t, timeout, 1500*time.Millisecond)
+	expect(t, intervals, []time.Duration{time.Minute, time.Hour})
+	os.Clearenv()
+
+	err := app.Run([]string{"run", "--timeout", "P1M"})
+	expect(t, err.Error(), `app: invalid value "P1M" for flag -timeout: ISO 8601 duration "P1M" has years or months, which do not have a fixed length`)
+	if err := app.Run([]string{"run", "--timeout", "1h"}); err == nil {
+		t.Error("expected an error for a Go duration with DurationFormatISO8601")
 	}
 
+	err = (&App{Flags: []Flag{&DurationFlag{Name: "timeout", DurationFormat: "unix"}}}).Run([]string{"run"})
+	expect(t, err.Error(), `unknown duration format "unix" for flag timeout`)
+
+	expect(t, FlagToString(&DurationFlag{Name: "timeout", Value: 26 * time.Hour, DurationFormat: DurationFormatISO8601}),
+		"--timeout value\t(default: P1DT2H)")
+	expect(t, FlagToString(&DurationSliceFlag{Name: "interval", Value: []time.Duration{time.Minute}, DurationFormat: DurationFormatISO8601}),
+		"--interval value\t(default: PT1M)")
+}
+
+func TestBoolTokensConsistent(t *testing.T) {
 	defer resetEnv(os.Environ())
 	os.Clearenv()
-	os.Setenv("APP_FOO", "123")
-
-	io.WriteString(temp, "abc")
-	temp.Close()
-	defer func() {
-		os.Remove(temp.Name())
-	}()
 
-	var filePathTests = []struct {
-		path     string
-		name     []string
-		expected string
-	}{
-		{"file-does-not-exist", []string{"APP_BAR"}, ""},
-		{"file-does-not-exist", []string{"APP_FOO"}, "123"},
-		{"file-does-not-exist", []string{"APP_FOO", "APP_BAR"}, "123"},
-		{temp.Name(), []string{"APP_FOO"}, "123"},
-		{temp.Name(), []string{"APP_BAR"}, "abc"},
+	var scalar, fromEnv bool
+	var slice, sliceFromEnv []bool
+	app := &App{
+		Flags: []Flag{
+			&BoolFlag{Name: "b"},
+			&BoolFlag{Name: "env-b", EnvVars: []string{"APP_B"}},
+			&BoolSliceFlag{Name: "s"},
+			&BoolSliceFlag{Name: "env-s", EnvVars: []string{"APP_S"}},
+		},
+		Action: func(ctx *Context) error {
+			scalar, fromEnv = ctx.Bool("b"), ctx.Bool("env-b")
+			slice, sliceFromEnv = ctx.BoolSlice("s"), ctx.BoolSlice("env-s")
+			return nil
+		},
 	}
-
-	for _, filePathTest := range filePathTests {
-		got, _ := flagFromEnvOrFile(filePathTest.name, filePathTest.path)
-		if want := filePathTest.expected; got != want {
-			t.Errorf("Did not expect %v - Want %v", got, want)
-		}
+	for _, token := range []string{"t", "f", "T", "F", "true", "False", "yes", "no", "y", "N", "on", "OFF", "1", "0"} {
+		b, err := generic.ParseBool(token)
+		expect(t, err, nil)
+		os.Setenv("APP_B", token)
+		os.Setenv("APP_S", token+","+token)
+		expect(t, app.Run([]string{"run", "--b=" + token, "--s=" + token}), nil)
+		expect(t, scalar, b)
+		expect(t, fromEnv, b)
+		expect(t, slice, []bool{b})
+		expect(t, sliceFromEnv, []bool{b, b})
 	}
 }
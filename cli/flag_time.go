@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// timeLayoutValue is a flag.Value for time flags which parses values with
+// a layout before falling back to the generic time layouts
+type timeLayoutValue struct {
+	flag.Value
+	layout string
+}
+
+// Set parses value with the layout, otherwise passes it unchanged to the
+// underlying flag.Value
+func (v *timeLayoutValue) Set(value interface{}) error {
+	if s, ok := value.(string); ok {
+		if t, err := time.Parse(v.layout, s); err == nil {
+			value = t
+		}
+	}
+	return v.Value.Set(value)
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *timeLayoutValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+// DefaultTimeKeywords may be used as the Keywords of a TimeFlag to accept
+// "now", or "today" and "yesterday" for the start of those days in the
+// local time zone
+var DefaultTimeKeywords = map[string]func() time.Time{
+	"now": time.Now,
+	"today": func() time.Time {
+		return startOfDay(time.Now())
+	},
+	"yesterday": func() time.Time {
+		return startOfDay(time.Now().AddDate(0, 0, -1))
+	},
+}
+
+// startOfDay returns midnight of the day of t
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// timeKeywordValue is a flag.Value for time flags which resolves keywords
+// such as "now" before passing values to the underlying flag.Value
+type timeKeywordValue struct {
+	flag.Value
+	keywords map[string]func() time.Time
+}
+
+// Set resolves value if it is a keyword, otherwise passes it unchanged to
+// the underlying flag.Value
+func (v *timeKeywordValue) Set(value interface{}) error {
+	if s, ok := value.(string); ok {
+		if keyword, ok := v.keywords[s]; ok {
+			value = keyword()
+		}
+	}
+	return v.Value.Set(value)
+}
+
+// Get returns the value of the underlying flag.Value
+func (v *timeKeywordValue) Get() interface{} {
+	return v.Value.(flag.Getter).Get()
+}
+
+// timeLayout returns the Layout of a time flag, or time.RFC3339 if not set
+func timeLayout(f Flag) string {
+	if layout, _ := getFlagLayout(f); layout != "" {
+		return layout
+	}
+	return time.RFC3339
+}
+
+// parseTimeElem sets the time.Time pointed to by ptr from val if it can be
+// parsed with layout
+func parseTimeElem(ptr interface{}, val, layout string) bool {
+	if _, ok := ptr.(*time.Time); !ok || layout == "" {
+		return false
+	}
+	t, err := time.Parse(layout, val)
+	if err != nil {
+		return false
+	}
+	generic.Set(ptr, t)
+	return true
+}
+
+// formatTime returns v formatted with the layout of the time flag f, or
+// false if v is not a time.Time
+func formatTime(f Flag, v interface{}) (string, bool) {
+	t, ok := v.(time.Time)
+	if !ok {
+		return "", false
+	}
+	if t.IsZero() {
+		return "", true
+	}
+	return t.Format(timeLayout(f)), true
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// flagsFileEntry is a flag value read from the file of App.FlagsFile
+type flagsFileEntry struct {
+	name, value string
+	line        int
+}
+
+// newFlagsFileFlag returns the flag named by FlagsFile
+func (a *App) newFlagsFileFlag() Flag {
+	return &StringFlag{
+		Name:      a.FlagsFile,
+		Usage:     "load flags from `FILE`, with one flag such as --name value on each line",
+		TakesFile: true,
+	}
+}
+
+// flagsFilePath returns the path given to the FlagsFile flag of the root
+// App of the context, or an empty string if it is not given
+func flagsFilePath(context *Context) string {
+	for _, ctx := range context.Lineage() {
+		if ctx.App == nil || ctx.App.FlagsFile == "" || ctx.flagSet == nil {
+			continue
+		}
+		if isSetIn(ctx.flagSet, ctx.App.FlagsFile) {
+			return ctx.flagSet.Lookup(ctx.App.FlagsFile).Value.String()
+		}
+	}
+	return ""
+}
+
+// readFlagsFile reads the flags of a flags file, which has lines such as
+// --name value, --name=value or name=value, ignoring blank lines and lines
+// starting with #. Values may be quoted as in a .env file, and a name alone
+// is given the value "true" for bool flags.
+func readFlagsFile(path string) ([]flagsFileEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read flags file: %s", err)
+	}
+	defer f.Close()
+	var entries []flagsFileEntry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimLeft(text, "-")
+		name, value := text, "true"
+		if i := strings.IndexAny(text, "= \t"); i >= 0 {
+			name = text[:i]
+			if value, err = dotEnvValue(strings.TrimSpace(text[i+1:])); err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", path, line, err)
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%s:%d: expected a flag name", path, line)
+		}
+		entries = append(entries, flagsFileEntry{name: name, value: value, line: line})
+	}
+	return entries, scanner.Err()
+}
+
+// resolveFlagsFile sets each flag which is not set on the command line from
+// the values of the flags file given to the FlagsFile flag. The flags file
+// is checked for unknown flags by the root App.
+func resolveFlagsFile(flags []Flag, context *Context) error {
+	path := flagsFilePath(context)
+	if path == "" {
+		return nil
+	}
+	entries, err := readFlagsFile(path)
+	if err != nil {
+		return err
+	}
+	if context.parentContext == nil || context.parentContext.App == nil {
+		known := context.App.knownFlagNames()
+		for _, entry := range entries {
+			if !known[entry.name] {
+				return fmt.Errorf("%s:%d: flag provided but not defined: -%s", path, entry.line, entry.name)
+			}
+		}
+	}
+	for _, f := range flags {
+		if err := resolveFlagsFileFlag(f, entries, path, context); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveFlagsFileFlag(f Flag, entries []flagsFileEntry, path string, context *Context) error {
+	names := FlagNames(f)
+	if context.Source(names[0]) == "flag" {
+		return nil
+	}
+	isName := map[string]bool{}
+	for _, name := range names {
+		isName[name] = true
+	}
+	found := false
+	for _, entry := range entries {
+		if !isName[entry.name] {
+			continue
+		}
+		found = true
+		if err := context.Set(names[0], entry.value); err != nil {
+			return fmt.Errorf("%s:%d: %s", path, entry.line, err)
+		}
+	}
+	if found {
+		context.flagSet.NeedsVisit(names[1:]...)
+		setFlagSource(context.flagSet, names, "file", path)
+	}
+	return nil
+}
+
+// knownFlagNames returns the names of the flags of the App and of all of
+// its commands and their subcommands
+func (a *App) knownFlagNames() map[string]bool {
+	known := map[string]bool{}
+	addFlags := func(flags []Flag) {
+		for _, f := range flags {
+			for _, name := range FlagNames(f) {
+				known[name] = true
+			}
+		}
+	}
+	var addCommands func(commands []*Command)
+	addCommands = func(commands []*Command) {
+		for _, c := range commands {
+			addFlags(c.Flags)
+			addCommands(c.Subcommands)
+		}
+	}
+	addFlags(a.allFlags())
+	addCommands(a.Commands)
+	return known
+}
@@ -14,6 +14,10 @@ type AfterFunc func(*Context) error
 // ActionFunc is the action to execute when no subcommands are specified
 type ActionFunc func(*Context) error
 
+// MiddlewareFunc wraps an ActionFunc, returning an ActionFunc which may run
+// code before and after calling the wrapped ActionFunc, or not call it at all
+type MiddlewareFunc func(ActionFunc) ActionFunc
+
 // CommandNotFoundFunc is executed if the proper command cannot be found
 type CommandNotFoundFunc func(*Context, string)
 
@@ -42,3 +46,8 @@ type FlagEnvHintFunc func(envVars []string, str string) string
 // FlagFileHintFunc is used by the default FlagStringFunc to annotate flag help
 // with the file path details.
 type FlagFileHintFunc func(filePath, str string) string
+
+// TranslatorFunc returns the user-facing message for a message key, such as
+// MsgRequiredFlag, formatted with args. It should return the result of
+// DefaultTranslator for any keys it does not translate.
+type TranslatorFunc func(key string, args ...interface{}) string
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rancher/spur/flag"
+	"github.com/rancher/spur/generic"
+)
+
+// newGenerateConfigFlag returns the flag named by GenerateConfig
+func (a *App) newGenerateConfigFlag() Flag {
+	return &BoolFlag{
+		Name:   a.GenerateConfig,
+		Usage:  "write the value of each flag as a JSON config file and exit",
+		Hidden: true,
+	}
+}
+
+// generateConfig returns true if the GenerateConfig flag of the App of the
+// context is set
+func generateConfig(context *Context) bool {
+	a := context.App
+	return a.GenerateConfig != "" && isSetIn(context.flagSet, a.GenerateConfig) && context.Bool(a.GenerateConfig)
+}
+
+// writeConfig writes the resolved value of each flag of the App to its
+// Writer as a JSON object keyed by the flag names, which may be loaded as a
+// config file by altsrc. The flags of commands are not written. Secret flags
+// are omitted, or written as secretMask with GenerateConfigMaskSecrets.
+func writeConfig(context *Context) error {
+	a := context.App
+	config := map[string]interface{}{}
+	for _, f := range context.GetFlags() {
+		name := FlagNames(f)[0]
+		if f == HelpFlag || f == VersionFlag || f == BashCompletionFlag || f == a.versionFlag ||
+			name == a.GenerateConfig || name == a.FlagsFile {
+			continue
+		}
+		if secret, _ := getFlagSecret(f); secret {
+			if a.GenerateConfigMaskSecrets {
+				config[name] = secretMask
+			}
+			continue
+		}
+		if fs := lookupFlagSet(name, context); fs != nil {
+			config[name] = configValue(fs.Lookup(name).Value)
+		}
+	}
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not generate config: %s", err)
+	}
+	_, err = fmt.Fprintf(a.Writer, "%s\n", b)
+	return err
+}
+
+// configValue returns the value of a flag as written by writeConfig, which
+// is the value itself for bools, numbers and string maps, a list of the
+// elements of a slice, or otherwise the string the value is parsed from
+// such as "1m30s" for a duration
+func configValue(value flag.Value) interface{} {
+	getter, ok := unwrapGeneric(value).(flag.Getter)
+	if !ok {
+		return value.String()
+	}
+	v := getter.Get()
+	if _, ok := v.([]byte); !ok && generic.IsSlice(v) {
+		elems := make([]interface{}, generic.Len(v))
+		for i := range elems {
+			elems[i] = configElem(generic.Index(v, i))
+		}
+		return elems
+	}
+	switch v.(type) {
+	case bool, int, int64, uint, uint64, float64, map[string]string:
+		return v
+	}
+	return value.String()
+}
+
+// configElem returns an element of a slice as written by writeConfig, which
+// is the element itself for bools, numbers and strings, or otherwise the
+// string the element is parsed from
+func configElem(v interface{}) interface{} {
+	switch v.(type) {
+	case bool, int, int64, uint, uint64, float64, string:
+		return v
+	}
+	if s, ok := generic.ToString(v); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
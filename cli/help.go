@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"text/template"
@@ -14,27 +16,32 @@ var helpCommand = &Command{
 	Name:      "help",
 	Aliases:   []string{"h"},
 	Usage:     "Shows a list of commands or help for one command",
-	ArgsUsage: "[command]",
-	Action: func(c *Context) error {
+	ArgsUsage: "[command...]",
+}
+
+func init() {
+	// set here as showing help for a command path refers back to
+	// helpCommand through App.Setup
+	helpCommand.Action = func(c *Context) error {
 		args := c.Args()
 		if args.Present() {
-			return ShowCommandHelp(c, args.First())
+			return showCommandPathHelp(c, args.Slice())
 		}
 
 		ShowAppHelp(c)
 		return nil
-	},
+	}
 }
 
 var helpSubcommand = &Command{
 	Name:      "help",
 	Aliases:   []string{"h"},
 	Usage:     "Shows a list of commands or help for one command",
-	ArgsUsage: "[command]",
+	ArgsUsage: "[command...]",
 	Action: func(c *Context) error {
 		args := c.Args()
 		if args.Present() {
-			return ShowCommandHelp(c, args.First())
+			return showCommandPathHelp(c, args.Slice())
 		}
 
 		return ShowSubcommandHelp(c)
@@ -64,6 +71,19 @@ var HelpPrinterCustom helpPrinterCustom = printHelpCustom
 // VersionPrinter prints the version for the App
 var VersionPrinter = printVersion
 
+// DefaultHelpWidth is the width returned by HelpWidth, and given to the
+// wrap template function, when the terminal width cannot be determined.
+// Help is only wrapped to a terminal or to the width of <APPNAME>_HELP_WIDTH,
+// so help written to a file or pipe is never wrapped at this width.
+var DefaultHelpWidth = 80
+
+const (
+	// helpPadding is the padding used by the help tabwriter between columns.
+	helpPadding = 2
+	// minHelpWrapWidth is the narrowest column that wrapping will produce.
+	minHelpWrapWidth = 20
+)
+
 // ShowAppHelpAndExit - Prints the list of subcommands for the app and exits with exit code.
 func ShowAppHelpAndExit(c *Context, exitCode int) {
 	ShowAppHelp(c)
@@ -159,11 +179,47 @@ func printFlagSuggestions(lastArg string, flags []Flag, writer io.Writer) {
 	}
 }
 
+// FileCompletionMarker is printed by shell completion in place of the
+// values of a flag with TakesFile set, for the completion scripts in
+// autocomplete to complete file names instead
+const FileCompletionMarker = ":files:"
+
+// printFlagValueSuggestions prints the Choices of the flag named by
+// lastArg, or FileCompletionMarker if the flag takes a file, returning
+// false if the flag has neither
+func printFlagValueSuggestions(lastArg string, flags []Flag, writer io.Writer) bool {
+	name := strings.TrimLeft(lastArg, "-")
+	for _, f := range flags {
+		for _, n := range FlagNames(f) {
+			if n != name {
+				continue
+			}
+			choices, _ := getFlagChoices(f)
+			for _, choice := range choices {
+				fmt.Fprintln(writer, choice)
+			}
+			if takesFile, _ := getFlagTakesFile(f); takesFile && len(choices) == 0 {
+				fmt.Fprintln(writer, FileCompletionMarker)
+				return true
+			}
+			return len(choices) > 0
+		}
+	}
+	return false
+}
+
 func DefaultCompleteWithFlags(cmd *Command) func(c *Context) {
 	return func(c *Context) {
 		if len(os.Args) > 2 {
 			lastArg := os.Args[len(os.Args)-2]
 			if strings.HasPrefix(lastArg, "-") {
+				flags := c.App.Flags
+				if cmd != nil {
+					flags = append(append([]Flag(nil), flags...), cmd.Flags...)
+				}
+				if printFlagValueSuggestions(lastArg, flags, c.App.Writer) {
+					return
+				}
 				printFlagSuggestions(lastArg, c.App.Flags, c.App.Writer)
 				if cmd != nil {
 					printFlagSuggestions(lastArg, cmd.Flags, c.App.Writer)
@@ -200,27 +256,97 @@ func ShowCommandHelp(ctx *Context, command string) error {
 				templ = CommandHelpTemplate
 			}
 
-			HelpPrinter(ctx.App.Writer, templ, c)
+			HelpPrinter(ctx.App.Writer, templ, commandHelpData(c, ctx.App))
 
 			return nil
 		}
 	}
 
 	if ctx.App.CommandNotFound == nil {
-		return Exit(fmt.Sprintf("No help topic for '%v'", command), 3)
+		return Exit(ctx.App.translate(MsgNoHelpTopic, command), 3)
 	}
 
 	ctx.App.CommandNotFound(ctx, command)
 	return nil
 }
 
+// showCommandPathHelp prints help for the command found by following the
+// names in path through the commands and subcommands of the App, stopping
+// at the deepest matching command
+func showCommandPathHelp(ctx *Context, path []string) error {
+	helpName := ctx.App.HelpName
+	commands := ctx.App.Commands
+	var command *Command
+	for _, name := range path {
+		next := findCommand(commands, name)
+		if next == nil {
+			break
+		}
+		command = next
+		helpName = fmt.Sprintf("%s %s", helpName, command.Name)
+		if command.HelpName == "" {
+			command.HelpName = helpName
+		}
+		commands = command.Subcommands
+	}
+
+	if command == nil {
+		return ShowCommandHelp(ctx, path[0])
+	}
+
+	templ := command.CustomHelpTemplate
+	if len(command.Subcommands) == 0 {
+		if templ == "" {
+			templ = CommandHelpTemplate
+		}
+		HelpPrinter(ctx.App.Writer, templ, commandHelpData(command, ctx.App))
+		return nil
+	}
+
+	// render the subcommands as they are when the command is run
+	app := &App{
+		Name:            command.HelpName,
+		HelpName:        command.HelpName,
+		Usage:           command.Usage,
+		UsageText:       command.UsageText,
+		Description:     command.Description,
+		ArgsUsage:       command.ArgsUsage,
+		Commands:        command.Subcommands,
+		Flags:           append([]Flag(nil), command.Flags...),
+		HideHelp:        command.HideHelp,
+		HideHelpCommand: command.HideHelpCommand,
+		HideVersion:     true,
+		Writer:          ctx.App.Writer,
+		Translator:      ctx.App.Translator,
+	}
+	app.Setup()
+	if templ == "" {
+		templ = SubcommandHelpTemplate
+	}
+	HelpPrinter(ctx.App.Writer, templ, app)
+	return nil
+}
+
+// commandHelpData returns a copy of c to render the help of, run by app
+// unless c is already being run by an App, so that the translator and
+// persistent flags of the App are available to the templates without
+// changing the Command which may be shared between Apps
+func commandHelpData(c *Command, app *App) *Command {
+	data := *c
+	if data.app == nil {
+		data.app = app
+	}
+	return &data
+}
+
 // ShowSubcommandHelp prints help for the given subcommand
 func ShowSubcommandHelp(c *Context) error {
 	if c == nil {
 		return nil
 	}
 
-	if c.Command != nil {
+	// a command with subcommands is run as the App of c
+	if c.Command != nil && len(c.Command.Subcommands) == 0 {
 		return ShowCommandHelp(c, c.Command.Name)
 	}
 
@@ -229,11 +355,15 @@ func ShowSubcommandHelp(c *Context) error {
 
 // ShowVersion prints the version number of the App
 func ShowVersion(c *Context) {
+	if c.App.VersionPrinter != nil {
+		c.App.VersionPrinter(c)
+		return
+	}
 	VersionPrinter(c)
 }
 
 func printVersion(c *Context) {
-	fmt.Fprintf(c.App.Writer, "%v version %v\n", c.App.Name, c.App.Version)
+	fmt.Fprintln(c.App.Writer, c.App.translate(MsgVersion, c.App.Name, c.App.Version))
 }
 
 // ShowCompletions prints the lists of commands within a given context
@@ -271,18 +401,17 @@ func FlagToString(f Flag) string {
 // The customFuncs map will be combined with a default template.FuncMap to
 // allow using arbitrary functions in template rendering.
 func printHelpCustom(out io.Writer, templ string, data interface{}, customFuncs map[string]interface{}) {
-	funcMap := template.FuncMap{
-		"join":         strings.Join,
-		"FlagToString": FlagToString,
-	}
+	width, wrap := HelpWidth(helpAppName(data), out)
+	funcMap := templateFuncs(data, width)
 	for key, value := range customFuncs {
 		funcMap[key] = value
 	}
 
-	w := tabwriter.NewWriter(out, 1, 8, 2, ' ', 0)
+	w := tabwriter.NewWriter(out, 1, 8, helpPadding, ' ', 0)
 	t := template.Must(template.New("help").Funcs(funcMap).Parse(templ))
 
-	err := t.Execute(w, data)
+	var buf bytes.Buffer
+	err := t.Execute(&buf, data)
 	if err != nil {
 		// If the writer is closed, t.Execute will fail, and there's nothing
 		// we can do to recover.
@@ -291,7 +420,152 @@ func printHelpCustom(out io.Writer, templ string, data interface{}, customFuncs
 		}
 		return
 	}
+	text := buf.String()
+	if wrap {
+		text = wrapHelpColumns(text, width)
+	}
+	io.WriteString(w, text)
 	w.Flush()
+	flushWriter(out)
+}
+
+// TemplateFuncs returns the functions used by the help templates, such as
+// translate, for rendering the help of data written to w. A custom
+// HelpPrinter which parses AppHelpTemplate, CommandHelpTemplate or
+// SubcommandHelpTemplate must add these functions to the template.
+func TemplateFuncs(w io.Writer, data interface{}) template.FuncMap {
+	width, _ := HelpWidth(helpAppName(data), w)
+	return templateFuncs(data, width)
+}
+
+// templateFuncs returns the functions used by the help templates for data
+// wrapped at width
+func templateFuncs(data interface{}, width int) template.FuncMap {
+	return template.FuncMap{
+		"join":             strings.Join,
+		"FlagToString":     FlagToString,
+		"humanizeDuration": HumanizeDuration,
+		"translate":        helpTranslator(data),
+		"helpWidth":        func() int { return width },
+		"wrap":             func(s string, width int) string { return strings.Join(wrapText(s, width), "\n") },
+	}
+}
+
+// flushWriter flushes w if it is buffered, such as a bufio.Writer, so that
+// help is written before any error text that follows it on ErrWriter
+func flushWriter(w io.Writer) {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+}
+
+// HelpWidth returns the width used for wrapping help text written to w, and
+// whether wrapping should be applied. The environment variable
+// <APPNAME>_HELP_WIDTH overrides the width, otherwise the width of the
+// terminal attached to w is used. If neither is available, such as when w
+// is a file or pipe, DefaultHelpWidth is returned and wrapping is disabled.
+func HelpWidth(appName string, w io.Writer) (int, bool) {
+	if appName != "" {
+		if v, ok := os.LookupEnv(helpWidthEnvVar(appName)); ok {
+			if width, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && width > 0 {
+				return width, true
+			}
+		}
+	}
+	if width, ok := TerminalWidth(w); ok {
+		return width, true
+	}
+	return DefaultHelpWidth, false
+}
+
+func helpWidthEnvVar(appName string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, appName)
+	return name + "_HELP_WIDTH"
+}
+
+func helpAppName(data interface{}) string {
+	var name string
+	switch d := data.(type) {
+	case *App:
+		name = d.Name
+	case *Command:
+		name = d.HelpName
+	}
+	if fields := strings.Fields(name); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// wrapHelpColumns wraps the last column of tab separated lines so that
+// the aligned output fits within width, indenting continuation lines to
+// the start of the column.
+func wrapHelpColumns(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	var result []string
+	for i := 0; i < len(lines); {
+		if strings.Count(lines[i], "\t") != 1 {
+			result = append(result, lines[i])
+			i++
+			continue
+		}
+		// find the block of aligned lines and the width of the first column
+		j, column := i, 0
+		for ; j < len(lines) && strings.Count(lines[j], "\t") == 1; j++ {
+			cell := lines[j][:strings.Index(lines[j], "\t")]
+			if n := utf8.RuneCountInString(cell); n > column {
+				column = n
+			}
+		}
+		column += helpPadding
+		if width-column < minHelpWrapWidth {
+			result = append(result, lines[i:j]...)
+			i = j
+			continue
+		}
+		for _, line := range lines[i:j] {
+			index := strings.Index(line, "\t")
+			cell, desc := line[:index], line[index+1:]
+			indent := cell[:len(cell)-len(strings.TrimLeft(cell, " "))]
+			for n, part := range wrapText(desc, width-column) {
+				if n == 0 {
+					result = append(result, cell+"\t"+part)
+				} else {
+					result = append(result, indent+"\t"+part)
+				}
+			}
+		}
+		i = j
+	}
+	return strings.Join(result, "\n")
+}
+
+// wrapText splits text into lines of at most width runes, breaking on
+// spaces. Words longer than width are kept whole.
+func wrapText(text string, width int) []string {
+	words := strings.Fields(text)
+	if width <= 0 || len(words) == 0 || utf8.RuneCountInString(text) <= width {
+		return []string{text}
+	}
+	var lines []string
+	line := words[0]
+	for _, word := range words[1:] {
+		if utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line += " " + word
+	}
+	return append(lines, line)
 }
 
 func printHelp(out io.Writer, templ string, data interface{}) {
@@ -299,8 +573,11 @@ func printHelp(out io.Writer, templ string, data interface{}) {
 }
 
 func checkVersion(c *Context) bool {
+	if c.App.versionFlag == nil {
+		return false
+	}
 	found := false
-	for _, name := range FlagNames(VersionFlag) {
+	for _, name := range FlagNames(c.App.versionFlag) {
 		if c.Bool(name) {
 			found = true
 		}
@@ -1,13 +1,16 @@
 package cli
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"runtime"
 	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/rancher/spur/flag"
 )
@@ -117,6 +120,51 @@ func Test_Version_Custom_Flags(t *testing.T) {
 	}
 }
 
+func Test_Version_Conflicting_Flags(t *testing.T) {
+	verbose := false
+	app := &App{
+		Name:    "test",
+		Version: "1.2.3",
+		Flags: []Flag{
+			&BoolFlag{Name: "verbose", Aliases: []string{"v"}, Destination: &verbose},
+		},
+		Action: func(ctx *Context) error {
+			return nil
+		},
+	}
+	output := new(bytes.Buffer)
+	app.Writer = output
+
+	err := app.Run([]string{"test", "-v"})
+	expect(t, err, nil)
+	expect(t, verbose, true)
+	expect(t, output.String(), "")
+
+	err = app.Run([]string{"test", "--version"})
+	expect(t, err, nil)
+	expect(t, output.String(), "test version 1.2.3\n")
+}
+
+func Test_Version_App_Printer(t *testing.T) {
+	output := new(bytes.Buffer)
+	app := &App{
+		Name:    "test",
+		Version: "1.2.3",
+		Writer:  output,
+		VersionPrinter: func(c *Context) {
+			fmt.Fprintf(c.App.Writer, "v%s\n", c.App.Version)
+		},
+		Action: func(ctx *Context) error {
+			t.Errorf("action should not run")
+			return nil
+		},
+	}
+
+	err := app.Run([]string{"test", "-v"})
+	expect(t, err, nil)
+	expect(t, output.String(), "v1.2.3\n")
+}
+
 func Test_helpCommand_Action_ErrorIfNoTopic(t *testing.T) {
 	app := &App{}
 
@@ -145,6 +193,65 @@ func Test_helpCommand_Action_ErrorIfNoTopic(t *testing.T) {
 	}
 }
 
+func Test_helpCommand_CommandPath(t *testing.T) {
+	app := &App{
+		Name:     "app",
+		HelpName: "app",
+		Commands: []*Command{
+			{
+				Name:  "deploy",
+				Usage: "manage deployments",
+				Subcommands: []*Command{
+					{
+						Name:  "rollout",
+						Usage: "roll out a release",
+						Flags: []Flag{&StringFlag{Name: "image", Usage: "image to deploy"}},
+					},
+					{
+						Name:  "history",
+						Usage: "show release history",
+						Subcommands: []*Command{
+							{Name: "prune", Usage: "remove old releases"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		args     []string
+		contains []string
+	}{
+		{[]string{"app", "help", "deploy"}, []string{"app deploy - manage deployments", "rollout", "history"}},
+		{[]string{"app", "help", "deploy", "rollout"}, []string{"app deploy rollout - roll out a release", "--image value"}},
+		{[]string{"app", "help", "deploy", "history"}, []string{"app deploy history - show release history", "prune"}},
+		{[]string{"app", "help", "deploy", "history", "prune"}, []string{"app deploy history prune - remove old releases"}},
+		{[]string{"app", "help", "deploy", "missing"}, []string{"app deploy - manage deployments"}},
+		{[]string{"app", "deploy", "help", "rollout"}, []string{"roll out a release", "--image value"}},
+	}
+	for _, test := range tests {
+		output := &bytes.Buffer{}
+		app.Writer = output
+		if err := app.Run(test.args); err != nil {
+			t.Fatalf("unexpected error for %v: %s", test.args, err)
+		}
+		for _, s := range test.contains {
+			if !strings.Contains(output.String(), s) {
+				t.Errorf("expected help for %v to contain %q, got %q", test.args, s, output.String())
+			}
+		}
+	}
+
+	output := &bytes.Buffer{}
+	app.Writer = output
+	app.ExitErrHandler = func(*Context, error) {}
+	err := app.Run([]string{"app", "help", "missing", "deploy"})
+	if err == nil || !strings.HasPrefix(err.Error(), "No help topic for 'missing'") {
+		t.Errorf("expected an unknown help topic error, got %v", err)
+	}
+}
+
 func Test_helpCommand_InHelpOutput(t *testing.T) {
 	app := &App{}
 	output := &bytes.Buffer{}
@@ -212,6 +319,35 @@ func TestShowAppHelp_CommandAliases(t *testing.T) {
 	}
 }
 
+func TestTemplateFuncs(t *testing.T) {
+	old := HelpPrinter
+	defer func() {
+		HelpPrinter = old
+	}()
+	HelpPrinter = func(w io.Writer, templ string, data interface{}) {
+		tmpl := template.Must(template.New("help").Funcs(TemplateFuncs(w, data)).Parse(templ))
+		if err := tmpl.Execute(w, data); err != nil {
+			t.Error(err)
+		}
+	}
+
+	cmd := &Command{Name: "frobbly", Usage: "frob the bly"}
+	output := new(bytes.Buffer)
+	app := &App{
+		Writer:     output,
+		Commands:   []*Command{cmd},
+		Translator: func(key string, args ...interface{}) string { return strings.ToLower(DefaultTranslator(key, args...)) },
+	}
+	_ = app.Run([]string{"app", "help", "frobbly"})
+
+	if !strings.Contains(output.String(), "name:") || !strings.Contains(output.String(), "frob the bly") {
+		t.Errorf("expected translated command help; got: %q", output.String())
+	}
+	if cmd.app != nil {
+		t.Errorf("expected showing help not to set the App of the command")
+	}
+}
+
 func TestShowCommandHelp_HelpPrinter(t *testing.T) {
 	doublecho := func(text string) string {
 		return text + " " + text
@@ -908,3 +1044,207 @@ func TestHideHelpCommand_WithSubcommands(t *testing.T) {
 		t.Errorf("Run returned unexpected error: %v", err)
 	}
 }
+
+func TestShowAppHelp_WrapsToHelpWidth(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+	os.Setenv("MY_APP_HELP_WIDTH", "40")
+
+	output := new(bytes.Buffer)
+	app := &App{
+		Name:   "my-app",
+		Writer: output,
+		Flags: []Flag{
+			&BoolFlag{Name: "dry-run", Usage: "print the actions that would be taken without running them"},
+		},
+	}
+	_ = app.Run([]string{"my-app", "--help"})
+
+	expected := "   --dry-run   print the actions that\n" +
+		"               would be taken without\n" +
+		"               running them (default:\n" +
+		"               false)\n"
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected wrapped flag usage %q, got:\n%s", expected, output.String())
+	}
+}
+
+func TestShowAppHelp_NoWrapWithoutTerminal(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	usage := "print the actions that would be taken without running them, " +
+		"along with the commands and files they would change"
+	output := new(bytes.Buffer)
+	app := &App{
+		Name:   "my-app",
+		Writer: output,
+		Flags:  []Flag{&BoolFlag{Name: "dry-run", Usage: usage}},
+	}
+	_ = app.Run([]string{"my-app", "--help"})
+
+	expected := "   --dry-run   " + usage + " (default: false)\n"
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected unwrapped flag usage %q, got:\n%s", expected, output.String())
+	}
+}
+
+func TestHelpWidth(t *testing.T) {
+	defer resetEnv(os.Environ())
+	os.Clearenv()
+
+	width, wrap := HelpWidth("myapp", ioutil.Discard)
+	expect(t, width, DefaultHelpWidth)
+	expect(t, wrap, false)
+
+	os.Setenv("MYAPP_HELP_WIDTH", "120")
+	width, wrap = HelpWidth("myapp", ioutil.Discard)
+	expect(t, width, 120)
+	expect(t, wrap, true)
+}
+
+func TestShowAppHelp_SortFlagsAndCommands(t *testing.T) {
+	newApp := func(sorted bool) *App {
+		return &App{
+			Name:         "app",
+			SortFlags:    sorted,
+			SortCommands: sorted,
+			Flags: []Flag{
+				&BoolFlag{Name: "zeta"},
+				&BoolFlag{Name: "Alpha"},
+				&BoolFlag{Name: "beta", Aliases: []string{"a"}},
+			},
+			Commands: []*Command{
+				{Name: "remove", Category: "files"},
+				{Name: "add", Category: "files"},
+				{Name: "status"},
+				{Name: "commit"},
+				{
+					Name:  "config",
+					Flags: []Flag{&StringFlag{Name: "value"}, &StringFlag{Name: "key"}},
+				},
+			},
+		}
+	}
+	indexes := func(s string, subs ...string) []int {
+		var result []int
+		for _, sub := range subs {
+			result = append(result, strings.Index(s, sub))
+		}
+		return result
+	}
+	ordered := func(s string, subs ...string) bool {
+		last := -1
+		for _, i := range indexes(s, subs...) {
+			if i <= last {
+				return false
+			}
+			last = i
+		}
+		return true
+	}
+
+	output := new(bytes.Buffer)
+	app := newApp(false)
+	app.Writer = output
+	expect(t, app.Run([]string{"app", "--help"}), nil)
+	expect(t, ordered(output.String(), "--zeta", "--Alpha", "--beta"), true)
+	expect(t, ordered(output.String(), "   status", "   commit", "   config", "files:", "     remove", "     add"), true)
+
+	output.Reset()
+	app = newApp(true)
+	app.Writer = output
+	expect(t, app.Run([]string{"app", "--help"}), nil)
+	expect(t, ordered(output.String(), "--Alpha", "--beta", "--help", "--zeta"), true)
+	expect(t, ordered(output.String(), "   commit", "   config", "   help", "   status", "files:", "     add", "     remove"), true)
+
+	output.Reset()
+	expect(t, app.Run([]string{"app", "config", "--help"}), nil)
+	expect(t, ordered(output.String(), "--help", "--key", "--value"), true)
+	expect(t, FlagNames(app.Flags[0])[0], "zeta")
+}
+
+func TestHelpFlushedBeforeErrors(t *testing.T) {
+	var output bytes.Buffer
+	writer := bufio.NewWriter(&output)
+	newApp := func(action ActionFunc) *App {
+		return &App{
+			Name:      "app",
+			Writer:    writer,
+			ErrWriter: &output,
+			Flags:     []Flag{&StringFlag{Name: "mode"}},
+			Action:    action,
+			ExitErrHandler: func(ctx *Context, err error) {
+				fmt.Fprintln(ctx.App.ErrWriter, "error:", err)
+			},
+		}
+	}
+	order := func(text ...string) {
+		t.Helper()
+		last := -1
+		for _, s := range text {
+			i := strings.Index(output.String(), s)
+			if i <= last {
+				t.Fatalf("expected %q in order in output:\n%s", text, output.String())
+			}
+			last = i
+		}
+	}
+
+	_ = newApp(func(ctx *Context) error {
+		ShowAppHelp(ctx)
+		return Exit("boom", 3)
+	}).Run([]string{"app"})
+	order("USAGE:", "--mode value", "error: boom")
+
+	output.Reset()
+	_ = newApp(nil).Run([]string{"app", "--bogus"})
+	order("Incorrect Usage:", "USAGE:", "--mode value", "error: flag provided but not defined: -bogus")
+}
+
+func TestShowHelp_Examples(t *testing.T) {
+	output := new(bytes.Buffer)
+	app := &App{
+		Name:   "app",
+		Writer: output,
+		Examples: []*Example{
+			{Command: "app serve", Usage: "Serve on the default port"},
+			{Command: "app serve --port 8080", Usage: "Serve on port 8080"},
+			{Command: "app version"},
+		},
+		Commands: []*Command{{
+			Name: "serve",
+			Examples: []*Example{
+				{Command: "app serve -p 80", Usage: "Serve on port 80"},
+			},
+			Action: func(ctx *Context) error { return nil },
+		}},
+	}
+
+	expect(t, app.Run([]string{"app", "--help"}), nil)
+	expected := `EXAMPLES:
+   app serve              Serve on the default port
+   app serve --port 8080  Serve on port 8080
+   app version
+
+`
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected\n%s\nto contain\n%s", output.String(), expected)
+	}
+
+	output.Reset()
+	expect(t, app.Run([]string{"app", "serve", "--help"}), nil)
+	expected = `EXAMPLES:
+   app serve -p 80  Serve on port 80
+`
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected\n%s\nto contain\n%s", output.String(), expected)
+	}
+
+	output.Reset()
+	app.Examples = nil
+	expect(t, app.Run([]string{"app", "--help"}), nil)
+	if strings.Contains(output.String(), "EXAMPLES:") {
+		t.Errorf("expected\n%s\nnot to contain EXAMPLES:", output.String())
+	}
+}
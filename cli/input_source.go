@@ -36,6 +36,7 @@ func ApplyInputSourceValue(f Flag, context *Context, isc InputSourceContext) err
 			if err := context.Set(name, value); err != nil {
 				return fmt.Errorf("unable to apply input source '%s': %s", isc.Source(), err)
 			}
+			setFlagSource(context.flagSet, FlagNames(f), "altsrc", "")
 		}
 	}
 	return nil
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// promptMissingFlags prompts on stdin for the required flags which are not
+// set if App.PromptMissing is set and stdin is a terminal
+func promptMissingFlags(flags []Flag, context *Context) error {
+	if context.App == nil || !context.App.PromptMissing || !isTerminal(os.Stdin) {
+		return nil
+	}
+	noEcho := func() (func(), error) {
+		return disableEcho(os.Stdin)
+	}
+	return promptFlags(flags, context, bufio.NewReader(os.Stdin), noEcho)
+}
+
+// promptFlags prompts for and reads a line from in for each required flag
+// which is not set, setting the flag from the line if it is not empty. The
+// echo of input is disabled with noEcho while reading the value of a
+// Secret flag.
+func promptFlags(flags []Flag, context *Context, in *bufio.Reader, noEcho func() (func(), error)) error {
+	w := context.App.ErrWriter
+	for _, f := range flags {
+		name, missing := missingRequiredFlag(f, context)
+		if !missing {
+			continue
+		}
+		prompt := prefixFor(name) + name
+		if usage, _ := getFlagUsage(f); usage != "" {
+			prompt += " (" + usage + ")"
+		}
+		fmt.Fprintf(w, "Enter value for %s: ", prompt)
+		secret, _ := getFlagSecret(f)
+		var restore func()
+		if secret {
+			var err error
+			if restore, err = noEcho(); err != nil {
+				return err
+			}
+		}
+		line, err := in.ReadString('\n')
+		if restore != nil {
+			restore()
+			fmt.Fprintln(w)
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		value := strings.TrimRight(line, "\r\n")
+		if value == "" {
+			continue
+		}
+		names := FlagNames(f)
+		if err := context.Set(names[0], value); err != nil {
+			if secret {
+				value = secretMask
+			}
+			return fmt.Errorf("invalid value %q for flag %s: %s", value, prompt, err)
+		}
+		context.flagSet.NeedsVisit(names[1:]...)
+		setFlagSource(context.flagSet, names, "prompt", "")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPromptFlags(t *testing.T) {
+	var out bytes.Buffer
+	app := &App{ErrWriter: &out}
+	flags := []Flag{
+		&StringFlag{Name: "name", Aliases: []string{"n"}, Usage: "your name", Required: true},
+		&IntFlag{Name: "port", Required: true},
+		&StringFlag{Name: "token", Required: true, Secret: true},
+		&StringFlag{Name: "region", Required: true},
+		&StringFlag{Name: "set", Required: true},
+		&StringFlag{Name: "optional"},
+	}
+	set, err := flagSet("test", flags, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, set.Parse([]string{"--set", "x"}), nil)
+	ctx := NewContext(app, set, nil)
+
+	echo := true
+	noEcho := func() (func(), error) {
+		echo = false
+		return func() { echo = true }, nil
+	}
+	in := bufio.NewReader(strings.NewReader("alice\n8080\r\ns3cret\n\n"))
+	expect(t, promptFlags(flags, ctx, in, noEcho), nil)
+	expect(t, echo, true)
+	expect(t, out.String(), "Enter value for --name (your name): "+
+		"Enter value for --port: "+
+		"Enter value for --token: \n"+
+		"Enter value for --region: ")
+
+	expect(t, ctx.String("name"), "alice")
+	expect(t, ctx.IsSet("n"), true)
+	expect(t, ctx.Source("name"), "prompt")
+	expect(t, ctx.Int("port"), 8080)
+	expect(t, ctx.String("token"), "s3cret")
+	expect(t, ctx.IsSet("region"), false)
+	expect(t, checkRequiredFlags(flags, ctx).Error(), "Required flag \"region\" not set")
+
+	set, _ = flagSet("test", flags, false)
+	ctx = NewContext(app, set, nil)
+	in = bufio.NewReader(strings.NewReader("alice\nnot-a-port\n"))
+	err = promptFlags(flags, ctx, in, noEcho)
+	expect(t, err != nil && strings.Contains(err.Error(), `invalid value "not-a-port" for flag --port`), true)
+}
+
+func TestApp_PromptMissingNotTerminal(t *testing.T) {
+	if isTerminal(os.Stdin) {
+		t.Skip("stdin is a terminal")
+	}
+	app := &App{
+		Writer:        &bytes.Buffer{},
+		PromptMissing: true,
+		Flags:         []Flag{&StringFlag{Name: "name", Required: true}},
+		Action:        func(ctx *Context) error { return nil },
+	}
+	err := app.Run([]string{"run"})
+	expect(t, err != nil && strings.Contains(err.Error(), `Required flag "name" not set`), true)
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/rancher/spur/flag"
+)
+
+// Resolver finds the value of a flag from a source other than the command
+// line, such as the environment or a file
+type Resolver interface {
+	// Resolve returns the value found and true, or false if the source
+	// has no value
+	Resolve() (string, bool)
+}
+
+// DefaultResolver may be implemented by the Value of a flag to provide a
+// default which is resolved each time the flag is applied, such as the
+// current working directory. The result is passed to the Set method of a
+// new copy of the Value. The resolved default has the lowest precedence,
+// after the command line, environment variables, files and Resolvers.
+type DefaultResolver interface {
+	Resolve() (interface{}, error)
+}
+
+// ValueSource provides the values of flags from a source consulted after
+// parsing, such as a remote configuration service, with a lower precedence
+// than the command line, environment variables, files and Resolvers
+type ValueSource interface {
+	// Value returns the value for the named flag and true, or false if the
+	// source has no value. The value may be a string or of the type of the
+	// flag, and is passed to the Set method of the flag.
+	Value(name string) (interface{}, bool, error)
+}
+
+// EnvResolver resolves a value from the first of the named environment
+// variables which is set
+type EnvResolver []string
+
+// Resolve implements Resolver
+func (r EnvResolver) Resolve() (string, bool) {
+	return flagFromEnv(r)
+}
+
+// FileResolver resolves a value from the contents of the first of the
+// files which can be read
+type FileResolver []string
+
+// Resolve implements Resolver
+func (r FileResolver) Resolve() (string, bool) {
+	val, _, ok := r.resolvePath()
+	return val, ok
+}
+
+func (r FileResolver) resolvePath() (string, string, bool) {
+	for _, path := range r {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			return string(data), path, true
+		}
+	}
+	return "", "", false
+}
+
+// EnvNamedFileResolver resolves a value from the contents of the file whose
+// path is the value of the first of the named environment variables which
+// is set, such as a mounted secret in a container
+type EnvNamedFileResolver []string
+
+// Resolve implements Resolver
+func (r EnvNamedFileResolver) Resolve() (string, bool) {
+	val, _, ok := r.resolvePath()
+	return val, ok
+}
+
+func (r EnvNamedFileResolver) resolvePath() (string, string, bool) {
+	path, ok := flagFromEnv(r)
+	if !ok || path == "" {
+		return "", "", false
+	}
+	return FileResolver{path}.resolvePath()
+}
+
+// pathResolver is implemented by resolvers which read values from files to
+// also return the path of the file a value was read from
+type pathResolver interface {
+	resolvePath() (val, path string, ok bool)
+}
+
+// flagResolvers returns the Resolvers of a flag, or resolvers for its
+// EnvVars and FilePath if none are set
+func flagResolvers(f Flag) []Resolver {
+	if resolvers, _ := getFlagResolvers(f); resolvers != nil {
+		return resolvers
+	}
+	envVars, _ := getFlagEnvVars(f)
+	filePath, _ := getFlagFilePath(f)
+	return defaultResolvers(envVars, filePath)
+}
+
+func defaultResolvers(envVars []string, filePath string) []Resolver {
+	var resolvers []Resolver
+	if len(envVars) > 0 {
+		resolvers = append(resolvers, EnvResolver(envVars))
+	}
+	if filePath != "" {
+		resolvers = append(resolvers, FileResolver(strings.Split(filePath, ",")))
+	}
+	return resolvers
+}
+
+// resolve returns the value of the first resolver with a value, the
+// resolver it came from, and the path of the file it was read from if any
+func resolve(resolvers []Resolver) (string, Resolver, string, bool) {
+	for _, r := range resolvers {
+		if pr, ok := r.(pathResolver); ok {
+			if val, path, ok := pr.resolvePath(); ok {
+				return val, r, path, true
+			}
+		} else if val, ok := r.Resolve(); ok {
+			return val, r, "", true
+		}
+	}
+	return "", nil, "", false
+}
+
+// resolverSource returns the Context.Source of a value from the resolver
+func resolverSource(r Resolver) string {
+	switch r.(type) {
+	case EnvResolver:
+		return "env"
+	case pathResolver:
+		return "file"
+	}
+	return "resolver"
+}
+
+// setFlagSource records the source and path of the value of the named flags
+func setFlagSource(set *flag.FlagSet, names []string, source, path string) {
+	for _, name := range names {
+		if f := set.Lookup(name); f != nil {
+			f.Source, f.Path = source, path
+			if n := len(f.History); n > 0 {
+				f.History[n-1].Source, f.History[n-1].Path = source, path
+			}
+		}
+	}
+}
+
+// resolveValueSources sets each flag which is not set from the first of the
+// ValueSources of the App with a value for any of its names
+func resolveValueSources(flags []Flag, context *Context) error {
+	if context.App == nil || len(context.App.ValueSources) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, f := range flags {
+		if err := resolveValueSource(f, context); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs...)
+}
+
+func resolveValueSource(f Flag, context *Context) error {
+	names := FlagNames(f)
+	for _, name := range names {
+		if context.IsSet(name) {
+			return nil
+		}
+	}
+	for _, source := range context.App.ValueSources {
+		for _, name := range names {
+			value, ok, err := source.Value(name)
+			if err != nil {
+				return fmt.Errorf("could not read value source for flag %s: %s", names[0], err)
+			}
+			if !ok {
+				continue
+			}
+			if err := context.Set(names[0], value); err != nil {
+				return err
+			}
+			context.flagSet.NeedsVisit(names[1:]...)
+			setFlagSource(context.flagSet, names, "source", "")
+			return nil
+		}
+	}
+	return nil
+}
@@ -3,74 +3,95 @@ package cli
 // AppHelpTemplate is the text template for the Default help topic.
 // cli.go uses text/template to render templates. You can
 // render custom help text by setting this variable.
-var AppHelpTemplate = `NAME:
+var AppHelpTemplate = `{{translate "help.name"}}
    {{.Name}}{{if .Usage}} - {{.Usage}}{{end}}
 
-USAGE:
-   {{if .UsageText}}{{.UsageText}}{{else}}{{.HelpName}} {{if .VisibleFlags}}[global options]{{end}}{{if .Commands}} command [command options]{{end}} {{if .ArgsUsage}}{{.ArgsUsage}}{{else}}[arguments...]{{end}}{{end}}{{if .Version}}{{if not .HideVersion}}
+{{translate "help.usage"}}
+   {{if .UsageText}}{{.UsageText}}{{else}}{{.HelpName}} {{if .VisibleFlags}}{{translate "usage.global_options"}}{{end}}{{if .Commands}} {{translate "usage.command"}} {{translate "usage.options"}}{{end}} {{if .ArgsUsage}}{{.ArgsUsage}}{{else}}{{translate "usage.arguments"}}{{end}}{{end}}{{if .Version}}{{if not .HideVersion}}
 
-VERSION:
+{{translate "help.version"}}
    {{.Version}}{{end}}{{end}}{{if .Description}}
 
-DESCRIPTION:
-   {{.Description}}{{end}}{{if len .Authors}}
+{{translate "help.description"}}
+   {{.Description}}{{end}}{{if .Examples}}
 
-AUTHOR{{with $length := len .Authors}}{{if ne 1 $length}}S{{end}}{{end}}:
+{{translate "help.examples"}}{{range .Examples}}
+   {{.Command}}{{if .Usage}}{{"\t"}}{{.Usage}}{{end}}{{end}}{{end}}{{if len .Authors}}
+
+{{if eq 1 (len .Authors)}}{{translate "help.author"}}{{else}}{{translate "help.authors"}}{{end}}
    {{range $index, $author := .Authors}}{{if $index}}
    {{end}}{{$author}}{{end}}{{end}}{{if .VisibleCommands}}
 
-COMMANDS:{{range .VisibleCategories}}{{if .Name}}
+{{translate "help.commands"}}{{range .VisibleCategories}}{{if .Name}}
    {{.Name}}:{{range .VisibleCommands}}
      {{join .Names ", "}}{{"\t"}}{{.Usage}}{{end}}{{else}}{{range .VisibleCommands}}
    {{join .Names ", "}}{{"\t"}}{{.Usage}}{{end}}{{end}}{{end}}{{end}}{{if .VisibleFlags}}
 
-GLOBAL OPTIONS:
+{{translate "help.global_options"}}
    {{range $index, $option := .VisibleFlags}}{{if $index}}
+   {{end}}{{FlagToString $option}}{{end}}{{end}}{{if .VisiblePersistentFlags}}
+
+{{translate "help.global_flags"}}
+   {{range $index, $option := .VisiblePersistentFlags}}{{if $index}}
    {{end}}{{FlagToString $option}}{{end}}{{end}}{{if .Copyright}}
 
-COPYRIGHT:
+{{translate "help.copyright"}}
    {{.Copyright}}{{end}}
 `
 
 // CommandHelpTemplate is the text template for the command help topic.
 // cli.go uses text/template to render templates. You can
 // render custom help text by setting this variable.
-var CommandHelpTemplate = `NAME:
+var CommandHelpTemplate = `{{translate "help.name"}}
    {{.HelpName}} - {{.Usage}}
 
-USAGE:
-   {{if .UsageText}}{{.UsageText}}{{else}}{{.HelpName}}{{if .VisibleFlags}} [command options]{{end}} {{if .ArgsUsage}}{{.ArgsUsage}}{{else}}[arguments...]{{end}}{{end}}{{if .Category}}
+{{translate "help.usage"}}
+   {{if .UsageText}}{{.UsageText}}{{else}}{{.HelpName}}{{if .VisibleFlags}} {{translate "usage.options"}}{{end}} {{if .ArgsUsage}}{{.ArgsUsage}}{{else}}{{translate "usage.arguments"}}{{end}}{{end}}{{if .Category}}
 
-CATEGORY:
+{{translate "help.category"}}
    {{.Category}}{{end}}{{if .Description}}
 
-DESCRIPTION:
-   {{.Description}}{{end}}{{if .VisibleFlags}}
+{{translate "help.description"}}
+   {{.Description}}{{end}}{{if .Examples}}
 
-OPTIONS:
+{{translate "help.examples"}}{{range .Examples}}
+   {{.Command}}{{if .Usage}}{{"\t"}}{{.Usage}}{{end}}{{end}}{{end}}{{if .VisibleFlags}}
+
+{{translate "help.options"}}
    {{range .VisibleFlags}}{{FlagToString .}}
+   {{end}}{{end}}{{if .VisiblePersistentFlags}}{{if not .VisibleFlags}}
+{{end}}
+{{translate "help.global_flags"}}
+   {{range .VisiblePersistentFlags}}{{FlagToString .}}
    {{end}}{{end}}
 `
 
 // SubcommandHelpTemplate is the text template for the subcommand help topic.
 // cli.go uses text/template to render templates. You can
 // render custom help text by setting this variable.
-var SubcommandHelpTemplate = `NAME:
+var SubcommandHelpTemplate = `{{translate "help.name"}}
    {{.HelpName}} - {{.Usage}}
 
-USAGE:
-   {{if .UsageText}}{{.UsageText}}{{else}}{{.HelpName}} command{{if .VisibleFlags}} [command options]{{end}} {{if .ArgsUsage}}{{.ArgsUsage}}{{else}}[arguments...]{{end}}{{end}}{{if .Description}}
+{{translate "help.usage"}}
+   {{if .UsageText}}{{.UsageText}}{{else}}{{.HelpName}} {{translate "usage.command"}}{{if .VisibleFlags}} {{translate "usage.options"}}{{end}} {{if .ArgsUsage}}{{.ArgsUsage}}{{else}}{{translate "usage.arguments"}}{{end}}{{end}}{{if .Description}}
+
+{{translate "help.description"}}
+   {{.Description}}{{end}}{{if .Examples}}
 
-DESCRIPTION:
-   {{.Description}}{{end}}
+{{translate "help.examples"}}{{range .Examples}}
+   {{.Command}}{{if .Usage}}{{"\t"}}{{.Usage}}{{end}}{{end}}{{end}}
 
-COMMANDS:{{range .VisibleCategories}}{{if .Name}}
+{{translate "help.commands"}}{{range .VisibleCategories}}{{if .Name}}
    {{.Name}}:{{range .VisibleCommands}}
      {{join .Names ", "}}{{"\t"}}{{.Usage}}{{end}}{{else}}{{range .VisibleCommands}}
    {{join .Names ", "}}{{"\t"}}{{.Usage}}{{end}}{{end}}{{end}}{{if .VisibleFlags}}
 
-OPTIONS:
+{{translate "help.options"}}
    {{range .VisibleFlags}}{{FlagToString .}}
+   {{end}}{{end}}{{if .VisiblePersistentFlags}}{{if not .VisibleFlags}}
+{{end}}
+{{translate "help.global_flags"}}
+   {{range .VisiblePersistentFlags}}{{FlagToString .}}
    {{end}}{{end}}
 `
 
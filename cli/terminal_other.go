@@ -0,0 +1,26 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// TerminalWidth returns the column width of the terminal attached to w,
+// and false if w is not a terminal.
+func TerminalWidth(w io.Writer) (int, bool) {
+	return 0, false
+}
+
+// isTerminal returns true if f is a terminal
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+// disableEcho turns off the echo of input to the terminal f, returning a
+// func to restore the previous attributes
+func disableEcho(f *os.File) (func(), error) {
+	return nil, errors.New("disabling echo is not supported")
+}
@@ -0,0 +1,63 @@
+// +build linux darwin freebsd netbsd openbsd
+
+package cli
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	rows    uint16
+	cols    uint16
+	xpixels uint16
+	ypixels uint16
+}
+
+// TerminalWidth returns the column width of the terminal attached to w,
+// and false if w is not a terminal.
+func TerminalWidth(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, false
+	}
+	ws := &winsize{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(ws)))
+	if errno != 0 || ws.cols == 0 {
+		return 0, false
+	}
+	return int(ws.cols), true
+}
+
+// termios gets or sets the terminal attributes of f with the ioctl req
+func termios(f *os.File, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal returns true if f is a terminal
+func isTerminal(f *os.File) bool {
+	var t syscall.Termios
+	return termios(f, ioctlGetTermios, &t) == nil
+}
+
+// disableEcho turns off the echo of input to the terminal f, returning a
+// func to restore the previous attributes
+func disableEcho(f *os.File) (func(), error) {
+	var old syscall.Termios
+	if err := termios(f, ioctlGetTermios, &old); err != nil {
+		return nil, err
+	}
+	t := old
+	t.Lflag &^= syscall.ECHO
+	if err := termios(f, ioctlSetTermios, &t); err != nil {
+		return nil, err
+	}
+	return func() { termios(f, ioctlSetTermios, &old) }, nil
+}
@@ -0,0 +1,119 @@
+package cli
+
+import "fmt"
+
+// Message keys of the user-facing strings passed to App.Translator. The
+// default English message of each key is given with the arguments it is
+// formatted with.
+const (
+	MsgHelpName           = "help.name"            // "NAME:"
+	MsgHelpUsage          = "help.usage"           // "USAGE:"
+	MsgHelpVersion        = "help.version"         // "VERSION:"
+	MsgHelpDescription    = "help.description"     // "DESCRIPTION:"
+	MsgHelpAuthor         = "help.author"          // "AUTHOR:"
+	MsgHelpAuthors        = "help.authors"         // "AUTHORS:"
+	MsgHelpCommands       = "help.commands"        // "COMMANDS:"
+	MsgHelpGlobalOptions  = "help.global_options"  // "GLOBAL OPTIONS:"
+	MsgHelpOptions        = "help.options"         // "OPTIONS:"
+	MsgHelpGlobalFlags    = "help.global_flags"    // "GLOBAL FLAGS:"
+	MsgHelpCategory       = "help.category"        // "CATEGORY:"
+	MsgHelpCopyright      = "help.copyright"       // "COPYRIGHT:"
+	MsgHelpExamples       = "help.examples"        // "EXAMPLES:"
+	MsgUsageGlobalOptions = "usage.global_options" // "[global options]"
+	MsgUsageCommand       = "usage.command"        // "command"
+	MsgUsageOptions       = "usage.options"        // "[command options]"
+	MsgUsageArguments     = "usage.arguments"      // "[arguments...]"
+
+	MsgIncorrectUsage         = "error.incorrect_usage"           // "Incorrect Usage:"
+	MsgNoHelpTopic            = "error.no_help_topic"             // "No help topic for '%v'", command
+	MsgRequiredFlag           = "error.required_flag"             // "Required flag %q not set", name
+	MsgRequiredFlags          = "error.required_flags"            // "Required flags %q not set", names
+	MsgFlagRequires           = "error.flag_requires"             // "%s requires %s", flag, required flag
+	MsgFlagMinItems           = "error.flag_min_items"            // "%s requires at least %d values, got %d", flag, min, count
+	MsgFlagMaxItems           = "error.flag_max_items"            // "%s accepts at most %d values, got %d", flag, max, count
+	MsgFlagDuplicate          = "error.flag_duplicate"            // "%s has the duplicate value %v", flag, value
+	MsgFlagConflictsFile      = "error.flag_conflicts_file"       // "flags %s and %s cannot both be set", flag, file flag
+	MsgPathNotExist           = "error.path_not_exist"            // "path %q does not exist", path
+	MsgPathNotDir             = "error.path_not_dir"              // "path %q is not a directory", path
+	MsgPathNotFile            = "error.path_not_file"             // "path %q is not a file", path
+	MsgFlagFileUnreadable     = "error.flag_file_unreadable"      // "unable to read %s from file: %s", flag, error
+	MsgDefaultCommandNotFound = "error.default_command_not_found" // "default command %q not found", name
+	MsgSubcommandRequired     = "error.subcommand_required"       // "a subcommand is required"
+	MsgVersion                = "version"                         // "%v version %v", name, version
+
+	MsgWarning          = "warning"                   // "Warning:"
+	MsgDeprecatedAction = "warning.deprecated_action" // "Action func(*Context) is deprecated, use func(*Context) error"
+)
+
+var defaultMessages = map[string]string{
+	MsgHelpName:           "NAME:",
+	MsgHelpUsage:          "USAGE:",
+	MsgHelpVersion:        "VERSION:",
+	MsgHelpDescription:    "DESCRIPTION:",
+	MsgHelpAuthor:         "AUTHOR:",
+	MsgHelpAuthors:        "AUTHORS:",
+	MsgHelpCommands:       "COMMANDS:",
+	MsgHelpGlobalOptions:  "GLOBAL OPTIONS:",
+	MsgHelpOptions:        "OPTIONS:",
+	MsgHelpGlobalFlags:    "GLOBAL FLAGS:",
+	MsgHelpCategory:       "CATEGORY:",
+	MsgHelpCopyright:      "COPYRIGHT:",
+	MsgHelpExamples:       "EXAMPLES:",
+	MsgUsageGlobalOptions: "[global options]",
+	MsgUsageCommand:       "command",
+	MsgUsageOptions:       "[command options]",
+	MsgUsageArguments:     "[arguments...]",
+
+	MsgIncorrectUsage:         "Incorrect Usage:",
+	MsgNoHelpTopic:            "No help topic for '%v'",
+	MsgRequiredFlag:           "Required flag %q not set",
+	MsgRequiredFlags:          "Required flags %q not set",
+	MsgFlagRequires:           "%s requires %s",
+	MsgFlagMinItems:           "%s requires at least %d values, got %d",
+	MsgFlagMaxItems:           "%s accepts at most %d values, got %d",
+	MsgFlagDuplicate:          "%s has the duplicate value %v",
+	MsgFlagConflictsFile:      "flags %s and %s cannot both be set",
+	MsgPathNotExist:           "path %q does not exist",
+	MsgPathNotDir:             "path %q is not a directory",
+	MsgPathNotFile:            "path %q is not a file",
+	MsgFlagFileUnreadable:     "unable to read %s from file: %s",
+	MsgDefaultCommandNotFound: "default command %q not found",
+	MsgSubcommandRequired:     "a subcommand is required",
+	MsgVersion:                "%v version %v",
+
+	MsgWarning:          "Warning:",
+	MsgDeprecatedAction: "Action func(*Context) is deprecated, use func(*Context) error",
+}
+
+// DefaultTranslator returns the English message for key formatted with
+// args, or the key itself if it is not known
+func DefaultTranslator(key string, args ...interface{}) string {
+	msg, ok := defaultMessages[key]
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// translate returns the message for key from the Translator of the App, or
+// from DefaultTranslator if not set
+func (a *App) translate(key string, args ...interface{}) string {
+	if a != nil && a.Translator != nil {
+		return a.Translator(key, args...)
+	}
+	return DefaultTranslator(key, args...)
+}
+
+// helpTranslator returns the Translator used for the help of data
+func helpTranslator(data interface{}) TranslatorFunc {
+	switch d := data.(type) {
+	case *App:
+		return d.translate
+	case *Command:
+		return d.app.translate
+	}
+	return DefaultTranslator
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rancher/spur/flag"
+)
+
+// Validate parses the arguments slice as Run would, checking the flags of the
+// App and of any commands selected by the arguments and calling their
+// Validator funcs, without running any Before, Action or After functions or
+// writing any output other than the prompts of App.PromptMissing. Errors are
+// returned as Run returns them, with flag parse errors as a CommandError, so
+// they may be distinguished as they would be for a real run. As with Run,
+// flag destinations are updated with the parsed values.
+func (a *App) Validate(arguments []string) error {
+	if len(arguments) == 0 {
+		return fmt.Errorf("arguments not provided")
+	}
+	a.Setup()
+
+	if err := loadDotEnvFiles(a.DotEnvFiles); err != nil {
+		return err
+	}
+
+	if a.AllowArgFiles {
+		expanded, err := expandArgFiles(arguments[1:])
+		if err != nil {
+			return err
+		}
+		arguments = append(arguments[:1:1], expanded...)
+	}
+	if a.ArgsRewriter != nil {
+		arguments = a.ArgsRewriter(arguments)
+	}
+
+	set, err := a.newFlagSet()
+	if err != nil {
+		return err
+	}
+	if err := parseIter(set, a, arguments[1:], false); err != nil {
+		return newCommandError(a.Name, err)
+	}
+	if err := normalizeFlags(a.allFlags(), set); err != nil {
+		return err
+	}
+
+	ctx := NewContext(a, set, &Context{Context: context.Background()})
+	if (!a.HideHelp && checkHelp(ctx)) || (!a.HideVersion && checkVersion(ctx)) {
+		return nil
+	}
+	if err := joinErrors(resolveAndCheckFlags(a.allFlags(), a.PersistentFlags, ctx)); err != nil {
+		return err
+	}
+	if a.Validator != nil {
+		if err := a.Validator(ctx); err != nil {
+			return err
+		}
+	}
+	if a.Command(ctx.Args().First()) == nil {
+		c, err := a.defaultCommand(ctx)
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			return checkFlags(a.PersistentFlags, ctx)
+		}
+	}
+	return a.validateCommands(ctx, a.Commands, a.Name)
+}
+
+// validateCommands validates the flags of the command named by the first
+// argument of ctx and of any of its subcommands, where name is the name of
+// the App running the commands in a real run, such as "app command"
+func (a *App) validateCommands(ctx *Context, commands []*Command, name string) error {
+	args := ctx.Args()
+	if !args.Present() {
+		return nil
+	}
+	c := findCommand(commands, args.First())
+	if c == nil {
+		return nil
+	}
+
+	if !c.HideHelp && HelpFlag != nil {
+		c.appendFlag(HelpFlag)
+	}
+	c.app = a
+	name += " " + c.Name
+
+	set, err := c.validationFlagSet(args, name)
+	if err != nil {
+		return err
+	}
+
+	cctx := NewContext(a, set, ctx)
+	cctx.Command = c
+	inheritPersistentFlags(a.PersistentFlags, cctx)
+	if !c.HideHelp && (cctx.Bool("h") || cctx.Bool("help")) {
+		return nil
+	}
+	flags, deferred := c.allFlags(), []Flag(nil)
+	if len(c.Subcommands) > 0 {
+		// as for an App, persistent flags may still be given to a subcommand
+		deferred = a.PersistentFlags
+	} else if c.ArgsFlag != nil {
+		if err := applyArgsFlag(c.ArgsFlag, set); err != nil {
+			return newCommandError(name, err)
+		}
+		flags = append(flags[:len(flags):len(flags)], c.ArgsFlag)
+	}
+	if err := joinErrors(resolveAndCheckFlags(flags, deferred, cctx)); err != nil {
+		return err
+	}
+	if c.Validator != nil {
+		if err := c.Validator(cctx); err != nil {
+			return err
+		}
+	}
+	if len(c.Subcommands) > 0 && findCommand(c.Subcommands, cctx.Args().First()) == nil {
+		return checkFlags(a.PersistentFlags, cctx)
+	}
+	return a.validateCommands(cctx, c.Subcommands, name)
+}
+
+// validationFlagSet parses the flags of c from args as Run would, returning
+// parse errors as a CommandError for the named command
+func (c *Command) validationFlagSet(args Args, name string) (*flag.FlagSet, error) {
+	if len(c.Subcommands) == 0 {
+		set, err := c.parseFlags(args, false)
+		if err != nil {
+			return nil, newCommandError(name, err)
+		}
+		return set, nil
+	}
+	// commands with subcommands are run as an App, which does not skip flag parsing
+	set, err := c.newFlagSet()
+	if err != nil {
+		return nil, err
+	}
+	if err := parseIter(set, c, args.Tail(), false); err != nil {
+		return nil, newCommandError(name, err)
+	}
+	return set, normalizeFlags(c.Flags, set)
+}
+
+// resolveAndCheckFlags resolves the values of flags not set on the command
+// line from their other sources, then checks the flags other than those in
+// deferred, such as the PersistentFlags of an App which may still be given
+// after a command. Resolving stops at the first error, so that flags are
+// never prompted for once the run will fail. The errors from resolving and
+// from checking are returned separately, as only a failed check shows help.
+func resolveAndCheckFlags(flags, deferred []Flag, ctx *Context) (ferr, cerr error) {
+	resolvers := []func([]Flag, *Context) error{
+		resolvePositionalAliases,
+		resolveFlagsFile,
+		resolveFromFileFlags,
+		resolveValueSources,
+		promptMissingFlags,
+		resolveTemplateDefaults,
+		resolveDerivedFlags,
+	}
+	for _, resolve := range resolvers {
+		if ferr = resolve(flags, ctx); ferr != nil {
+			break
+		}
+	}
+	checked := flags
+	if len(deferred) > 0 {
+		checked = nil
+		for _, f := range flags {
+			if !hasFlag(deferred, f) {
+				checked = append(checked, f)
+			}
+		}
+	}
+	return ferr, checkFlags(checked, ctx)
+}
+
+// checkFlags checks that the required flags are set and that the values
+// of the flags are allowed
+func checkFlags(flags []Flag, ctx *Context) error {
+	return joinErrors(checkRequiredFlags(flags, ctx), checkFlagRequires(flags, ctx), checkFlagItems(flags, ctx), checkPathFlags(flags, ctx))
+}
@@ -41,14 +41,14 @@
 
 		-flag
 		-flag=x
-		-flag x  // non-boolean flags only
+		-flag x  // non-boolean flags, or boolean words only
 	One or two minus signs may be used; they are equivalent.
-	The last form is not permitted for boolean flags because the
-	meaning of the command
+	The last form is only permitted for boolean flags when x is one
+	of the words true, false, yes, no, on or off in any case, as
+	otherwise the meaning of the command
 		cmd -x *
-	where * is a Unix shell wildcard, will change if there is a file
-	called 0, false, etc. You must use the -flag=false form to turn
-	off a boolean flag.
+	where * is a Unix shell wildcard, would change if there is a file
+	called 0, f, etc. Any other following argument is not consumed.
 
 	Flag parsing stops just before the first non-flag argument
 	("-" is a non-flag argument) or after the terminator "--".
@@ -74,6 +74,7 @@ import (
 	"os"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rancher/spur/generic"
@@ -112,6 +113,12 @@ type BoolFlag interface {
 	IsBoolFlag() bool
 }
 
+// SecretValue is an interface for a Value whose values must not be shown,
+// which are masked in parse errors.
+type SecretValue interface {
+	IsSecret() bool
+}
+
 // IsBoolValue returns true for data types which don't require a flag value
 func IsBoolValue(value interface{}) bool {
 	if v, ok := value.(BoolFlag); ok {
@@ -126,6 +133,18 @@ func IsBoolValue(value interface{}) bool {
 	return t != nil && t.String() == "bool"
 }
 
+// isBoolWord returns true if s is a word which may follow a boolean flag as
+// its value, such as "false" in -flag false. These are the tokens of
+// generic.ParseBool other than single letters and digits, which are more
+// likely to be arguments.
+func isBoolWord(s string) bool {
+	if len(s) < 2 || strings.TrimSpace(s) != s {
+		return false
+	}
+	_, err := generic.ParseBool(s)
+	return err == nil
+}
+
 // ErrorHandling defines how FlagSet.Parse behaves if the parse fails.
 type ErrorHandling int
 
@@ -149,21 +168,81 @@ type FlagSet struct {
 	// to ExitOnError, which exits the program after calling Usage.
 	Usage func()
 
+	// TrackHistory records each value assigned to a flag by Set or the
+	// command line in the History of the flag and its aliases
+	TrackHistory bool
+
+	// IgnoreUnknown keeps flags which are not defined, along with any value
+	// given with "=", in the arguments remaining after parsing instead of
+	// returning an error. The value of an unknown flag given as the next
+	// argument ends the flags as any other argument does.
+	IgnoreUnknown bool
+
+	// BoolWords lets a boolean flag take the next argument as its value if
+	// it is a word such as "false", "no" or "off", as in -flag false. Such
+	// a word is then never a positional argument, so -force on start sets
+	// -force and leaves only start.
+	BoolWords bool
+
 	name          string
 	parsed        bool
 	actual        map[string]*Flag
 	formal        map[string]*Flag
 	args          []string // arguments after flags
+	unknown       []string // flags which are not defined, if IgnoreUnknown
 	errorHandling ErrorHandling
 	output        io.Writer // nil means stderr; use Output() accessor
 }
 
 // A Flag represents the state of a flag.
 type Flag struct {
-	Name     string // name as it appears on command line
-	Usage    string // help message
-	Value    Value  // value as set
-	DefValue string // default value (as text); for usage message
+	Name     string       // name as it appears on command line
+	Usage    string       // help message
+	Value    Value        // value as set
+	DefValue string       // default value (as text); for usage message
+	NArgs    int          // max arguments consumed per occurrence; negative for unlimited
+	Source   string       // where the value was set from if not by Set or the command line, such as "env"
+	Path     string       // path of the file the value was read from, if any
+	History  []Assignment // values assigned to the flag, if the FlagSet has TrackHistory set
+}
+
+// An Assignment is a value assigned to a flag and where it was set from.
+type Assignment struct {
+	Value  interface{} // value of the flag after the assignment
+	Source string      // as for Flag.Source, empty for Set or the command line
+	Path   string      // as for Flag.Path
+}
+
+// record appends the current value of flag to the History of the flag and
+// any aliases sharing its Value, if the flag set has TrackHistory set.
+func (f *FlagSet) record(flag *Flag) {
+	if !f.TrackHistory {
+		return
+	}
+	a := Assignment{Value: flag.Value.String(), Source: flag.Source, Path: flag.Path}
+	if g, ok := flag.Value.(Getter); ok {
+		a.Value = g.Get()
+	}
+	for _, ff := range f.formal {
+		if ff == flag || sameValue(ff.Value, flag.Value) {
+			ff.History = append(ff.History, a)
+		}
+	}
+}
+
+// sameValue returns true if a and b are the same Value, such as the Value
+// shared by a flag and its aliases. Values such as maps, which can not be
+// compared with ==, are the same if they refer to the same data.
+func sameValue(a, b Value) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	return va.Type().Comparable() && a == b
 }
 
 // sortFlags returns the flags as a slice in lexicographical sorted order.
@@ -182,6 +261,17 @@ func sortFlags(flags map[string]*Flag) []*Flag {
 
 const invalidValueTemplate = "invalid value %q for flag -%s: %v"
 
+// secretMask replaces the values of a SecretValue in parse errors
+const secretMask = "***"
+
+// errorValue returns value as shown in a parse error for flag
+func errorValue(flag *Flag, value interface{}) interface{} {
+	if v, ok := flag.Value.(SecretValue); ok && v.IsSecret() {
+		return secretMask
+	}
+	return value
+}
+
 func (f *FlagSet) addActual(name string, flag *Flag) {
 	if f.actual == nil {
 		f.actual = make(map[string]*Flag)
@@ -270,8 +360,10 @@ func (f *FlagSet) Set(name string, value interface{}) error {
 	}
 	err := flag.Value.Set(value)
 	if err != nil {
-		return fmt.Errorf(invalidValueTemplate, value, name, err)
+		return fmt.Errorf(invalidValueTemplate, errorValue(flag, value), name, err)
 	}
+	flag.Source, flag.Path = "", ""
+	f.record(flag)
 	f.addActual(name, flag)
 	return nil
 }
@@ -287,6 +379,9 @@ func isZeroValue(flag *Flag, value string) bool {
 	// Build a zero value of the flag's Value type, and see if the
 	// result of calling its String method equals the value passed in.
 	if val, ok := flag.Value.(Getter); ok {
+		if val.Get() == nil {
+			return value == ""
+		}
 		if s, ok := generic.ToString(generic.Zero(val.Get())); ok {
 			return value == s
 		}
@@ -317,7 +412,9 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 	// No explicit name, so use type if we can find one.
 	name = "value"
 	if v, ok := flag.Value.(Getter); ok {
-		name = generic.TypeOf(v.Get()).String()
+		if t := generic.TypeOf(v.Get()); t != nil {
+			name = t.String()
+		}
 	}
 	if IsBoolValue(flag.Value) {
 		name = ""
@@ -347,7 +444,7 @@ func (f *FlagSet) PrintDefaults() {
 		s += strings.ReplaceAll(usage, "\n", "\n    \t")
 
 		if !isZeroValue(flag, flag.DefValue) {
-			if v, ok := flag.Value.(Getter); ok && generic.TypeOf(v.Get()).String() == "string" {
+			if v, ok := flag.Value.(Getter); ok && generic.TypeOf(v.Get()) == reflect.TypeOf("") {
 				// put quotes on the value
 				s += fmt.Sprintf(" (default %q)", flag.DefValue)
 			} else {
@@ -443,6 +540,14 @@ func NArg() int { return len(CommandLine.args) }
 // Args returns the non-flag arguments.
 func (f *FlagSet) Args() []string { return f.args }
 
+// SetArgs replaces the non-flag arguments, such as to remove arguments
+// which have been used as the values of flags.
+func (f *FlagSet) SetArgs(args []string) { f.args = args }
+
+// Unknown returns the flags which were not defined, if IgnoreUnknown is set.
+// They are also at the start of Args.
+func (f *FlagSet) Unknown() []string { return f.unknown }
+
 // Args returns the non-flag command-line arguments.
 func Args() []string { return CommandLine.args }
 
@@ -454,7 +559,7 @@ func Args() []string { return CommandLine.args }
 // decompose the comma-separated string into the slice.
 func (f *FlagSet) Var(value Value, name string, usage string) {
 	// Remember the default value as a string; it won't change.
-	flag := &Flag{name, usage, value, value.String()}
+	flag := &Flag{Name: name, Usage: usage, Value: value, DefValue: value.String()}
 	_, alreadythere := f.formal[name]
 	if alreadythere {
 		var msg string
@@ -542,13 +647,22 @@ func (f *FlagSet) parseOne() (bool, error) {
 			f.usage()
 			return false, ErrHelp
 		}
+		if f.IgnoreUnknown {
+			f.unknown = append(f.unknown, s)
+			return true, nil
+		}
 		return false, f.failf("flag provided but not defined: -%s", name)
 	}
 
 	if IsBoolValue(flag.Value) { // special case: doesn't need an arg
 		if hasValue {
 			if err := flag.Value.Set(value); err != nil {
-				return false, f.failf(invalidValueTemplate, value, name, err)
+				return false, f.failf(invalidValueTemplate, errorValue(flag, value), name, err)
+			}
+		} else if f.BoolWords && len(f.args) > 0 && isBoolWord(f.args[0]) {
+			value, f.args = f.args[0], f.args[1:]
+			if err := flag.Value.Set(value); err != nil {
+				return false, f.failf(invalidValueTemplate, errorValue(flag, value), name, err)
 			}
 		} else {
 			if err := flag.Value.Set("true"); err != nil {
@@ -566,9 +680,22 @@ func (f *FlagSet) parseOne() (bool, error) {
 			return false, f.failf("flag needs an argument: -%s", name)
 		}
 		if err := flag.Value.Set(value); err != nil {
-			return false, f.failf(invalidValueTemplate, value, name, err)
+			return false, f.failf(invalidValueTemplate, errorValue(flag, value), name, err)
+		}
+		// consume any following values for flags taking multiple arguments
+		for n := 1; (flag.NArgs < 0 || n < flag.NArgs) && len(f.args) > 0; n++ {
+			value = f.args[0]
+			if !isFlagValue(value) {
+				break
+			}
+			f.args = f.args[1:]
+			if err := flag.Value.Set(value); err != nil {
+				return false, f.failf(invalidValueTemplate, errorValue(flag, value), name, err)
+			}
 		}
 	}
+	flag.Source, flag.Path = "", ""
+	f.record(flag)
 	if f.actual == nil {
 		f.actual = make(map[string]*Flag)
 	}
@@ -576,6 +703,17 @@ func (f *FlagSet) parseOne() (bool, error) {
 	return true, nil
 }
 
+// isFlagValue returns true if the argument may be consumed as the value of a
+// flag taking multiple arguments, which excludes arguments that look like
+// flags unless they are negative numbers.
+func isFlagValue(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return true
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
+
 // Parse parses flag definitions from the argument list, which should not
 // include the command name. Must be called after all flags in the FlagSet
 // are defined and before flags are accessed by the program.
@@ -583,12 +721,16 @@ func (f *FlagSet) parseOne() (bool, error) {
 func (f *FlagSet) Parse(arguments []string) error {
 	f.parsed = true
 	f.args = arguments
+	f.unknown = nil
 	for {
 		seen, err := f.parseOne()
 		if seen {
 			continue
 		}
 		if err == nil {
+			if len(f.unknown) > 0 {
+				f.args = append(append([]string{}, f.unknown...), f.args...)
+			}
 			break
 		}
 		switch f.errorHandling {
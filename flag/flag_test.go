@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"reflect"
 	"runtime"
 	"sort"
 	"strconv"
@@ -326,6 +327,65 @@ func TestUserDefinedBool(t *testing.T) {
 	}
 }
 
+func TestBoolWordArgument(t *testing.T) {
+	var flags FlagSet
+	flags.Init("test", ContinueOnError)
+	flags.BoolWords = true
+	a := flags.Bool("a", true, "usage")
+	b := flags.Bool("b", false, "usage")
+	if err := flags.Parse([]string{"-a", "off", "-b", "1", "false"}); err != nil {
+		t.Fatal(err)
+	}
+	if *a || !*b {
+		t.Errorf("want: a false, b true; got: a %v, b %v", *a, *b)
+	}
+	if args := flags.Args(); len(args) != 2 || args[0] != "1" || args[1] != "false" {
+		t.Errorf("want: [1 false]; got: %q", args)
+	}
+
+	// without BoolWords the word is an argument
+	var plain FlagSet
+	plain.Init("test", ContinueOnError)
+	force := plain.Bool("force", false, "usage")
+	if err := plain.Parse([]string{"-force", "on", "start"}); err != nil {
+		t.Fatal(err)
+	}
+	if !*force {
+		t.Errorf("want: force true; got: force %v", *force)
+	}
+	if args := plain.Args(); len(args) != 2 || args[0] != "on" || args[1] != "start" {
+		t.Errorf("want: [on start]; got: %q", args)
+	}
+}
+
+// setValue is a Value of a type which can not be compared with ==
+type setValue map[string]bool
+
+func (s setValue) String() string { return fmt.Sprint(map[string]bool(s)) }
+
+func (s setValue) Set(value interface{}) error {
+	s[fmt.Sprint(value)] = true
+	return nil
+}
+
+func TestTrackHistoryUncomparableValue(t *testing.T) {
+	var flags FlagSet
+	flags.Init("test", ContinueOnError)
+	flags.TrackHistory = true
+	set := setValue{}
+	flags.Var(set, "set", "usage")
+	flags.Var(set, "s", "usage")
+	flags.Var(setValue{}, "other", "usage")
+	if err := flags.Parse([]string{"-set", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]int{"set": 1, "s": 1, "other": 0} {
+		if got := len(flags.Lookup(name).History); got != want {
+			t.Errorf("want %d assignments for %s; got %d", want, name, got)
+		}
+	}
+}
+
 func TestSetOutput(t *testing.T) {
 	var flags FlagSet
 	var buf bytes.Buffer
@@ -337,6 +397,37 @@ func TestSetOutput(t *testing.T) {
 	}
 }
 
+func TestNArgs(t *testing.T) {
+	tests := []struct {
+		nargs    int
+		args     []string
+		expected []int
+		rest     []string
+	}{
+		{0, []string{"-points", "1", "2"}, []int{1}, []string{"2"}},
+		{2, []string{"-points", "1", "2", "3"}, []int{1, 2}, []string{"3"}},
+		{-1, []string{"-points", "1", "2", "3"}, []int{1, 2, 3}, []string{}},
+		{-1, []string{"-points", "1", "-2", "-3", "-v"}, []int{1, -2, -3}, []string{}},
+		{-1, []string{"-points", "1", "2", "--", "3"}, []int{1, 2}, []string{"3"}},
+		{-1, []string{"-points=1", "2", "-v", "3"}, []int{1, 2}, []string{"3"}},
+	}
+	for _, test := range tests {
+		flags := NewFlagSet("test", ContinueOnError)
+		points := flags.IntSlice("points", nil, "points")
+		flags.Bool("v", false, "verbose")
+		flags.Lookup("points").NArgs = test.nargs
+		if err := flags.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*points, test.expected) {
+			t.Errorf("%v: expected points %v got %v", test.args, test.expected, *points)
+		}
+		if !reflect.DeepEqual(flags.Args(), test.rest) {
+			t.Errorf("%v: expected args %v got %v", test.args, test.rest, flags.Args())
+		}
+	}
+}
+
 // This tests that one can reset the flags. This still works but not well, and is
 // superseded by FlagSet.
 func TestChangingArgs(t *testing.T) {
@@ -626,3 +717,33 @@ func TestExitCode(t *testing.T) {
 		}
 	}
 }
+
+func TestIgnoreUnknown(t *testing.T) {
+	var flags FlagSet
+	flags.Init("test", ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.IgnoreUnknown = true
+	a := flags.String("a", "", "usage")
+	b := flags.Bool("b", false, "usage")
+	err := flags.Parse([]string{"-x", "-a", "1", "--verbose=2", "-b", "--y", "arg", "-a", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *a != "1" || !*b {
+		t.Errorf("want: a 1, b true; got: a %q, b %v", *a, *b)
+	}
+	if unknown := flags.Unknown(); !reflect.DeepEqual(unknown, []string{"-x", "--verbose=2", "--y"}) {
+		t.Errorf("want: [-x --verbose=2 --y]; got: %q", unknown)
+	}
+	if args := flags.Args(); !reflect.DeepEqual(args, []string{"-x", "--verbose=2", "--y", "arg", "-a", "2"}) {
+		t.Errorf("want: [-x --verbose=2 --y arg -a 2]; got: %q", args)
+	}
+
+	flags.IgnoreUnknown = false
+	if err := flags.Parse([]string{"-x"}); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+	if unknown := flags.Unknown(); len(unknown) != 0 {
+		t.Errorf("want: no unknown flags; got: %q", unknown)
+	}
+}
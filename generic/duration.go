@@ -0,0 +1,206 @@
+// Copyright 2020 Rancher Labs, Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generic
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationUnits = []struct {
+	name string
+	unit time.Duration
+}{
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+	{"second", time.Second},
+	{"millisecond", time.Millisecond},
+	{"microsecond", time.Microsecond},
+	{"nanosecond", time.Nanosecond},
+}
+
+// HumanizeDuration returns d in a human readable form, such as
+// "1 hour 30 minutes" for 90 minutes, or "0 seconds" for a zero duration.
+func HumanizeDuration(d time.Duration) string {
+	if d == 0 {
+		return "0 seconds"
+	}
+	sign := ""
+	if d < 0 {
+		// time.Duration can not represent the negation of its minimum value
+		if d == time.Duration(-1<<63) {
+			d++
+		}
+		sign, d = "-", -d
+	}
+	var parts []string
+	for _, u := range durationUnits {
+		n := d / u.unit
+		if n == 0 {
+			continue
+		}
+		d -= n * u.unit
+		name := u.name
+		if n != 1 {
+			name += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, name))
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// iso8601Units are the designators of an ISO 8601 duration in the order
+// they must appear, years and months have no fixed length so are rejected
+var iso8601Units = []struct {
+	designator byte
+	time       bool
+	unit       time.Duration
+}{
+	{'Y', false, 0},
+	{'M', false, 0},
+	{'W', false, 7 * 24 * time.Hour},
+	{'D', false, 24 * time.Hour},
+	{'H', true, time.Hour},
+	{'M', true, time.Minute},
+	{'S', true, time.Second},
+}
+
+// ParseISO8601Duration parses an ISO 8601 duration such as "P1DT2H30M" or
+// "PT0.5S", with an optional leading sign. Weeks and days are converted to
+// 7 and 24 hours, while years and months are an error as their length
+// depends on the date they are applied to.
+func ParseISO8601Duration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "" || (s[0] != 'P' && s[0] != 'p') {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+	}
+	s = strings.ToUpper(s[1:])
+	var d time.Duration
+	next, inTime, components := 0, false, 0
+	for s != "" {
+		if s[0] == 'T' {
+			if inTime {
+				return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+			}
+			inTime, s = true, s[1:]
+			if s == "" {
+				return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+			}
+			continue
+		}
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.' && r != ','
+		})
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+		}
+		number, designator := strings.Replace(s[:i], ",", ".", 1), s[i]
+		s = s[i+1:]
+		for next < len(iso8601Units) && (iso8601Units[next].designator != designator || iso8601Units[next].time != inTime) {
+			next++
+		}
+		if next == len(iso8601Units) {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+		}
+		u := iso8601Units[next]
+		next++
+		if u.unit == 0 {
+			return 0, fmt.Errorf("ISO 8601 duration %q has years or months, which do not have a fixed length", orig)
+		}
+		v, err := iso8601Component(number, u.unit)
+		if err != nil || d > math.MaxInt64-v {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %s", orig, errRange)
+		}
+		d += v
+		components++
+	}
+	if components == 0 {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// iso8601Component returns number, which may have a fraction, multiplied
+// by unit
+func iso8601Component(number string, unit time.Duration) (time.Duration, error) {
+	whole, frac := number, ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		whole, frac = number[:i], number[i+1:]
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return 0, errRange
+	}
+	d := time.Duration(n) * unit
+	scale := float64(unit)
+	for _, c := range frac {
+		if c < '0' || c > '9' {
+			return 0, errParse
+		}
+		scale /= 10
+		d += time.Duration(float64(c-'0') * scale)
+	}
+	if d < 0 {
+		return 0, errRange
+	}
+	return d, nil
+}
+
+// FormatISO8601Duration returns d as an ISO 8601 duration, such as
+// "P1DT2H30M", with multiples of 24 hours given as days
+func FormatISO8601Duration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	u := uint64(d)
+	if d < 0 {
+		b.WriteByte('-')
+		u = -u
+	}
+	b.WriteByte('P')
+	day := uint64(24 * time.Hour)
+	if days := u / day; days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		u -= days * day
+	}
+	if u == 0 {
+		return b.String()
+	}
+	b.WriteByte('T')
+	if hours := u / uint64(time.Hour); hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+		u -= hours * uint64(time.Hour)
+	}
+	if minutes := u / uint64(time.Minute); minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+		u -= minutes * uint64(time.Minute)
+	}
+	if u > 0 {
+		seconds := strconv.FormatUint(u/uint64(time.Second), 10)
+		if ns := u % uint64(time.Second); ns > 0 {
+			seconds += strings.TrimRight(fmt.Sprintf(".%09d", ns), "0")
+		}
+		fmt.Fprintf(&b, "%sS", seconds)
+	}
+	return b.String()
+}
@@ -8,6 +8,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -31,6 +33,10 @@ var ToStringMap = map[string]ToStringFunc{}
 // FromStringMap provides a mapping of string to type conversion function
 var FromStringMap = map[string]FromStringFunc{}
 
+// registeredTypes maps the names of the types registered with RegisterType
+// to their types
+var registeredTypes = map[string]reflect.Type{}
+
 // TimeLayouts provides a list of layouts to attempt when converting time strings
 var TimeLayouts = []string{
 	time.RFC3339Nano,
@@ -77,6 +83,35 @@ func FromString(value string, ptr interface{}) error {
 	return nil
 }
 
+// RegisterType registers the type of value by its name, such as "net.IP",
+// along with the functions to convert it from and to a string in
+// FromStringMap and ToStringMap, which are left unchanged if nil
+func RegisterType(value interface{}, fromString FromStringFunc, toString ToStringFunc) {
+	typ := TypeOf(value)
+	name := typ.String()
+	registeredTypes[name] = typ
+	if fromString != nil {
+		FromStringMap[name] = fromString
+	}
+	if toString != nil {
+		ToStringMap[name] = toString
+	}
+}
+
+// LookupType returns a type registered with RegisterType by name, or a
+// slice of a registered type for a name such as "[]net.IP"
+func LookupType(name string) (reflect.Type, bool) {
+	if elem := strings.TrimPrefix(name, "[]"); elem != name {
+		typ, ok := LookupType(elem)
+		if !ok {
+			return nil, false
+		}
+		return reflect.SliceOf(typ), true
+	}
+	typ, ok := registeredTypes[name]
+	return typ, ok
+}
+
 // TypeOf returns the dereferenced value's type
 func TypeOf(value interface{}) reflect.Type {
 	typ := reflect.TypeOf(value)
@@ -86,10 +121,35 @@ func TypeOf(value interface{}) reflect.Type {
 	return typ
 }
 
+// TypeName returns the name of the dereferenced value's type as used in
+// messages such as parse errors, which is "duration" or "time" for
+// time.Duration and time.Time, the name of a built-in type such as "int",
+// the element name followed by "slice" for a slice such as "string slice",
+// or the qualified name of any other type such as "net.IP"
+func TypeName(value interface{}) string {
+	return typeName(TypeOf(value))
+}
+
+func typeName(typ reflect.Type) string {
+	switch {
+	case typ == nil:
+		return "nil"
+	case typ == reflect.TypeOf(time.Duration(0)):
+		return "duration"
+	case typ == reflect.TypeOf(time.Time{}):
+		return "time"
+	case typ.Name() != "" && typ.PkgPath() == "":
+		return typ.Name()
+	case typ.Name() == "" && typ.Kind() == reflect.Slice:
+		return typeName(typ.Elem()) + " slice"
+	}
+	return typ.String()
+}
+
 // ElemTypeOf returns the dereferenced value's type or TypeOf is not an Elem
 func ElemTypeOf(value interface{}) reflect.Type {
 	typ := TypeOf(value)
-	if typ.Kind() == reflect.Slice {
+	if typ != nil && typ.Kind() == reflect.Slice {
 		return typ.Elem()
 	}
 	return typ
@@ -105,9 +165,13 @@ func NewElem(value interface{}) interface{} {
 	return reflect.New(ElemTypeOf(value)).Interface()
 }
 
-// Zero returns a zero reflection with TypeOf value
+// Zero returns a zero reflection with TypeOf value, or nil if value is nil
 func Zero(value interface{}) interface{} {
-	return reflect.Zero(TypeOf(value)).Interface()
+	typ := TypeOf(value)
+	if typ == nil {
+		return nil
+	}
+	return reflect.Zero(typ).Interface()
 }
 
 // IsSlice return true if the TypeOf value is a slice
@@ -190,6 +254,13 @@ func Convert(src interface{}, value interface{}) (interface{}, error) {
 		if err := Unmarshal([]byte(s), val); err == nil {
 			return ValueOfPtr(val), nil
 		}
+		// Otherwise convert each deserialized element as a string, so that
+		// elements are parsed as they would be on their own, such as "t"
+		// or 1 in a bool slice
+		var elems []interface{}
+		if err := Unmarshal([]byte(s), &elems); err == nil {
+			value = elems
+		}
 	}
 	// If no error from converting element return appended value
 	if err == nil {
@@ -228,10 +299,46 @@ func ConvertElem(src interface{}, value interface{}) (interface{}, error) {
 	return ValueOfPtr(ptr), err
 }
 
+// StringifyOptions control how StringifyWith formats a value
+type StringifyOptions struct {
+	// Quote quotes strings, including the elements of slices
+	Quote bool
+	// SliceSeparator joins the formatted elements of slices, if empty slices
+	// are Marshaled instead
+	SliceSeparator string
+	// HumanizeDurations formats durations with HumanizeDuration
+	HumanizeDurations bool
+	// ISO8601Durations formats durations with FormatISO8601Duration, unless
+	// HumanizeDurations is also set
+	ISO8601Durations bool
+}
+
 // Stringify returns the ToString version of the value, or the Marshaled version
 // in the case of slices, otherwise panic if cannot be converted to string
 func Stringify(value interface{}) string {
+	return StringifyWith(value, StringifyOptions{})
+}
+
+// StringifyWith returns the value formatted as Stringify with the given
+// options applied, otherwise panic if cannot be converted to string
+func StringifyWith(value interface{}, opts StringifyOptions) string {
+	if opts.SliceSeparator != "" && IsSlice(value) && !IsPtr(value) {
+		parts := make([]string, Len(value))
+		for i := range parts {
+			parts[i] = StringifyWith(Index(value, i), opts)
+		}
+		return strings.Join(parts, opts.SliceSeparator)
+	}
+	if d, ok := value.(time.Duration); ok && opts.HumanizeDurations {
+		return HumanizeDuration(d)
+	}
+	if d, ok := value.(time.Duration); ok && opts.ISO8601Durations {
+		return FormatISO8601Duration(d)
+	}
 	if s, ok := ToString(value); ok {
+		if _, isString := value.(string); isString && opts.Quote {
+			return strconv.Quote(s)
+		}
 		return s
 	}
 	if b, err := Marshal(value); err == nil {
@@ -239,3 +346,90 @@ func Stringify(value interface{}) string {
 	}
 	panic(errParse)
 }
+
+// Equal returns true if a and b are of the same type and hold equal values.
+// Unlike reflect.DeepEqual a nil slice or map is equal to an empty one,
+// pointers are equal if the values they point to are equal, and types with an
+// Equal method (such as time.Time) are compared using that method.
+func Equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return isEmpty(reflect.ValueOf(a)) && isEmpty(reflect.ValueOf(b))
+	}
+	return equal(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func equal(a, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	if method, ok := a.Type().MethodByName("Equal"); ok && a.CanInterface() &&
+		method.Type.NumIn() == 2 && method.Type.In(1) == a.Type() &&
+		method.Type.NumOut() == 1 && method.Type.Out(0).Kind() == reflect.Bool {
+		return a.Method(method.Index).Call([]reflect.Value{b})[0].Bool()
+	}
+	switch a.Kind() {
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, key := range a.MapKeys() {
+			other := b.MapIndex(key)
+			if !other.IsValid() || !equal(a.MapIndex(key), other) {
+				return false
+			}
+		}
+		return true
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		}
+		return equal(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Func:
+		return a.IsNil() && b.IsNil()
+	}
+	return a.Pointer() == b.Pointer()
+}
+
+// isEmpty returns true if value is invalid, a nil pointer, or an empty slice
+// or map
+func isEmpty(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
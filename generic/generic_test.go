@@ -0,0 +1,301 @@
+// Copyright 2020 Rancher Labs, Inc. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generic
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type equalStruct struct {
+	Name  string
+	tags  []string
+	ports map[string]int
+}
+
+func TestEqual(t *testing.T) {
+	now := time.Now()
+	str := "a"
+	otherStr := "a"
+	var nilSlice []string
+	var nilMap map[string]string
+	var nilPtr *string
+	tests := []struct {
+		a, b   interface{}
+		expect bool
+	}{
+		{nil, nil, true},
+		{1, 1, true},
+		{1, 2, false},
+		{1, int64(1), false},
+		{"a", "a", true},
+		{"a", "b", false},
+		{true, false, false},
+		{1.5, 1.5, true},
+		{time.Second, time.Second, true},
+		{time.Second, time.Minute, false},
+
+		{[]string{"a", "b"}, []string{"a", "b"}, true},
+		{[]string{"a", "b"}, []string{"b", "a"}, false},
+		{[]string{"a"}, []string{"a", "b"}, false},
+		{[]int{1, 2}, []int64{1, 2}, false},
+		{nilSlice, []string{}, true},
+		{[]string{}, nilSlice, true},
+		{nilSlice, nilSlice, true},
+		{nilSlice, []string{""}, false},
+		{nil, []string{}, true},
+		{[]string{}, nil, true},
+		{nil, []string{"a"}, false},
+		{[][]string{nil}, [][]string{{}}, true},
+
+		{map[string]string{"a": "1"}, map[string]string{"a": "1"}, true},
+		{map[string]string{"a": "1"}, map[string]string{"a": "2"}, false},
+		{map[string]string{"a": "1"}, map[string]string{"b": "1"}, false},
+		{map[string]string{"": ""}, map[string]string{}, false},
+		{nilMap, map[string]string{}, true},
+		{nil, map[string]string{}, true},
+		{map[string][]int{"a": nil}, map[string][]int{"a": {}}, true},
+
+		{&str, &otherStr, true},
+		{&str, nilPtr, false},
+		{nilPtr, nilPtr, true},
+		{nil, nilPtr, true},
+		{&[]string{}, &nilSlice, true},
+
+		{now, now.Round(0), true},
+		{now, now.Add(time.Second), false},
+		{[]time.Time{now}, []time.Time{now.In(time.UTC)}, true},
+
+		{[2]string{"a", "b"}, [2]string{"a", "b"}, true},
+		{[2]string{"a", "b"}, [2]string{"a", "c"}, false},
+		{equalStruct{Name: "a"}, equalStruct{Name: "a", tags: []string{}, ports: map[string]int{}}, true},
+		{equalStruct{Name: "a", tags: []string{"x"}}, equalStruct{Name: "a", tags: []string{"y"}}, false},
+		{equalStruct{ports: map[string]int{"a": 1}}, equalStruct{ports: map[string]int{"a": 1}}, true},
+	}
+	for i, test := range tests {
+		if result := Equal(test.a, test.b); result != test.expect {
+			t.Errorf("test %d: Equal(%#v, %#v) = %v, expected %v", i, test.a, test.b, result, test.expect)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	for _, s := range []string{"1", "t", "T", "true", "TRUE", "True", "y", "Y", "yes", "YES", "on", "On", " true "} {
+		if v, err := ParseBool(s); err != nil || !v {
+			t.Errorf("ParseBool(%q) = %v, %v, expected true", s, v, err)
+		}
+	}
+	for _, s := range []string{"", "0", "f", "F", "false", "FALSE", "False", "n", "N", "no", "NO", "off", "Off"} {
+		if v, err := ParseBool(s); err != nil || v {
+			t.Errorf("ParseBool(%q) = %v, %v, expected false", s, v, err)
+		}
+	}
+	for _, s := range []string{"2", "maybe", "yess", "tru"} {
+		if _, err := ParseBool(s); err == nil {
+			t.Errorf("ParseBool(%q) expected an error", s)
+		}
+	}
+}
+
+func TestBoolFromStringMatchesSlice(t *testing.T) {
+	for _, s := range []string{"yes", "no", "1", "0", "On", "off", "true", "F", "t", "y", "N"} {
+		var b bool
+		if err := FromString(s, &b); err != nil {
+			t.Fatalf("FromString(%q) returned %v", s, err)
+		}
+		slice, err := Convert([]bool{}, s)
+		if err != nil {
+			t.Fatalf("Convert([]bool{}, %q) returned %v", s, err)
+		}
+		if !Equal(slice, []bool{b}) {
+			t.Errorf("Convert([]bool{}, %q) = %v, expected [%v]", s, slice, b)
+		}
+		list := "[" + s + ", " + s + "]"
+		slice, err = Convert([]bool{}, list)
+		if err != nil || !Equal(slice, []bool{b, b}) {
+			t.Errorf("Convert([]bool{}, %q) = %v, %v, expected [%v %v]", list, slice, err, b, b)
+		}
+	}
+	var b bool
+	if err := FromString("maybe", &b); err != errParse {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestStringifyWith(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		opts   StringifyOptions
+		expect string
+	}{
+		{"a b", StringifyOptions{}, "a b"},
+		{"a b", StringifyOptions{Quote: true}, `"a b"`},
+		{42, StringifyOptions{Quote: true}, "42"},
+		{90 * time.Minute, StringifyOptions{}, "1h30m0s"},
+		{90 * time.Minute, StringifyOptions{HumanizeDurations: true}, "1 hour 30 minutes"},
+		{[]string{"a", "b"}, StringifyOptions{}, `["a","b"]`},
+		{[]string{"a", "b"}, StringifyOptions{Quote: true}, `["a","b"]`},
+		{[]string{"a", "b"}, StringifyOptions{SliceSeparator: ","}, "a,b"},
+		{[]string{"a", "b"}, StringifyOptions{SliceSeparator: ", ", Quote: true}, `"a", "b"`},
+		{[]int{1, 2}, StringifyOptions{SliceSeparator: " "}, "1 2"},
+		{[]string{}, StringifyOptions{SliceSeparator: ","}, ""},
+		{[]time.Duration{time.Second, time.Minute}, StringifyOptions{SliceSeparator: ", ", HumanizeDurations: true}, "1 second, 1 minute"},
+	}
+	for i, test := range tests {
+		if result := StringifyWith(test.value, test.opts); result != test.expect {
+			t.Errorf("test %d: StringifyWith(%#v, %+v) = %q, expected %q", i, test.value, test.opts, result, test.expect)
+		}
+	}
+	if result := Stringify([]int{1, 2}); result != "[1,2]" {
+		t.Errorf("Stringify([]int{1, 2}) = %q, expected %q", result, "[1,2]")
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	type named []int
+	var nilInts []int
+	tests := []struct {
+		value  interface{}
+		expect string
+	}{
+		{1, "int"},
+		{new(float64), "float64"},
+		{"", "string"},
+		{time.Second, "duration"},
+		{time.Time{}, "time"},
+		{nilInts, "int slice"},
+		{&[]string{}, "string slice"},
+		{[]time.Duration{}, "duration slice"},
+		{[][]bool{}, "bool slice slice"},
+		{registeredPoint{}, "generic.registeredPoint"},
+		{named{}, "generic.named"},
+		{map[string]int{}, "map[string]int"},
+		{nil, "nil"},
+	}
+	for _, test := range tests {
+		if result := TypeName(test.value); result != test.expect {
+			t.Errorf("TypeName(%#v) = %q, expected %q", test.value, result, test.expect)
+		}
+	}
+}
+
+func TestFromStringIntegerBases(t *testing.T) {
+	tests := []struct {
+		s      string
+		expect int64
+	}{
+		{"255", 255},
+		{"0xFF", 255},
+		{"0Xff", 255},
+		{"0o17", 15},
+		{"017", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0xFF_FF", 65535},
+		{"-0x10", -16},
+	}
+	for _, test := range tests {
+		var i int
+		var i64 int64
+		var u uint
+		var u64 uint64
+		for _, ptr := range []interface{}{&i, &i64, &u, &u64} {
+			if test.expect < 0 && (ptr == &u || ptr == &u64) {
+				continue
+			}
+			if err := FromString(test.s, ptr); err != nil {
+				t.Errorf("FromString(%q, %T) returned %v", test.s, ptr, err)
+			}
+		}
+		if int64(i) != test.expect || i64 != test.expect {
+			t.Errorf("FromString(%q) = %d, %d, expected %d", test.s, i, i64, test.expect)
+		}
+		if test.expect >= 0 && (int64(u) != test.expect || int64(u64) != test.expect) {
+			t.Errorf("FromString(%q) = %d, %d, expected %d", test.s, u, u64, test.expect)
+		}
+		slice, err := Convert([]int{}, test.s)
+		if err != nil || !Equal(slice, []int{int(test.expect)}) {
+			t.Errorf("Convert([]int{}, %q) = %v, %v, expected [%d]", test.s, slice, err, test.expect)
+		}
+	}
+	for _, s := range []string{"1__000", "_1", "0xG", "0b102", "1_"} {
+		var i int
+		if err := FromString(s, &i); err == nil {
+			t.Errorf("FromString(%q) expected an error, got %d", s, i)
+		}
+	}
+}
+
+type registeredPoint struct {
+	X, Y int
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType(registeredPoint{}, func(s string) (interface{}, error) {
+		var p registeredPoint
+		if _, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}, nil)
+
+	typ, ok := LookupType("generic.registeredPoint")
+	if !ok || typ != reflect.TypeOf(registeredPoint{}) {
+		t.Fatalf("LookupType returned %v, %v", typ, ok)
+	}
+	typ, ok = LookupType("[]generic.registeredPoint")
+	if !ok || typ != reflect.TypeOf([]registeredPoint{}) {
+		t.Fatalf("LookupType of slice returned %v, %v", typ, ok)
+	}
+	if _, ok := LookupType("generic.unknown"); ok {
+		t.Error("expected an unknown type not to be found")
+	}
+
+	var p registeredPoint
+	if err := FromString("1,2", &p); err != nil || p != (registeredPoint{1, 2}) {
+		t.Errorf("FromString returned %v, %v", p, err)
+	}
+}
+
+func TestISO8601Duration(t *testing.T) {
+	tests := []struct {
+		s      string
+		expect time.Duration
+		format string
+	}{
+		{"P1D", 24 * time.Hour, "P1D"},
+		{"P1DT2H", 26 * time.Hour, "P1DT2H"},
+		{"PT1H30M", 90 * time.Minute, "PT1H30M"},
+		{"P2W", 14 * 24 * time.Hour, "P14D"},
+		{"PT0.5S", 500 * time.Millisecond, "PT0.5S"},
+		{"PT1,25S", 1250 * time.Millisecond, "PT1.25S"},
+		{"PT0S", 0, "PT0S"},
+		{"-PT90S", -90 * time.Second, "-PT1M30S"},
+		{"p1dt1m", 24*time.Hour + time.Minute, "P1DT1M"},
+	}
+	for _, test := range tests {
+		d, err := ParseISO8601Duration(test.s)
+		if err != nil || d != test.expect {
+			t.Errorf("ParseISO8601Duration(%q) = %v, %v, expected %v", test.s, d, err, test.expect)
+		}
+		if s := FormatISO8601Duration(d); s != test.format {
+			t.Errorf("FormatISO8601Duration(%v) = %q, expected %q", d, s, test.format)
+		}
+	}
+	for _, s := range []string{"", "P", "PT", "1D", "P1H", "PT1D", "P1DT", "PT1S1M", "P1D1D", "PTH", "P1.2.3D", "P99999999999D", "1h"} {
+		if d, err := ParseISO8601Duration(s); err == nil {
+			t.Errorf("ParseISO8601Duration(%q) = %v, expected an error", s, d)
+		}
+	}
+	for _, s := range []string{"P1Y", "P1M", "P1Y2M3D"} {
+		_, err := ParseISO8601Duration(s)
+		if err == nil || !strings.Contains(err.Error(), "years or months") {
+			t.Errorf("ParseISO8601Duration(%q) = %v, expected a years or months error", s, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package generic
 
 import (
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,11 +15,7 @@ func init() {
 		return s, nil
 	}
 	FromStringMap["bool"] = func(s string) (interface{}, error) {
-		if s == "" {
-			s = "false"
-		}
-		v, err := strconv.ParseBool(s)
-		return bool(v), err
+		return ParseBool(s)
 	}
 	FromStringMap["int"] = func(s string) (interface{}, error) {
 		v, err := strconv.ParseInt(s, 0, strconv.IntSize)
@@ -55,3 +52,17 @@ func init() {
 		return nil, errParse
 	}
 }
+
+// ParseBool parses the tokens accepted wherever a bool is converted from a
+// string, such as for bool and bool slice flags and their environment
+// variables, which are those accepted by strconv.ParseBool along with yes,
+// no, y, n, on and off in any case. An empty string is false.
+func ParseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "0", "f", "false", "n", "no", "off":
+		return false, nil
+	case "1", "t", "true", "y", "yes", "on":
+		return true, nil
+	}
+	return false, &strconv.NumError{Func: "ParseBool", Num: s, Err: strconv.ErrSyntax}
+}
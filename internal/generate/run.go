@@ -3,7 +3,6 @@ package main
 import (
 	"flag"
 	"fmt"
-	"html/template"
 	"io/ioutil"
 	"log"
 	"os"
@@ -11,6 +10,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"text/template"
 )
 
 // ReplaceTemplate is the value of filename text to replace
@@ -44,9 +44,132 @@ type data struct {
 	Elem       string
 	Name       string
 	Title      string
-	LongName   string
 	IsSlice    bool
 	TakesValue bool
+
+	// Wrapper is set for the WrapperTypes, which have the types of their
+	// Value and Destination fields given with the doc comments of the
+	// flag and of the Value, and the packages the types are imported from
+	Wrapper     bool     `replace:"-"`
+	Value       string   `replace:"-"`
+	Destination string   `replace:"-"`
+	Doc         []string `replace:"-"`
+	ValueDoc    []string `replace:"-"`
+	Imports     []string `replace:"-"`
+}
+
+// WrapperTypes are the flags which have a flag.Value of their own to
+// parse values, by the directory of the template to generate them from.
+// Only the struct of each flag is generated, so that each has all of the
+// fields common to flags.
+var WrapperTypes = map[string][]data{
+	"cli": {
+		{
+			Name:        "generic",
+			Title:       "Generic",
+			Value:       "Generic",
+			Destination: "Generic",
+			Doc:         []string{"GenericFlag is a flag with type flag.Value"},
+		},
+		{
+			Name:        "path",
+			Title:       "Path",
+			Value:       "string",
+			Destination: "*string",
+			Doc: []string{
+				"PathFlag is a flag with type string for a file system path. Expanding",
+				"the path and checking that it exists are separate options, so a path",
+				"which is created by the App may still be expanded.",
+			},
+		},
+		{
+			Name:        "bytes",
+			Title:       "Bytes",
+			Value:       "string",
+			Destination: "*[]byte",
+			Doc: []string{
+				"BytesFlag is a flag with type []byte, for values such as keys or binary",
+				"payloads which are decoded according to the Encoding of the flag",
+			},
+			ValueDoc: []string{
+				"Value is the default value encoded as it would be given on the",
+				"command line",
+			},
+		},
+		{
+			Name:        "ipNetSlice",
+			Title:       "IPNetSlice",
+			Value:       "[]string",
+			Destination: "*[]*net.IPNet",
+			IsSlice:     true,
+			Doc: []string{
+				"IPNetSliceFlag is a flag with type []*net.IPNet for CIDR blocks, such as",
+				"--allow 10.0.0.0/8,192.168.0.0/16",
+			},
+			ValueDoc: []string{
+				"Value is the default CIDR blocks as they would be given on the",
+				"command line",
+			},
+			Imports: []string{"net"},
+		},
+		{
+			Name:        "stringMap",
+			Title:       "StringMap",
+			Value:       "[]string",
+			Destination: "*map[string]string",
+			Doc: []string{
+				"StringMapFlag is a flag with type map[string]string for key=value pairs,",
+				"such as --label env=prod,team=web",
+			},
+			ValueDoc: []string{
+				"Value is the default key=value pairs as they would be given on the",
+				"command line",
+			},
+		},
+		{
+			Name:        "stringSet",
+			Title:       "StringSet",
+			Value:       "StringSet",
+			Destination: "*StringSet",
+			IsSlice:     true,
+			Doc: []string{
+				"StringSetFlag is a flag with type StringSet, such as --enable a,b,c where",
+				"each value is kept once in the order first given",
+			},
+		},
+		{
+			Name:        "deadline",
+			Title:       "Deadline",
+			Value:       "string",
+			Destination: "*time.Time",
+			Doc: []string{
+				"DeadlineFlag is a flag with type time.Time which accepts either a duration",
+				"relative to the current time, such as \"30s\", or an absolute timestamp.",
+				"A value is first parsed as a duration and added to the current time, and",
+				"only if that fails is it parsed as a timestamp using generic.TimeLayouts,",
+				"so a value which is a valid duration is never treated as a timestamp.",
+			},
+			ValueDoc: []string{
+				"Value is the default deadline as it would be given on the command",
+				"line, a relative default is resolved when the flag is applied",
+			},
+			Imports: []string{"time"},
+		},
+		{
+			Name:        "json",
+			Title:       "JSON",
+			Value:       "string",
+			Destination: "interface{}",
+			Doc: []string{
+				"JSONFlag is a flag whose value is a JSON document decoded with",
+				"json.Unmarshal into Destination, such as --filter '{\"k\":\"v\"}'",
+			},
+			ValueDoc: []string{
+				"Value is the default value as a JSON document, and Destination is",
+				"a pointer to the value to decode into, defaults to a new interface{}",
+			},
+		},
+	},
 }
 
 var fields []string
@@ -55,6 +178,9 @@ func init() {
 	st := reflect.TypeOf(data{})
 	for i := 0; i < st.NumField(); i++ {
 		field := st.Field(i)
+		if field.Tag.Get("replace") == "-" {
+			continue
+		}
 		fields = append(fields, field.Name)
 	}
 }
@@ -92,27 +218,31 @@ func Generate(path string) error {
 
 		if strings.Contains(info.Name(), ReplaceTemplate) {
 			for _, t := range strings.Split(types, ",") {
-				if err := genTemplate(path, t); err != nil {
+				if err := genTemplate(path, genTemplateInfo(path, t)); err != nil {
 					return err
 				}
 				if GenSlice && !strings.HasPrefix(t, "[]") {
-					if err := genTemplate(path, "[]"+t); err != nil {
+					if err := genTemplate(path, genTemplateInfo(path, "[]"+t)); err != nil {
 						return err
 					}
 				}
 			}
+			for _, w := range WrapperTypes[filepath.Dir(path)] {
+				w.Wrapper = true
+				if err := genTemplate(path, w); err != nil {
+					return err
+				}
+			}
 		}
 
 		return nil
 	})
 }
 
-func genTemplate(path, typeString string) error {
+func genTemplate(path string, templateInfo data) error {
 	dir := filepath.Dir(path)
 	base := filepath.Base(path)
 
-	templateInfo := genTemplateInfo(path, typeString)
-
 	replacementText := fmt.Sprintf(".zz_generated_%s", templateInfo.Name)
 	generatedPath := strings.TrimPrefix(base, "_")
 	generatedPath = strings.ReplaceAll(generatedPath, ReplaceTemplate, replacementText)
@@ -154,11 +284,9 @@ func genTemplateInfo(path, t string) data {
 	}
 
 	isSliceInfo := false
-	longNameInfo := nameInfo
 
 	if strings.HasPrefix(typeInfo, "[]") {
 		nameInfo = fmt.Sprintf("%sSlice", nameInfo)
-		longNameInfo = fmt.Sprintf("%s slice", longNameInfo)
 		isSliceInfo = true
 	}
 
@@ -169,7 +297,6 @@ func genTemplateInfo(path, t string) data {
 		Elem:       elemInfo,
 		Name:       nameInfo,
 		Title:      titleInfo,
-		LongName:   longNameInfo,
 		IsSlice:    isSliceInfo,
 		TakesValue: elemInfo != "bool",
 	}